@@ -1,6 +1,11 @@
 package v1alpha1
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -8,6 +13,71 @@ import (
 // Facts is a map of arbitrary facts for the cluster
 type Facts map[string]string
 
+// FactType defines the type of the value of a fact
+type FactType string
+
+const (
+	StringFactType  FactType = "string"
+	IntegerFactType FactType = "integer"
+	BooleanFactType FactType = "boolean"
+)
+
+// FactSchema declares the allowed facts of clusters
+type FactSchema struct {
+	// Facts declares the allowed facts by their key.
+	Facts map[string]FactDefinition `json:"facts,omitempty"`
+	// Strict makes facts which aren't declared in the schema a violation.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// FactDefinition declares the allowed values of a fact
+type FactDefinition struct {
+	// Type of the value of the fact. Defaults to string.
+	// +kubebuilder:validation:Enum=string;integer;boolean
+	Type FactType `json:"type,omitempty"`
+	// Enum restricts the value to one of the given values.
+	Enum []string `json:"enum,omitempty"`
+	// Pattern is a regular expression the value must match.
+	Pattern string `json:"pattern,omitempty"`
+	// Required facts must be set by the cluster, by Steward or by the tenant.
+	Required bool `json:"required,omitempty"`
+}
+
+const (
+	// ConditionFactsValid is true if the facts of a cluster match the fact schema of its tenant.
+	ConditionFactsValid = "FactsValid"
+	// ConditionExpiring is true if an ephemeral cluster expires soon or has expired.
+	ConditionExpiring = "Expiring"
+	// ConditionVaultAccess is true if the Vault policy and auth role of a cluster are provisioned.
+	ConditionVaultAccess = "VaultAccess"
+)
+
+// ClusterPhase is the lifecycle phase of a cluster
+type ClusterPhase string
+
+const (
+	// DecommissioningPhase is the phase of a cluster which is being decommissioned.
+	DecommissioningPhase ClusterPhase = "Decommissioning"
+	// DecommissionedPhase is the phase of a cluster which was decommissioned and can be deleted.
+	DecommissionedPhase ClusterPhase = "Decommissioned"
+)
+
+// DecommissionStage is a stage of decommissioning a cluster
+type DecommissionStage string
+
+const (
+	// RevokeAccessStage removes the deploy keys and revokes the access tokens of the catalog repository and invalidates the bootstrap token.
+	RevokeAccessStage DecommissionStage = "RevokeAccess"
+	// RecordArchiveStage writes the archive record of the cluster.
+	RecordArchiveStage DecommissionStage = "RecordArchive"
+	// RemoveSecretsStage removes the Vault secrets of the cluster.
+	RemoveSecretsStage DecommissionStage = "RemoveSecrets"
+	// ArchiveRepositoryStage archives the catalog repository.
+	ArchiveRepositoryStage DecommissionStage = "ArchiveRepository"
+	// DoneStage is reached once all stages are complete.
+	DoneStage DecommissionStage = "Done"
+)
+
 // ClusterSpec defines the desired state of Cluster
 type ClusterSpec struct {
 	// DisplayName of cluster which could be different from metadata.name. Allows cluster renaming should it be needed.
@@ -41,17 +111,74 @@ type ClusterSpec struct {
 	CreationPolicy CreationPolicy `json:"creationPolicy,omitempty"`
 	// EnableCompilePipeline determines whether the gitops compile pipeline should be set up for this cluster
 	EnableCompilePipeline bool `json:"enableCompilePipeline,omitempty"`
+	// Decommission starts decommissioning the cluster.
+	// Access to the catalog repository is revoked, an archive record is written, the Vault secrets are removed and the catalog repository is archived.
+	// The cluster can't be deleted until decommissioning is complete.
+	// Decommissioning can't be aborted once started.
+	Decommission bool `json:"decommission,omitempty"`
+	// TTL makes the cluster ephemeral. The cluster expires once the duration has passed since its creation, for example `72h`.
+	// An expired cluster is deleted with the ephemeral deletion policy of the operator, regardless of its delete protection.
+	TTL string `json:"ttl,omitempty"`
+	// ExpiresAt makes the cluster ephemeral. The cluster expires at the given time.
+	// It takes precedence over TTL.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// SecretTemplates declares secrets which are generated by the operator and stored in Vault below the path of the cluster.
+	// +listType=map
+	// +listMapKey=pathSuffix
+	SecretTemplates []SecretTemplate `json:"secretTemplates,omitempty"`
+}
+
+// GeneratedSecretType is the type of a generated secret
+type GeneratedSecretType string
+
+const (
+	// PasswordSecret is a random alphanumeric password stored in the key `password`.
+	PasswordSecret GeneratedSecretType = "Password"
+	// SSHKeySecret is an Ed25519 SSH key pair stored in the keys `privateKey` and `publicKey`.
+	SSHKeySecret GeneratedSecretType = "SSHKey"
+	// AgeKeySecret is an age X25519 key pair stored in the keys `identity` and `recipient`.
+	AgeKeySecret GeneratedSecretType = "AgeKey"
+)
+
+// SecretTemplate declares a secret which is generated once and stored in Vault below the path of the cluster
+type SecretTemplate struct {
+	// PathSuffix is the path of the secret relative to the Vault path of the cluster.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(/[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`
+	PathSuffix string `json:"pathSuffix"`
+	// Type is the type of the generated secret.
+	// +kubebuilder:validation:Enum=Password;SSHKey;AgeKey
+	// +kubebuilder:default=Password
+	Type GeneratedSecretType `json:"type,omitempty"`
+	// Length is the length of a generated password. It defaults to 32.
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=256
+	Length int `json:"length,omitempty"`
+	// RotationPeriod is the duration after which the secret is generated again, for example `720h`.
+	// The secret is never generated again if it's empty.
+	RotationPeriod string `json:"rotationPeriod,omitempty"`
 }
 
 // BootstrapToken this key is used only once for Steward to register.
 type BootstrapToken struct {
-	// Token is the actual token to register the cluster
+	// Token is the actual token to register the cluster.
+	//
+	// Deprecated: New tokens aren't stored in the status anymore.
+	// The operator copies existing tokens to the secret referenced by SecretRef and removes them from the status once they're invalid.
 	Token string `json:"token,omitempty"`
+	// TokenHash is the hex encoded SHA256 hash of the token.
+	TokenHash string `json:"tokenHash,omitempty"`
+	// SecretRef is the name of the secret containing the plaintext token in the key `token`.
+	// The secret is deleted once the token was used or expired.
+	SecretRef string `json:"secretRef,omitempty"`
 	// ValidUntil timespan how long the token is valid. If the token is
 	// used after this timestamp it will be rejected.
 	ValidUntil metav1.Time `json:"validUntil,omitempty"`
 	// TokenValid indicates if the token is still valid or was already used.
 	TokenValid bool `json:"tokenValid,omitempty"`
+	// LastRegeneration is the time the token was last regenerated on request.
+	LastRegeneration *metav1.Time `json:"lastRegeneration,omitempty"`
+	// RegenerationRequest is the value of the regenerate bootstrap token annotation the token was last issued for.
+	RegenerationRequest string `json:"regenerationRequest,omitempty"`
 }
 
 // ClusterStatus defines the observed state of Cluster
@@ -60,8 +187,80 @@ type ClusterStatus struct {
 	BootstrapToken *BootstrapToken `json:"bootstrapToken,omitempty"`
 	// Facts are key/value pairs for dynamically fetched facts
 	Facts Facts `json:"facts,omitempty"`
+	// EffectiveFacts are the facts of the cluster merged with the facts inherited from the tenant.
+	// Static facts take precedence over dynamic facts, which take precedence over the facts of the tenant.
+	EffectiveFacts Facts `json:"effectiveFacts,omitempty"`
+	// Conditions contains the current conditions of the cluster.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	// CompileMeta contains information about the last compilation with Commodore.
 	CompileMeta CompileMeta `json:"compileMeta,omitempty"`
+	// Tenant is the name of the tenant the resources of the cluster belong to.
+	// It differs from spec.tenantRef while the cluster is moved to another tenant.
+	Tenant string `json:"tenant,omitempty"`
+	// Phase is the lifecycle phase of the cluster. It's empty for active clusters.
+	Phase ClusterPhase `json:"phase,omitempty"`
+	// Decommission contains the progress of decommissioning the cluster.
+	Decommission *DecommissionStatus `json:"decommission,omitempty"`
+	// TenantMigration tracks the progress of moving the cluster to another tenant.
+	// It's removed once the move is complete.
+	TenantMigration *TenantMigration `json:"tenantMigration,omitempty"`
+	// ExpiresAt is the time an ephemeral cluster expires. It's empty for clusters which don't expire.
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// VaultSecretPath is the path of the Vault secret containing the Steward token.
+	// It's used to move the secrets of the cluster if the secret layout changes.
+	VaultSecretPath string `json:"vaultSecretPath,omitempty"`
+	// ServiceAccountTokenExpiresAt is the time the Steward token stored in Vault expires.
+	// It's only set for tokens requested with the TokenRequest API.
+	ServiceAccountTokenExpiresAt *metav1.Time `json:"serviceAccountTokenExpiresAt,omitempty"`
+	// GeneratedSecrets contains the secrets generated from the secret templates.
+	// +listType=map
+	// +listMapKey=pathSuffix
+	GeneratedSecrets []GeneratedSecretStatus `json:"generatedSecrets,omitempty"`
+}
+
+// GeneratedSecretStatus records a secret generated from a secret template
+type GeneratedSecretStatus struct {
+	// PathSuffix is the path of the secret relative to the Vault path of the cluster.
+	PathSuffix string `json:"pathSuffix"`
+	// Type is the type of the generated secret.
+	Type GeneratedSecretType `json:"type,omitempty"`
+	// GeneratedAt is the time the secret was last generated.
+	GeneratedAt metav1.Time `json:"generatedAt"`
+}
+
+// DecommissionStatus contains the progress of decommissioning a cluster
+type DecommissionStatus struct {
+	// StartedAt is the time decommissioning was started.
+	StartedAt metav1.Time `json:"startedAt,omitempty"`
+	// CompletedAt is the time decommissioning was completed.
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+	// Stage is the current stage.
+	// +kubebuilder:validation:Enum=RevokeAccess;RecordArchive;RemoveSecrets;ArchiveRepository;Done
+	Stage DecommissionStage `json:"stage,omitempty"`
+	// CatalogCommit is the SHA of the final commit of the catalog repository.
+	CatalogCommit string `json:"catalogCommit,omitempty"`
+	// ArchiveRef is the name of the ConfigMap containing the archive record of the cluster.
+	// The ConfigMap is owned by the tenant and outlives the cluster.
+	ArchiveRef string `json:"archiveRef,omitempty"`
+}
+
+// TenantMigration tracks the progress of moving a cluster to another tenant
+type TenantMigration struct {
+	// From is the name of the tenant the cluster is moved from.
+	From string `json:"from"`
+	// To is the name of the tenant the cluster is moved to.
+	To string `json:"to"`
+	// StartedAt is the time the move was started.
+	StartedAt metav1.Time `json:"startedAt,omitempty"`
+	// VaultSecretsMoved is true once the Vault secrets of the cluster were moved to the path of the new tenant.
+	VaultSecretsMoved bool `json:"vaultSecretsMoved,omitempty"`
+	// SourceTenantUpdated is true once the repository files, the Role and the compile pipeline of the old tenant no longer reference the cluster.
+	SourceTenantUpdated bool `json:"sourceTenantUpdated,omitempty"`
+	// TargetTenantUpdated is true once the repository files and the Role of the new tenant reference the cluster.
+	TargetTenantUpdated bool `json:"targetTenantUpdated,omitempty"`
 }
 
 // CompileMeta contains information about the last compilation with Commodore.
@@ -109,6 +308,8 @@ type CompileMetaInstanceVersionInfo struct {
 // +kubebuilder:resource:path=clusters,scope=Namespaced
 // +kubebuilder:printcolumn:name="Display Name",type="string",JSONPath=".spec.displayName"
 // +kubebuilder:printcolumn:name="Tenant",type="string",JSONPath=".spec.tenantRef.name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Expires At",type="date",JSONPath=".status.expiresAt",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type Cluster struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -177,6 +378,58 @@ func (c *Cluster) GetStatus() interface{} {
 	return c.Status
 }
 
+// GetBootstrapTokenSecretName returns the name of the secret containing the plaintext bootstrap token
+func (c *Cluster) GetBootstrapTokenSecretName() string {
+	return c.Name + "-bootstrap-token"
+}
+
+// DecommissionRequested returns true if decommissioning the cluster was requested by spec or annotation, or is already in progress.
+func (c *Cluster) DecommissionRequested() bool {
+	return c.Spec.Decommission || c.Annotations[DecommissionAnnotation] == "true" || c.Status.Decommission != nil
+}
+
+// GetExpiry returns the time the cluster expires.
+// The time is zero if the cluster isn't ephemeral.
+func (c *Cluster) GetExpiry() (time.Time, error) {
+	if c.Spec.ExpiresAt != nil {
+		return c.Spec.ExpiresAt.Time, nil
+	}
+	if c.Spec.TTL == "" {
+		return time.Time{}, nil
+	}
+	ttl, err := time.ParseDuration(c.Spec.TTL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return c.CreationTimestamp.Add(ttl), nil
+}
+
+// HashBootstrapToken returns the hash of a bootstrap token as stored in the status of a cluster
+func HashBootstrapToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Validate returns true if the given plaintext token matches the hash of the bootstrap token and the token is still valid at the given time.
+func (t *BootstrapToken) Validate(token string, now time.Time) bool {
+	if t == nil || !t.TokenValid || t.TokenHash == "" || now.After(t.ValidUntil.Time) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashBootstrapToken(token)), []byte(t.TokenHash)) == 1
+}
+
 func (c *Cluster) GetEnableCompilePipeline() bool {
 	return c.Spec.EnableCompilePipeline
 }
+
+// ComputeEffectiveFacts returns the facts of the cluster merged with the given facts inherited from the tenant.
+// Static facts take precedence over dynamic facts, which take precedence over the facts of the tenant.
+func (c *Cluster) ComputeEffectiveFacts(tenantFacts Facts) Facts {
+	facts := Facts{}
+	for _, f := range []Facts{tenantFacts, c.Status.Facts, c.Spec.Facts} {
+		for k, v := range f {
+			facts[k] = v
+		}
+	}
+	return facts
+}
@@ -7,4 +7,44 @@ const (
 
 	// DeleteProtectionAnnotation defines the delete protection annotation name
 	DeleteProtectionAnnotation = "syn.tools/protected-delete"
+
+	// TenantTemplateAnnotation lists the names of the TenantTemplates which were applied to a tenant, in order of precedence.
+	TenantTemplateAnnotation = "lieutenant.syn.tools/tenant-template"
+	// TenantTemplatesAnnotation selects TenantTemplates for a tenant by name.
+	// The value is a comma separated list of template names, the first template takes precedence.
+	TenantTemplatesAnnotation = "lieutenant.syn.tools/tenant-templates"
+	// TenantTemplateSelectorAnnotation selects TenantTemplates for a tenant by a label selector.
+	TenantTemplateSelectorAnnotation = "lieutenant.syn.tools/tenant-template-selector"
+	// TenantTemplatePriorityAnnotation defines the priority of a TenantTemplate selected by a label selector.
+	// Templates with a higher priority take precedence.
+	TenantTemplatePriorityAnnotation = "lieutenant.syn.tools/tenant-template-priority"
+	// StrictTemplatesAnnotation makes rendering the cluster template of a tenant fail if a referenced map key is missing.
+	StrictTemplatesAnnotation = "lieutenant.syn.tools/strict-templates"
+	// DryRenderClusterAnnotation names a cluster of a tenant for which the cluster template is rendered without applying it.
+	// The changes the template would make are reported in the status of the tenant.
+	DryRenderClusterAnnotation = "lieutenant.syn.tools/dry-render-cluster"
+	// FactLabelsAnnotation lists the labels of a cluster which are managed by the operator to mirror its facts.
+	FactLabelsAnnotation = "lieutenant.syn.tools/fact-labels"
+	// RegenerateBootstrapTokenAnnotation requests a new bootstrap token for a cluster.
+	// A new token is issued whenever the value of the annotation changes.
+	RegenerateBootstrapTokenAnnotation = "lieutenant.syn.tools/regenerate-bootstrap-token"
+	// DecommissionAnnotation requests decommissioning a cluster if set to true.
+	DecommissionAnnotation = "lieutenant.syn.tools/decommission"
+	// ForceDeleteAnnotation allows deleting a tenant while clusters still reference it if set to true.
+	ForceDeleteAnnotation = "lieutenant.syn.tools/force-delete"
+	// ConfirmDeleteAnnotation confirms the deletion of a protected object if its value equals the name of the object.
+	ConfirmDeleteAnnotation = "lieutenant.syn.tools/confirm-delete"
+	// UndeleteAnnotation releases a deleted object without touching its external resources if set to true.
+	// It's only honored while the deletion is blocked by the deletion protection or the deletion grace period.
+	UndeleteAnnotation = "lieutenant.syn.tools/undelete"
+	// LieutenantAccessTokenUIDAnnotation holds the UID of the project access token stored in the access token secret of a GitRepo.
+	LieutenantAccessTokenUIDAnnotation = "lieutenant.syn.tools/accessTokenUID"
+	// LieutenantAccessTokenExpiresAtAnnotation holds the time at which the project access token stored in the access token secret of a GitRepo expires.
+	LieutenantAccessTokenExpiresAtAnnotation = "lieutenant.syn.tools/accessTokenExpiresAt"
+	// DeployKeyGeneratedAtAnnotation holds the time at which the key of a generated deploy key secret was generated
+	DeployKeyGeneratedAtAnnotation = "lieutenant.syn.tools/deployKeyGeneratedAt"
+	// SharedSecretLabel marks a Secret which may be mirrored into Vault as a shared secret of a tenant if set to true.
+	SharedSecretLabel = "lieutenant.syn.tools/shared-secret"
+	// DefaultTenantTemplateName is the name of the TenantTemplate applied if a tenant doesn't select any templates.
+	DefaultTenantTemplateName = "default"
 )
@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -38,6 +40,16 @@ const (
 // GitPhase is the enum for the git phase status
 type GitPhase string
 
+// GitRepoDecommission defines how far a repository is decommissioned
+type GitRepoDecommission string
+
+const (
+	// RevokeAccessDecommission removes the deploy keys and revokes the project access tokens of the repository.
+	RevokeAccessDecommission GitRepoDecommission = "RevokeAccess"
+	// ArchiveDecommission additionally archives the repository.
+	ArchiveDecommission GitRepoDecommission = "Archive"
+)
+
 // GitType as the enum for git types
 type GitType string
 
@@ -55,6 +67,11 @@ type GitRepoSpec struct {
 	GitRepoTemplate `json:",inline"`
 	// TenantRef references the tenant this repo belongs to
 	TenantRef corev1.LocalObjectReference `json:"tenantRef,omitempty"`
+	// Decommission revokes all access to the repository.
+	// RevokeAccess: removes the deploy keys and revokes the project access tokens, template files and CI variables aren't updated anymore.
+	// Archive: additionally archives the repository. An archived repository is retained when the GitRepo is deleted.
+	// +kubebuilder:validation:Enum=RevokeAccess;Archive
+	Decommission GitRepoDecommission `json:"decommission,omitempty"`
 }
 
 // GitRepoTemplate is used for templating git repos, it does not contain the tenantRef as it will be added by the
@@ -161,6 +178,9 @@ type DeployKeyTemplate struct {
 	Type string `json:"type,omitempty"`
 	// WriteAccess if the key has RW access or not
 	WriteAccess bool `json:"writeAccess,omitempty"`
+	// RotationPeriod is the duration after which a generated key is replaced by a new key, for example `2160h`.
+	// Generated keys aren't rotated if it's empty.
+	RotationPeriod string `json:"rotationPeriod,omitempty"`
 }
 
 // DeployKeyStatus tracks the status for a generated Deploy Key
@@ -185,6 +205,18 @@ type GitRepoStatus struct {
 	LastAppliedCIVariables string `json:"lastAppliedCIVariables,omitempty"`
 	// GeneratedDeployKeys contains all SSH deploy keys that were generated for the git repo
 	GeneratedDeployKeys map[string]DeployKeyStatus `json:"generatedDeployKeys,omitempty"`
+	// Decommission contains the progress of decommissioning the repository.
+	Decommission *GitRepoDecommissionStatus `json:"decommission,omitempty"`
+}
+
+// GitRepoDecommissionStatus contains the progress of decommissioning a repository
+type GitRepoDecommissionStatus struct {
+	// AccessRevoked is true once the deploy keys were removed and the project access tokens were revoked.
+	AccessRevoked bool `json:"accessRevoked,omitempty"`
+	// LastCommit is the SHA of the latest commit on the default branch after access was revoked.
+	LastCommit string `json:"lastCommit,omitempty"`
+	// Archived is true once the repository was archived.
+	Archived bool `json:"archived,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -258,3 +290,12 @@ func (g *GitRepo) GetMeta() metav1.ObjectMeta {
 func (g *GitRepo) GetStatus() interface{} {
 	return g.Status
 }
+
+// DeployKeyGeneratedAt returns the time at which the key in a generated deploy key secret was generated.
+// Secrets created before the time was recorded fall back to their creation time.
+func DeployKeyGeneratedAt(secret *corev1.Secret) time.Time {
+	if t, err := time.Parse(time.RFC3339, secret.Annotations[DeployKeyGeneratedAtAnnotation]); err == nil {
+		return t
+	}
+	return secret.CreationTimestamp.Time
+}
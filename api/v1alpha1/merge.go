@@ -0,0 +1,280 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"dario.cat/mergo"
+)
+
+// MergeStrategyType defines how a field of a template is merged into an object
+type MergeStrategyType string
+
+const (
+	DefaultMergeStrategy     MergeStrategyType = "Default"
+	OverrideMergeStrategy    MergeStrategyType = "Override"
+	EnforceMergeStrategy     MergeStrategyType = "Enforce"
+	AppendMergeStrategy      MergeStrategyType = "Append"
+	MergeByNameMergeStrategy MergeStrategyType = "MergeByName"
+)
+
+// MergeStrategy defines how a single field of a template is merged into an object
+type MergeStrategy struct {
+	// Path of the field relative to the spec, using the YAML field names separated by dots.
+	// For example `deletionPolicy`, `gitRepoTemplate.ciVariables` or `facts.cloud`.
+	// +required
+	Path string `json:"path"`
+	// Strategy defines how the field is merged.
+	// Default: the value of the object takes precedence, maps are merged key by key.
+	// Override: the value of the template takes precedence if it's set, maps are merged key by key.
+	// Enforce: the value of the template replaces the value of the object, even if it's not set.
+	// Append: the list items of the template are appended to the list of the object, unless the object already contains an item with the same name, or an equal item if the items have no name.
+	// MergeByName: the list items of the template replace the items of the object with the same name, other items are appended.
+	// +kubebuilder:validation:Enum=Default;Override;Enforce;Append;MergeByName
+	// +required
+	Strategy MergeStrategyType `json:"strategy"`
+}
+
+// MergeWithStrategies recursively merges src into dst.
+// By default the values of dst take precedence, the given strategies change this for individual fields.
+// dst must be a pointer to a struct, src a struct or a pointer to a struct of the same type.
+func MergeWithStrategies(dst, src interface{}, strategies []MergeStrategy) error {
+	return mergeWithStrategies(dst, src, strategies, nil)
+}
+
+// mergeWithStrategies merges src into dst like MergeWithStrategies.
+// The fields at the fixed paths keep the value of dst, even if dst doesn't set them.
+func mergeWithStrategies(dst, src interface{}, strategies []MergeStrategy, fixed []string) error {
+	orig, err := toUnstructuredMap(dst)
+	if err != nil {
+		return err
+	}
+	if err := mergo.Merge(dst, src); err != nil {
+		return err
+	}
+	if len(strategies) == 0 && len(fixed) == 0 {
+		return nil
+	}
+
+	tmpl, err := toUnstructuredMap(src)
+	if err != nil {
+		return err
+	}
+	merged, err := toUnstructuredMap(dst)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range strategies {
+		if err := applyMergeStrategy(merged, orig, tmpl, s); err != nil {
+			return fmt.Errorf("merge strategy %s for %q: %w", s.Strategy, s.Path, err)
+		}
+	}
+	for _, f := range fixed {
+		path := strings.Split(f, ".")
+		v, _ := lookupPath(orig, path)
+		if err := setPath(merged, path, v); err != nil {
+			return fmt.Errorf("keep field %q: %w", f, err)
+		}
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(dst).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return json.Unmarshal(raw, dst)
+}
+
+func applyMergeStrategy(merged, orig, tmpl map[string]interface{}, s MergeStrategy) error {
+	path := strings.Split(s.Path, ".")
+	objVal, _ := lookupPath(orig, path)
+	tmplVal, tmplOk := lookupPath(tmpl, path)
+
+	switch s.Strategy {
+	case DefaultMergeStrategy, "":
+		return nil
+	case OverrideMergeStrategy:
+		if !tmplOk {
+			return nil
+		}
+		return setPath(merged, path, overrideValue(objVal, tmplVal))
+	case EnforceMergeStrategy:
+		if !tmplOk {
+			return setPath(merged, path, nil)
+		}
+		return setPath(merged, path, tmplVal)
+	case AppendMergeStrategy, MergeByNameMergeStrategy:
+		objList, ok := asList(objVal)
+		if !ok {
+			return fmt.Errorf("field of the object is not a list")
+		}
+		tmplList, ok := asList(tmplVal)
+		if !ok {
+			return fmt.Errorf("field of the template is not a list")
+		}
+		var list []interface{}
+		if s.Strategy == AppendMergeStrategy {
+			list = appendUnique(objList, tmplList)
+		} else {
+			var err error
+			list, err = mergeByName(objList, tmplList)
+			if err != nil {
+				return err
+			}
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		return setPath(merged, path, list)
+	default:
+		return fmt.Errorf("unknown merge strategy")
+	}
+}
+
+// overrideValue merges maps recursively with the values of tmpl taking precedence.
+// Other values are replaced by tmpl.
+func overrideValue(obj, tmpl interface{}) interface{} {
+	objMap, objOk := obj.(map[string]interface{})
+	tmplMap, tmplOk := tmpl.(map[string]interface{})
+	if !objOk || !tmplOk {
+		return tmpl
+	}
+	out := make(map[string]interface{}, len(objMap)+len(tmplMap))
+	for k, v := range objMap {
+		out[k] = v
+	}
+	for k, v := range tmplMap {
+		out[k] = overrideValue(objMap[k], v)
+	}
+	return out
+}
+
+// appendUnique appends the items of tmpl which aren't in obj yet.
+// Objects with a name are the same item if their names match, other items if they're equal.
+func appendUnique(obj, tmpl []interface{}) []interface{} {
+	out := append([]interface{}{}, obj...)
+	for _, t := range tmpl {
+		found := false
+		for _, o := range obj {
+			if sameItem(o, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func sameItem(a, b interface{}) bool {
+	aName, aErr := itemName(a)
+	bName, bErr := itemName(b)
+	if aErr == nil && bErr == nil {
+		return aName == bName
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func mergeByName(obj, tmpl []interface{}) ([]interface{}, error) {
+	out := append([]interface{}{}, obj...)
+	index := make(map[string]int, len(obj))
+	for i, o := range obj {
+		name, err := itemName(o)
+		if err != nil {
+			return nil, err
+		}
+		index[name] = i
+	}
+	for _, t := range tmpl {
+		name, err := itemName(t)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := index[name]; ok {
+			out[i] = t
+			continue
+		}
+		index[name] = len(out)
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func itemName(item interface{}) (string, error) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("list item is not an object")
+	}
+	name, ok := m["name"].(string)
+	if !ok {
+		return "", fmt.Errorf("list item has no name")
+	}
+	return name, nil
+}
+
+func asList(v interface{}) ([]interface{}, bool) {
+	if v == nil {
+		return nil, true
+	}
+	l, ok := v.([]interface{})
+	return l, ok
+}
+
+func lookupPath(m map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, p := range path {
+		cm, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = cm[p]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setPath sets the value at the given path, creating missing parent maps.
+// A nil value removes the field.
+func setPath(m map[string]interface{}, path []string, value interface{}) error {
+	for _, p := range path[:len(path)-1] {
+		next, ok := m[p]
+		if !ok || next == nil {
+			if value == nil {
+				return nil
+			}
+			next = map[string]interface{}{}
+			m[p] = next
+		}
+		m, ok = next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("field %q is not an object", p)
+		}
+	}
+	last := path[len(path)-1]
+	if value == nil {
+		delete(m, last)
+		return nil
+	}
+	m[last] = value
+	return nil
+}
+
+func toUnstructuredMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,168 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMergeWithStrategies(t *testing.T) {
+	tests := map[string]struct {
+		obj        TenantSpec
+		tmpl       TenantSpec
+		strategies []MergeStrategy
+
+		want    TenantSpec
+		wantErr string
+	}{
+		"default": {
+			obj:  TenantSpec{DisplayName: "Tenant", Facts: Facts{"cloud": "cloudscale"}},
+			tmpl: TenantSpec{DisplayName: "Template", DeletionPolicy: ArchivePolicy, Facts: Facts{"cloud": "exoscale", "region": "ch"}},
+			want: TenantSpec{DisplayName: "Tenant", DeletionPolicy: ArchivePolicy, Facts: Facts{"cloud": "cloudscale", "region": "ch"}},
+		},
+		"override": {
+			obj:  TenantSpec{DisplayName: "Tenant", Facts: Facts{"cloud": "cloudscale", "zone": "a"}},
+			tmpl: TenantSpec{DisplayName: "Template", Facts: Facts{"cloud": "exoscale"}},
+			strategies: []MergeStrategy{
+				{Path: "displayName", Strategy: OverrideMergeStrategy},
+				{Path: "facts", Strategy: OverrideMergeStrategy},
+			},
+			want: TenantSpec{DisplayName: "Template", Facts: Facts{"cloud": "exoscale", "zone": "a"}},
+		},
+		"override unset": {
+			obj:        TenantSpec{DisplayName: "Tenant"},
+			strategies: []MergeStrategy{{Path: "displayName", Strategy: OverrideMergeStrategy}},
+			want:       TenantSpec{DisplayName: "Tenant"},
+		},
+		"enforce": {
+			obj:        TenantSpec{DeletionPolicy: DeletePolicy, Facts: Facts{"cloud": "cloudscale", "zone": "a"}},
+			tmpl:       TenantSpec{DeletionPolicy: RetainPolicy, Facts: Facts{"cloud": "exoscale"}},
+			strategies: []MergeStrategy{{Path: "deletionPolicy", Strategy: EnforceMergeStrategy}, {Path: "facts", Strategy: EnforceMergeStrategy}},
+			want:       TenantSpec{DeletionPolicy: RetainPolicy, Facts: Facts{"cloud": "exoscale"}},
+		},
+		"enforce unset": {
+			obj:        TenantSpec{DeletionPolicy: DeletePolicy},
+			strategies: []MergeStrategy{{Path: "deletionPolicy", Strategy: EnforceMergeStrategy}},
+			want:       TenantSpec{},
+		},
+		"append": {
+			obj: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "tenant"},
+				{Name: "OWN", Value: "tenant"},
+			}}},
+			tmpl: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "template"},
+				{Name: "NEW", Value: "template"},
+			}}},
+			strategies: []MergeStrategy{{Path: "gitRepoTemplate.ciVariables", Strategy: AppendMergeStrategy}},
+			want: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "tenant"},
+				{Name: "OWN", Value: "tenant"},
+				{Name: "NEW", Value: "template"},
+			}}},
+		},
+		"append without name": {
+			obj:        TenantSpec{FactSchema: &FactSchema{Facts: map[string]FactDefinition{"cloud": {Enum: []string{"cloudscale"}}}}},
+			tmpl:       TenantSpec{FactSchema: &FactSchema{Facts: map[string]FactDefinition{"cloud": {Enum: []string{"cloudscale", "exoscale"}}}}},
+			strategies: []MergeStrategy{{Path: "factSchema.facts.cloud.enum", Strategy: AppendMergeStrategy}},
+			want:       TenantSpec{FactSchema: &FactSchema{Facts: map[string]FactDefinition{"cloud": {Enum: []string{"cloudscale", "exoscale"}}}}},
+		},
+		"merge by name": {
+			obj: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "tenant"},
+				{Name: "OWN", Value: "tenant"},
+			}}},
+			tmpl: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "template"},
+				{Name: "NEW", Value: "template"},
+			}}},
+			strategies: []MergeStrategy{{Path: "gitRepoTemplate.ciVariables", Strategy: MergeByNameMergeStrategy}},
+			want: TenantSpec{GitRepoTemplate: &GitRepoTemplate{CIVariables: []EnvVar{
+				{Name: "SHARED", Value: "template"},
+				{Name: "OWN", Value: "tenant"},
+				{Name: "NEW", Value: "template"},
+			}}},
+		},
+		"merge by name without name": {
+			obj:        TenantSpec{FactSchema: &FactSchema{Facts: map[string]FactDefinition{"cloud": {Enum: []string{"cloudscale"}}}}},
+			tmpl:       TenantSpec{FactSchema: &FactSchema{Facts: map[string]FactDefinition{"cloud": {Enum: []string{"exoscale"}}}}},
+			strategies: []MergeStrategy{{Path: "factSchema.facts.cloud.enum", Strategy: MergeByNameMergeStrategy}},
+			wantErr:    "list item is not an object",
+		},
+		"not a list": {
+			obj:        TenantSpec{DisplayName: "Tenant"},
+			strategies: []MergeStrategy{{Path: "displayName", Strategy: AppendMergeStrategy}},
+			wantErr:    "field of the object is not a list",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj := tc.obj
+			err := MergeWithStrategies(&obj, tc.tmpl, tc.strategies)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, obj)
+		})
+	}
+}
+
+func TestTenant_ApplyTemplates(t *testing.T) {
+	newTemplate := func(name string, spec TenantSpec, strategies ...MergeStrategy) TenantTemplate {
+		return TenantTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: TenantTemplateSpec{
+				TenantSpec:      spec,
+				MergeStrategies: strategies,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		templates []TenantTemplate
+
+		want TenantSpec
+	}{
+		"enforce unset": {
+			templates: []TenantTemplate{
+				newTemplate("managed", TenantSpec{}, MergeStrategy{Path: "deletionPolicy", Strategy: EnforceMergeStrategy}),
+				newTemplate("base", TenantSpec{DeletionPolicy: RetainPolicy}),
+			},
+			want: TenantSpec{},
+		},
+		"enforce": {
+			templates: []TenantTemplate{
+				newTemplate("managed", TenantSpec{DeletionPolicy: ArchivePolicy}, MergeStrategy{Path: "deletionPolicy", Strategy: EnforceMergeStrategy}),
+				newTemplate("base", TenantSpec{DeletionPolicy: RetainPolicy}, MergeStrategy{Path: "deletionPolicy", Strategy: EnforceMergeStrategy}),
+			},
+			want: TenantSpec{DeletionPolicy: ArchivePolicy},
+		},
+		"override": {
+			templates: []TenantTemplate{
+				newTemplate("managed", TenantSpec{DisplayName: "Managed"}, MergeStrategy{Path: "displayName", Strategy: OverrideMergeStrategy}),
+				newTemplate("base", TenantSpec{DisplayName: "Base", DeletionPolicy: RetainPolicy}, MergeStrategy{Path: "displayName", Strategy: OverrideMergeStrategy}),
+			},
+			want: TenantSpec{DisplayName: "Managed", DeletionPolicy: DeletePolicy},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tenant := &Tenant{
+				Spec: TenantSpec{
+					DisplayName:    "Tenant",
+					DeletionPolicy: DeletePolicy,
+				},
+			}
+
+			require.NoError(t, tenant.ApplyTemplates(tc.templates))
+			if tc.want.DisplayName == "" {
+				tc.want.DisplayName = "Tenant"
+			}
+			assert.Equal(t, tc.want, tenant.Spec)
+		})
+	}
+}
@@ -2,8 +2,9 @@ package v1alpha1
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
-	"dario.cat/mergo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -37,14 +38,81 @@ type TenantSpec struct {
 	// The fields within this can use Go templating.
 	// See https://syn.tools/lieutenant-operator/explanations/templating.html for details.
 	ClusterTemplate *ClusterSpec `json:"clusterTemplate,omitempty"`
+	// ClusterTemplateMergeStrategies defines how individual fields of the cluster template are merged into the clusters.
+	// By default the values of the cluster take precedence.
+	ClusterTemplateMergeStrategies []MergeStrategy `json:"clusterTemplateMergeStrategies,omitempty"`
 	// CompilePipeline contains the configuration for the automatically configured compile pipelines on this tenant
 	CompilePipeline *CompilePipelineSpec `json:"compilePipeline,omitempty"`
+	// Facts are key/value pairs inherited by all clusters of this tenant.
+	// The facts of a cluster take precedence.
+	Facts Facts `json:"facts,omitempty"`
+	// FactSchema declares the facts of the clusters of this tenant.
+	// The facts of the clusters are validated against it and violations are reported in the cluster conditions.
+	FactSchema *FactSchema `json:"factSchema,omitempty"`
+	// SharedSecrets are Kubernetes Secrets which are mirrored into the Vault path shared by all clusters of this tenant.
+	// +listType=map
+	// +listMapKey=name
+	SharedSecrets []SharedSecret `json:"sharedSecrets,omitempty"`
 }
 
+// SharedSecret references a Kubernetes Secret which is mirrored into the shared Vault path of the tenant
+type SharedSecret struct {
+	// Name is the path of the secret relative to the shared Vault path of the tenant.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(/[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`
+	Name string `json:"name"`
+	// SecretRef references a Secret in the namespace of the tenant. All keys of the Secret are mirrored.
+	// The Secret must have the label `lieutenant.syn.tools/shared-secret: "true"`.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+}
+
+const (
+	// ConditionClusterTemplateValid is true if the cluster template of a tenant can be rendered.
+	ConditionClusterTemplateValid = "ClusterTemplateValid"
+)
+
 // TenantStatus defines the observed state of Tenant
 type TenantStatus struct {
 	// CompilePipeline contains the status of the automatically configured compile pipelines on this tenant
 	CompilePipeline *CompilePipelineStatus `json:"compilePipeline,omitempty"`
+	// SharedSecrets contains the shared secrets mirrored into Vault.
+	// +listType=map
+	// +listMapKey=name
+	SharedSecrets []SharedSecretStatus `json:"sharedSecrets,omitempty"`
+	// Conditions contains the current conditions of the tenant.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ClusterTemplatePreview contains the changes the cluster template would make to the cluster named in the annotation `lieutenant.syn.tools/dry-render-cluster`.
+	ClusterTemplatePreview *ClusterTemplatePreview `json:"clusterTemplatePreview,omitempty"`
+}
+
+// ClusterTemplatePreview is the result of rendering the cluster template of a tenant for a cluster without applying it
+type ClusterTemplatePreview struct {
+	// Cluster is the name of the cluster the template was rendered for.
+	Cluster string `json:"cluster"`
+	// Changes are the fields of the cluster the template would change, sorted by path.
+	Changes []ClusterTemplateFieldChange `json:"changes,omitempty"`
+	// Error is the error which occurred while rendering the template.
+	Error string `json:"error,omitempty"`
+}
+
+// ClusterTemplateFieldChange is a field of a cluster which the cluster template would change
+type ClusterTemplateFieldChange struct {
+	// Path is the path of the field, for example `spec.displayName`.
+	Path string `json:"path"`
+	// Old is the JSON encoded value of the field before the change. It's empty if the field isn't set.
+	Old string `json:"old,omitempty"`
+	// New is the JSON encoded value of the field after the change. It's empty if the field would be removed.
+	New string `json:"new,omitempty"`
+}
+
+// SharedSecretStatus records a shared secret mirrored into Vault
+type SharedSecretStatus struct {
+	// Name is the path of the secret relative to the shared Vault path of the tenant.
+	Name string `json:"name"`
+	// Keys are the keys of the secret in Vault.
+	Keys []string `json:"keys,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -154,16 +222,55 @@ func (t *Tenant) ApplyTemplate(template *TenantTemplate) error {
 	if template == nil {
 		return nil
 	}
+	return t.ApplyTemplates([]TenantTemplate{*template})
+}
+
+// ApplyTemplates recursively merges in the values of the given templates.
+// The values of the tenant take precedence, followed by the templates in the given order,
+// unless the merge strategies of a template define otherwise.
+// A field overridden or enforced by a template can't be overridden or enforced by the following templates.
+// The names of the applied templates are recorded in the TenantTemplateAnnotation.
+func (t *Tenant) ApplyTemplates(templates []TenantTemplate) error {
+	if len(templates) == 0 {
+		delete(t.ObjectMeta.Annotations, TenantTemplateAnnotation)
+		return nil
+	}
 
-	if err := mergo.Merge(&t.Spec, template.Spec); err != nil {
-		return fmt.Errorf("failed to merge tenant template into tenant: %w", err)
+	names := make([]string, 0, len(templates))
+	owned := []string{}
+	for _, template := range templates {
+		// Fields owned by a previous template keep their value, even if the template removed them
+		fixed := append([]string{}, owned...)
+		strategies := make([]MergeStrategy, 0, len(template.Spec.MergeStrategies))
+		for _, s := range template.Spec.MergeStrategies {
+			if slices.Contains(fixed, s.Path) {
+				continue
+			}
+			if s.Strategy == OverrideMergeStrategy || s.Strategy == EnforceMergeStrategy {
+				owned = append(owned, s.Path)
+			}
+			strategies = append(strategies, s)
+		}
+		if err := mergeWithStrategies(&t.Spec, *template.Spec.TenantSpec.DeepCopy(), strategies, fixed); err != nil {
+			return fmt.Errorf("failed to merge tenant template %q into tenant: %w", template.Name, err)
+		}
+		names = append(names, template.Name)
 	}
 
 	if t.ObjectMeta.Annotations == nil {
 		t.ObjectMeta.Annotations = map[string]string{}
 	}
 
-	t.ObjectMeta.Annotations["lieutenant.syn.tools/tenant-template"] = template.ObjectMeta.Name
+	t.ObjectMeta.Annotations[TenantTemplateAnnotation] = strings.Join(names, ",")
 
 	return nil
 }
+
+// GetAppliedTemplates returns the names of the TenantTemplates which were applied to the tenant
+func (t *Tenant) GetAppliedTemplates() []string {
+	v := t.GetAnnotations()[TenantTemplateAnnotation]
+	if v == "" {
+		return []string{}
+	}
+	return strings.Split(v, ",")
+}
@@ -16,7 +16,22 @@ type TenantTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec TenantSpec `json:"spec,omitempty"`
+	Spec   TenantTemplateSpec   `json:"spec,omitempty"`
+	Status TenantTemplateStatus `json:"status,omitempty"`
+}
+
+// TenantTemplateSpec defines the desired state of TenantTemplate
+type TenantTemplateSpec struct {
+	TenantSpec `json:",inline"`
+	// MergeStrategies defines how individual fields of the template are merged into the tenants.
+	// By default the values of the tenant take precedence.
+	MergeStrategies []MergeStrategy `json:"mergeStrategies,omitempty"`
+}
+
+// TenantTemplateStatus defines the observed state of TenantTemplate
+type TenantTemplateStatus struct {
+	// Tenants contains the names of all tenants this template was applied to
+	Tenants []string `json:"tenants,omitempty"`
 }
 
 //+kubebuilder:object:root=true
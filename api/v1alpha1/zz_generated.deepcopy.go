@@ -5,7 +5,8 @@
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -28,6 +29,10 @@ func (in *AccessToken) DeepCopy() *AccessToken {
 func (in *BootstrapToken) DeepCopyInto(out *BootstrapToken) {
 	*out = *in
 	in.ValidUntil.DeepCopyInto(&out.ValidUntil)
+	if in.LastRegeneration != nil {
+		in, out := &in.LastRegeneration, &out.LastRegeneration
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapToken.
@@ -115,6 +120,15 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.SecretTemplates != nil {
+		in, out := &in.SecretTemplates, &out.SecretTemplates
+		*out = make([]SecretTemplate, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -142,7 +156,46 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.EffectiveFacts != nil {
+		in, out := &in.EffectiveFacts, &out.EffectiveFacts
+		*out = make(Facts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.CompileMeta.DeepCopyInto(&out.CompileMeta)
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(DecommissionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TenantMigration != nil {
+		in, out := &in.TenantMigration, &out.TenantMigration
+		*out = new(TenantMigration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ServiceAccountTokenExpiresAt != nil {
+		in, out := &in.ServiceAccountTokenExpiresAt, &out.ServiceAccountTokenExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.GeneratedSecrets != nil {
+		in, out := &in.GeneratedSecrets, &out.GeneratedSecrets
+		*out = make([]GeneratedSecretStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -155,6 +208,41 @@ func (in *ClusterStatus) DeepCopy() *ClusterStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTemplateFieldChange) DeepCopyInto(out *ClusterTemplateFieldChange) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTemplateFieldChange.
+func (in *ClusterTemplateFieldChange) DeepCopy() *ClusterTemplateFieldChange {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTemplateFieldChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTemplatePreview) DeepCopyInto(out *ClusterTemplatePreview) {
+	*out = *in
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = make([]ClusterTemplateFieldChange, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTemplatePreview.
+func (in *ClusterTemplatePreview) DeepCopy() *ClusterTemplatePreview {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTemplatePreview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompileMeta) DeepCopyInto(out *CompileMeta) {
 	*out = *in
@@ -267,6 +355,26 @@ func (in *CompilePipelineStatus) DeepCopy() *CompilePipelineStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DecommissionStatus) DeepCopyInto(out *DecommissionStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DecommissionStatus.
+func (in *DecommissionStatus) DeepCopy() *DecommissionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DecommissionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeployKey) DeepCopyInto(out *DeployKey) {
 	*out = *in
@@ -355,7 +463,7 @@ func (in *EnvVarSource) DeepCopyInto(out *EnvVarSource) {
 	*out = *in
 	if in.SecretKeyRef != nil {
 		in, out := &in.SecretKeyRef, &out.SecretKeyRef
-		*out = new(v1.SecretKeySelector)
+		*out = new(corev1.SecretKeySelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -370,6 +478,48 @@ func (in *EnvVarSource) DeepCopy() *EnvVarSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FactDefinition) DeepCopyInto(out *FactDefinition) {
+	*out = *in
+	if in.Enum != nil {
+		in, out := &in.Enum, &out.Enum
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FactDefinition.
+func (in *FactDefinition) DeepCopy() *FactDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(FactDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FactSchema) DeepCopyInto(out *FactSchema) {
+	*out = *in
+	if in.Facts != nil {
+		in, out := &in.Facts, &out.Facts
+		*out = make(map[string]FactDefinition, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FactSchema.
+func (in *FactSchema) DeepCopy() *FactSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(FactSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in Facts) DeepCopyInto(out *Facts) {
 	{
@@ -391,6 +541,22 @@ func (in Facts) DeepCopy() Facts {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GeneratedSecretStatus) DeepCopyInto(out *GeneratedSecretStatus) {
+	*out = *in
+	in.GeneratedAt.DeepCopyInto(&out.GeneratedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GeneratedSecretStatus.
+func (in *GeneratedSecretStatus) DeepCopy() *GeneratedSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GeneratedSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepo) DeepCopyInto(out *GitRepo) {
 	*out = *in
@@ -418,6 +584,21 @@ func (in *GitRepo) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitRepoDecommissionStatus) DeepCopyInto(out *GitRepoDecommissionStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoDecommissionStatus.
+func (in *GitRepoDecommissionStatus) DeepCopy() *GitRepoDecommissionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GitRepoDecommissionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitRepoList) DeepCopyInto(out *GitRepoList) {
 	*out = *in
@@ -482,6 +663,11 @@ func (in *GitRepoStatus) DeepCopyInto(out *GitRepoStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Decommission != nil {
+		in, out := &in.Decommission, &out.Decommission
+		*out = new(GitRepoDecommissionStatus)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitRepoStatus.
@@ -539,6 +725,72 @@ func (in *GitRepoTemplate) DeepCopy() *GitRepoTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeStrategy) DeepCopyInto(out *MergeStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeStrategy.
+func (in *MergeStrategy) DeepCopy() *MergeStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTemplate) DeepCopyInto(out *SecretTemplate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTemplate.
+func (in *SecretTemplate) DeepCopy() *SecretTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedSecret) DeepCopyInto(out *SharedSecret) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedSecret.
+func (in *SharedSecret) DeepCopy() *SharedSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SharedSecretStatus) DeepCopyInto(out *SharedSecretStatus) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SharedSecretStatus.
+func (in *SharedSecretStatus) DeepCopy() *SharedSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SharedSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Tenant) DeepCopyInto(out *Tenant) {
 	*out = *in
@@ -598,6 +850,22 @@ func (in *TenantList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantMigration) DeepCopyInto(out *TenantMigration) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantMigration.
+func (in *TenantMigration) DeepCopy() *TenantMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantSpec) DeepCopyInto(out *TenantSpec) {
 	*out = *in
@@ -611,11 +879,33 @@ func (in *TenantSpec) DeepCopyInto(out *TenantSpec) {
 		*out = new(ClusterSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterTemplateMergeStrategies != nil {
+		in, out := &in.ClusterTemplateMergeStrategies, &out.ClusterTemplateMergeStrategies
+		*out = make([]MergeStrategy, len(*in))
+		copy(*out, *in)
+	}
 	if in.CompilePipeline != nil {
 		in, out := &in.CompilePipeline, &out.CompilePipeline
 		*out = new(CompilePipelineSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Facts != nil {
+		in, out := &in.Facts, &out.Facts
+		*out = make(Facts, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FactSchema != nil {
+		in, out := &in.FactSchema, &out.FactSchema
+		*out = new(FactSchema)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SharedSecrets != nil {
+		in, out := &in.SharedSecrets, &out.SharedSecrets
+		*out = make([]SharedSecret, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantSpec.
@@ -636,6 +926,25 @@ func (in *TenantStatus) DeepCopyInto(out *TenantStatus) {
 		*out = new(CompilePipelineStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.SharedSecrets != nil {
+		in, out := &in.SharedSecrets, &out.SharedSecrets
+		*out = make([]SharedSecretStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ClusterTemplatePreview != nil {
+		in, out := &in.ClusterTemplatePreview, &out.ClusterTemplatePreview
+		*out = new(ClusterTemplatePreview)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantStatus.
@@ -654,6 +963,7 @@ func (in *TenantTemplate) DeepCopyInto(out *TenantTemplate) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantTemplate.
@@ -705,3 +1015,44 @@ func (in *TenantTemplateList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantTemplateSpec) DeepCopyInto(out *TenantTemplateSpec) {
+	*out = *in
+	in.TenantSpec.DeepCopyInto(&out.TenantSpec)
+	if in.MergeStrategies != nil {
+		in, out := &in.MergeStrategies, &out.MergeStrategies
+		*out = make([]MergeStrategy, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantTemplateSpec.
+func (in *TenantTemplateSpec) DeepCopy() *TenantTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TenantTemplateStatus) DeepCopyInto(out *TenantTemplateStatus) {
+	*out = *in
+	if in.Tenants != nil {
+		in, out := &in.Tenants, &out.Tenants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TenantTemplateStatus.
+func (in *TenantTemplateStatus) DeepCopy() *TenantTemplateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TenantTemplateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -8,32 +8,84 @@ import (
 
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/projectsyn/lieutenant-operator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// bootstrapTokenSecretKey is the key of the plaintext token in the bootstrap token secret
+const bootstrapTokenSecretKey = "token"
+
 func setBootstrapToken(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
 	instance, ok := obj.(*synv1alpha1.Cluster)
 	if !ok {
 		return pipeline.Result{Err: fmt.Errorf("%s is not a cluster object", obj.GetName())}
 	}
 
+	request := instance.GetAnnotations()[synv1alpha1.RegenerateBootstrapTokenAnnotation]
+
+	// token is the plaintext of a newly issued token, which needs to be written to the secret
+	token := ""
 	if instance.Status.BootstrapToken == nil {
 		data.Log.Info("Adding status to Cluster object")
-		err := newClusterStatus(instance)
+		t, err := newClusterStatus(instance)
 		if err != nil {
 			return pipeline.Result{Err: fmt.Errorf("setting initial status on cluster: %w", err)}
 		}
+		token = t
+		instance.Status.BootstrapToken.RegenerationRequest = request
+	} else if request != "" && request != instance.Status.BootstrapToken.RegenerationRequest && !instance.DecommissionRequested() {
+		data.Log.Info("Regenerating bootstrap token", "request", request)
+		t, err := newClusterStatus(instance)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("regenerating bootstrap token: %w", err)}
+		}
+		token = t
+		now := metav1.Now()
+		instance.Status.BootstrapToken.LastRegeneration = &now
+		instance.Status.BootstrapToken.RegenerationRequest = request
+		data.Eventf(instance, corev1.EventTypeNormal, "BootstrapTokenRegenerated", "RegenerateBootstrapToken",
+			"Regenerated bootstrap token, valid until %s", instance.Status.BootstrapToken.ValidUntil.UTC().Format(time.RFC3339))
+	} else if legacy := instance.Status.BootstrapToken.Token; legacy != "" && instance.Status.BootstrapToken.TokenHash == "" {
+		// Tokens issued by previous versions of the operator are stored in plaintext.
+		// The plaintext is kept in the status until the token is invalidated, as Lieutenant API versions
+		// which don't validate the token hash still read it from there.
+		data.Log.Info("Copying bootstrap token to secret")
+		token = legacy
+		instance.Status.BootstrapToken.TokenHash = synv1alpha1.HashBootstrapToken(legacy)
+		instance.Status.BootstrapToken.SecretRef = instance.GetBootstrapTokenSecretName()
 	}
 
 	if time.Now().After(instance.Status.BootstrapToken.ValidUntil.Time) {
 		instance.Status.BootstrapToken.TokenValid = false
 	}
 
-	return pipeline.Result{}
+	if !instance.Status.BootstrapToken.TokenValid {
+		instance.Status.BootstrapToken.Token = ""
+		if instance.Status.BootstrapToken.SecretRef == "" {
+			return pipeline.Result{}
+		}
+		if err := deleteBootstrapTokenSecret(instance, data); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("deleting bootstrap token secret: %w", err)}
+		}
+		instance.Status.BootstrapToken.SecretRef = ""
+		return pipeline.Result{}
+	}
+
+	if token != "" {
+		if err := writeBootstrapTokenSecret(instance, token, data); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("writing bootstrap token secret: %w", err)}
+		}
+	}
+
+	// Reconcile again once the token expires to invalidate it and remove the secret
+	return pipeline.Result{RequeueAfter: time.Until(instance.Status.BootstrapToken.ValidUntil.Time)}
 }
 
 // newClusterStatus will create a default lifetime of 24h if it wasn't set in the object.
-func newClusterStatus(cluster *synv1alpha1.Cluster) error {
+// It returns the plaintext of the new token, only its hash is stored in the status.
+func newClusterStatus(cluster *synv1alpha1.Cluster) (string, error) {
 	parseTime := "24h"
 	if cluster.Spec.TokenLifeTime != "" {
 		parseTime = cluster.Spec.TokenLifeTime
@@ -41,22 +93,23 @@ func newClusterStatus(cluster *synv1alpha1.Cluster) error {
 
 	duration, err := time.ParseDuration(parseTime)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	validUntil := time.Now().Add(duration)
 
 	token, err := generateToken()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	cluster.Status.BootstrapToken = &synv1alpha1.BootstrapToken{
-		Token:      token,
+		TokenHash:  synv1alpha1.HashBootstrapToken(token),
+		SecretRef:  cluster.GetBootstrapTokenSecretName(),
 		ValidUntil: metav1.NewTime(validUntil),
 		TokenValid: true,
 	}
-	return nil
+	return token, nil
 }
 
 func generateToken() (string, error) {
@@ -67,3 +120,39 @@ func generateToken() (string, error) {
 	}
 	return base64.URLEncoding.EncodeToString(b), err
 }
+
+// writeBootstrapTokenSecret writes the plaintext token to the bootstrap token secret of the cluster.
+// The secret is owned by the cluster.
+func writeBootstrapTokenSecret(cluster *synv1alpha1.Cluster, token string, data *pipeline.Context) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.GetBootstrapTokenSecretName(),
+			Namespace: cluster.Namespace,
+		},
+	}
+	_, err := controllerutil.CreateOrUpdate(data.Context, data.Client, secret, func() error {
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
+		}
+		secret.Labels[synv1alpha1.LabelNameTenant] = cluster.GetTenantRef().Name
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			bootstrapTokenSecretKey: []byte(token),
+		}
+		return controllerutil.SetControllerReference(cluster, secret, data.Client.Scheme())
+	})
+	return err
+}
+
+func deleteBootstrapTokenSecret(cluster *synv1alpha1.Cluster, data *pipeline.Context) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.GetBootstrapTokenSecretName(),
+			Namespace: cluster.Namespace,
+		},
+	}
+	if err := data.Client.Delete(data.Context, secret); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
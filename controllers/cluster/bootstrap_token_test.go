@@ -0,0 +1,163 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func getBootstrapTokenSecret(t *testing.T, c client.Client) (string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.Background(), types.NamespacedName{Name: "c-cluster-bootstrap-token", Namespace: "lieutenant"}, secret)
+	if err != nil {
+		return "", err
+	}
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "c-cluster", secret.OwnerReferences[0].Name)
+	return string(secret.Data["token"]), nil
+}
+
+func Test_setBootstrapToken(t *testing.T) {
+	ctx := context.Background()
+	c := prepareClient(t, testCfg{})
+	data := &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	}
+	cluster := newTestCluster()
+	cluster.Spec.TokenLifeTime = "2h"
+
+	res := setBootstrapToken(cluster, data)
+	require.NoError(t, res.Err)
+	status := cluster.Status.BootstrapToken
+	require.NotNil(t, status)
+	assert.InDelta(t, 2*time.Hour, res.RequeueAfter, float64(time.Minute), "should requeue when the token expires")
+	assert.Empty(t, status.Token, "the plaintext token must not be stored in the status")
+	assert.Equal(t, "c-cluster-bootstrap-token", status.SecretRef)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), status.ValidUntil.Time, time.Minute)
+
+	token, err := getBootstrapTokenSecret(t, c)
+	require.NoError(t, err)
+	assert.Equal(t, synv1alpha1.HashBootstrapToken(token), status.TokenHash)
+	assert.True(t, status.Validate(token, time.Now()))
+	assert.False(t, status.Validate("wrong", time.Now()))
+	assert.False(t, status.Validate(token, time.Now().Add(3*time.Hour)))
+
+	// The Lieutenant API invalidates the token once it was used
+	status.TokenValid = false
+	res = setBootstrapToken(cluster, data)
+	require.NoError(t, res.Err)
+	assert.Zero(t, res.RequeueAfter)
+	assert.False(t, status.Validate(token, time.Now()))
+	assert.Empty(t, status.SecretRef)
+	_, err = getBootstrapTokenSecret(t, c)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_setBootstrapToken_Expired(t *testing.T) {
+	ctx := context.Background()
+	c := prepareClient(t, testCfg{})
+	data := &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	}
+	cluster := newTestCluster()
+	cluster.Spec.TokenLifeTime = "2h"
+
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	_, err := getBootstrapTokenSecret(t, c)
+	require.NoError(t, err)
+
+	cluster.Status.BootstrapToken.ValidUntil = metav1.NewTime(time.Now().Add(-time.Minute))
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	assert.False(t, cluster.Status.BootstrapToken.TokenValid)
+	_, err = getBootstrapTokenSecret(t, c)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_setBootstrapToken_MigratePlaintext(t *testing.T) {
+	ctx := context.Background()
+	c := prepareClient(t, testCfg{})
+	data := &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	}
+	cluster := newTestCluster()
+	cluster.Spec.TokenLifeTime = "2h"
+	cluster.Status.BootstrapToken = &synv1alpha1.BootstrapToken{
+		Token:      "legacy-token",
+		ValidUntil: metav1.NewTime(time.Now().Add(time.Hour)),
+		TokenValid: true,
+	}
+
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	assert.Equal(t, "legacy-token", cluster.Status.BootstrapToken.Token, "the plaintext token must be kept for older Lieutenant API versions")
+	assert.True(t, cluster.Status.BootstrapToken.Validate("legacy-token", time.Now()))
+	token, err := getBootstrapTokenSecret(t, c)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy-token", token)
+
+	cluster.Status.BootstrapToken.TokenValid = false
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	assert.Empty(t, cluster.Status.BootstrapToken.Token)
+	_, err = getBootstrapTokenSecret(t, c)
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_setBootstrapToken_Regenerate(t *testing.T) {
+	ctx := context.Background()
+	c := prepareClient(t, testCfg{})
+	recorder := events.NewFakeRecorder(5)
+	data := &pipeline.Context{
+		Context:  ctx,
+		Client:   c,
+		Log:      log.FromContext(ctx),
+		Recorder: recorder,
+	}
+
+	cluster := newTestCluster()
+	cluster.Spec.TokenLifeTime = "2h"
+	cluster.Annotations = map[string]string{
+		synv1alpha1.RegenerateBootstrapTokenAnnotation: "1",
+	}
+
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	initial := cluster.Status.BootstrapToken.DeepCopy()
+	assert.Equal(t, "1", initial.RegenerationRequest)
+	assert.Nil(t, initial.LastRegeneration, "the initial token must not count as a regeneration")
+
+	cluster.Status.BootstrapToken.TokenValid = false
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	assert.Equal(t, initial.TokenHash, cluster.Status.BootstrapToken.TokenHash, "the token must not be regenerated for the same request")
+	assert.Empty(t, recorder.Events)
+
+	cluster.Annotations[synv1alpha1.RegenerateBootstrapTokenAnnotation] = "2"
+	require.NoError(t, setBootstrapToken(cluster, data).Err)
+	status := cluster.Status.BootstrapToken
+	assert.NotEqual(t, initial.TokenHash, status.TokenHash)
+	assert.True(t, status.TokenValid)
+	assert.Equal(t, "2", status.RegenerationRequest)
+	require.NotNil(t, status.LastRegeneration)
+	assert.WithinDuration(t, time.Now(), status.LastRegeneration.Time, time.Minute)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), status.ValidUntil.Time, time.Minute)
+	token, err := getBootstrapTokenSecret(t, c)
+	require.NoError(t, err)
+	assert.True(t, status.Validate(token, time.Now()))
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Normal BootstrapTokenRegenerated")
+}
@@ -0,0 +1,214 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/projectsyn/lieutenant-operator/vault"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// decommissionPollInterval is the interval in which the catalog repository is checked while a cluster is decommissioned
+const decommissionPollInterval = 10 * time.Second
+
+// decommissionStage is a stage of decommissioning a cluster.
+// The stage function returns false if it has to wait for another controller.
+type decommissionStage struct {
+	stage synv1alpha1.DecommissionStage
+	f     func(cluster *synv1alpha1.Cluster, data *pipeline.Context) (bool, error)
+}
+
+// decommissionStages are the stages of decommissioning a cluster in the order they are run.
+var decommissionStages = []decommissionStage{
+	{stage: synv1alpha1.RevokeAccessStage, f: revokeClusterAccess},
+	{stage: synv1alpha1.RecordArchiveStage, f: recordClusterArchive},
+	{stage: synv1alpha1.RemoveSecretsStage, f: removeClusterSecrets},
+	{stage: synv1alpha1.ArchiveRepositoryStage, f: archiveCatalogRepository},
+}
+
+// decommission tears down the cluster in stages once decommissioning was requested.
+// The cluster is protected from deletion until all stages are complete.
+func decommission(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+	if !cluster.DecommissionRequested() {
+		return pipeline.Result{}
+	}
+	// A cluster which is deleted while decommissioning is in progress is only deleted once decommissioning is complete
+	if data.Deleted && cluster.Status.Decommission == nil {
+		return pipeline.Result{}
+	}
+
+	if cluster.Status.Decommission == nil {
+		data.Log.Info("Decommissioning cluster")
+		cluster.Status.Phase = synv1alpha1.DecommissioningPhase
+		cluster.Status.Decommission = &synv1alpha1.DecommissionStatus{
+			StartedAt: metav1.Now(),
+			Stage:     synv1alpha1.RevokeAccessStage,
+		}
+		setDeleteProtection(cluster, "true")
+		data.Eventf(cluster, corev1.EventTypeNormal, "DecommissionStarted", "Decommission", "Decommissioning cluster")
+	}
+	d := cluster.Status.Decommission
+
+	start := slices.IndexFunc(decommissionStages, func(s decommissionStage) bool { return s.stage == d.Stage })
+	for i := start; i >= 0 && i < len(decommissionStages); i++ {
+		done, err := decommissionStages[i].f(cluster, data)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("decommission stage %s: %w", d.Stage, err)}
+		}
+		if !done {
+			return pipeline.Result{RequeueAfter: decommissionPollInterval}
+		}
+		data.Log.Info("Completed decommission stage", "stage", d.Stage)
+		d.Stage = synv1alpha1.DoneStage
+		if i+1 < len(decommissionStages) {
+			d.Stage = decommissionStages[i+1].stage
+		}
+	}
+
+	if cluster.Status.Phase != synv1alpha1.DecommissionedPhase {
+		data.Log.Info("Decommissioned cluster")
+		now := metav1.Now()
+		d.CompletedAt = &now
+		cluster.Status.Phase = synv1alpha1.DecommissionedPhase
+		setDeleteProtection(cluster, "false")
+		data.Eventf(cluster, corev1.EventTypeNormal, "Decommissioned", "Decommission", "Decommissioned cluster, it can be deleted now")
+	}
+	return pipeline.Result{}
+}
+
+func setDeleteProtection(cluster *synv1alpha1.Cluster, value string) {
+	annotations := cluster.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[pipeline.DeleteProtectionAnnotation] = value
+	cluster.SetAnnotations(annotations)
+}
+
+// revokeClusterAccess invalidates the bootstrap token and revokes all access to the catalog repository and to Vault.
+func revokeClusterAccess(cluster *synv1alpha1.Cluster, data *pipeline.Context) (bool, error) {
+	if err := vault.RemoveClusterAccess(cluster, data); err != nil {
+		return false, err
+	}
+	if t := cluster.Status.BootstrapToken; t != nil {
+		t.TokenValid = false
+		if t.SecretRef != "" {
+			if err := deleteBootstrapTokenSecret(cluster, data); err != nil {
+				return false, fmt.Errorf("deleting bootstrap token secret: %w", err)
+			}
+			t.SecretRef = ""
+		}
+	}
+
+	repo, err := decommissionGitRepo(cluster, data, synv1alpha1.RevokeAccessDecommission)
+	if err != nil || repo == nil {
+		return repo == nil, err
+	}
+	if repo.Status.Decommission == nil || !repo.Status.Decommission.AccessRevoked {
+		return false, nil
+	}
+	cluster.Status.Decommission.CatalogCommit = repo.Status.Decommission.LastCommit
+	return true, nil
+}
+
+// recordClusterArchive writes the archive record of the cluster.
+// The record is owned by the tenant and outlives the cluster.
+func recordClusterArchive(cluster *synv1alpha1.Cluster, data *pipeline.Context) (bool, error) {
+	facts, err := json.Marshal(cluster.Spec.Facts)
+	if err != nil {
+		return false, fmt.Errorf("marshalling facts: %w", err)
+	}
+	dynamicFacts, err := json.Marshal(cluster.Status.Facts)
+	if err != nil {
+		return false, fmt.Errorf("marshalling dynamic facts: %w", err)
+	}
+
+	tenant := &synv1alpha1.Tenant{}
+	if err := data.Client.Get(data.Context, types.NamespacedName{Name: cluster.GetTenantRef().Name, Namespace: cluster.Namespace}, tenant); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, fmt.Errorf("getting tenant: %w", err)
+		}
+		tenant = nil
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name + "-archive",
+			Namespace: cluster.Namespace,
+		},
+	}
+	_, err = controllerutil.CreateOrUpdate(data.Context, data.Client, cm, func() error {
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		cm.Labels[synv1alpha1.LabelNameTenant] = cluster.GetTenantRef().Name
+		cm.Data = map[string]string{
+			"cluster":          cluster.Name,
+			"tenant":           cluster.GetTenantRef().Name,
+			"displayName":      cluster.Spec.DisplayName,
+			"catalogURL":       cluster.Spec.GitRepoURL,
+			"catalogCommit":    cluster.Status.Decommission.CatalogCommit,
+			"facts":            string(facts),
+			"dynamicFacts":     string(dynamicFacts),
+			"decommissionedAt": cluster.Status.Decommission.StartedAt.UTC().Format(time.RFC3339),
+		}
+		if tenant == nil {
+			return nil
+		}
+		return controllerutil.SetOwnerReference(tenant, cm, data.Client.Scheme())
+	})
+	if err != nil {
+		return false, fmt.Errorf("writing archive record: %w", err)
+	}
+	cluster.Status.Decommission.ArchiveRef = cm.Name
+	return true, nil
+}
+
+// removeClusterSecrets removes the Vault secrets of the cluster.
+func removeClusterSecrets(cluster *synv1alpha1.Cluster, data *pipeline.Context) (bool, error) {
+	if err := vault.RemoveClusterSecrets(cluster, data); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// archiveCatalogRepository archives the catalog repository of the cluster.
+func archiveCatalogRepository(cluster *synv1alpha1.Cluster, data *pipeline.Context) (bool, error) {
+	repo, err := decommissionGitRepo(cluster, data, synv1alpha1.ArchiveDecommission)
+	if err != nil || repo == nil {
+		return repo == nil, err
+	}
+	return repo.Status.Decommission != nil && repo.Status.Decommission.Archived, nil
+}
+
+// decommissionGitRepo requests the given decommission stage on the GitRepo of the cluster and returns it.
+// It returns nil if the cluster has no GitRepo.
+func decommissionGitRepo(cluster *synv1alpha1.Cluster, data *pipeline.Context, stage synv1alpha1.GitRepoDecommission) (*synv1alpha1.GitRepo, error) {
+	repo := &synv1alpha1.GitRepo{}
+	if err := data.Client.Get(data.Context, types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}, repo); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting gitrepo: %w", err)
+	}
+	if repo.Spec.Decommission == stage || repo.Spec.Decommission == synv1alpha1.ArchiveDecommission {
+		return repo, nil
+	}
+	repo.Spec.Decommission = stage
+	if err := data.Client.Update(data.Context, repo); err != nil {
+		return nil, fmt.Errorf("updating gitrepo: %w", err)
+	}
+	return repo, nil
+}
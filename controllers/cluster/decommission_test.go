@@ -0,0 +1,217 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newDecommissionTestGitRepo(decommission synv1alpha1.GitRepoDecommission, status *synv1alpha1.GitRepoDecommissionStatus) *synv1alpha1.GitRepo {
+	return &synv1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.GitRepoSpec{
+			Decommission: decommission,
+		},
+		Status: synv1alpha1.GitRepoStatus{
+			Decommission: status,
+		},
+	}
+}
+
+func Test_decommission(t *testing.T) {
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+	}
+	revoked := &synv1alpha1.GitRepoDecommissionStatus{AccessRevoked: true, LastCommit: "0123456789abcdef"}
+	archived := &synv1alpha1.GitRepoDecommissionStatus{AccessRevoked: true, LastCommit: "0123456789abcdef", Archived: true}
+
+	tests := map[string]struct {
+		stage  synv1alpha1.DecommissionStage
+		modify func(*synv1alpha1.Cluster)
+		objs   []client.Object
+
+		wantStage        synv1alpha1.DecommissionStage
+		wantGitRepo      synv1alpha1.GitRepoDecommission
+		wantArchive      bool
+		wantCommit       string
+		wantDecommission bool
+	}{
+		"not requested": {
+			modify: func(c *synv1alpha1.Cluster) {
+				c.Spec.Decommission = false
+			},
+			objs: []client.Object{newDecommissionTestGitRepo("", nil)},
+		},
+		"requested by annotation": {
+			modify: func(c *synv1alpha1.Cluster) {
+				c.Spec.Decommission = false
+				c.Annotations = map[string]string{synv1alpha1.DecommissionAnnotation: "true"}
+			},
+			objs:             []client.Object{newDecommissionTestGitRepo("", nil)},
+			wantStage:        synv1alpha1.RevokeAccessStage,
+			wantGitRepo:      synv1alpha1.RevokeAccessDecommission,
+			wantDecommission: true,
+		},
+		"waiting for access to be revoked": {
+			stage:            synv1alpha1.RevokeAccessStage,
+			objs:             []client.Object{newDecommissionTestGitRepo(synv1alpha1.RevokeAccessDecommission, nil)},
+			wantStage:        synv1alpha1.RevokeAccessStage,
+			wantGitRepo:      synv1alpha1.RevokeAccessDecommission,
+			wantDecommission: true,
+		},
+		"access revoked": {
+			stage:            synv1alpha1.RevokeAccessStage,
+			objs:             []client.Object{tenant, newDecommissionTestGitRepo(synv1alpha1.RevokeAccessDecommission, revoked)},
+			wantStage:        synv1alpha1.ArchiveRepositoryStage,
+			wantGitRepo:      synv1alpha1.ArchiveDecommission,
+			wantArchive:      true,
+			wantCommit:       "0123456789abcdef",
+			wantDecommission: true,
+		},
+		"repository archived": {
+			stage:            synv1alpha1.ArchiveRepositoryStage,
+			objs:             []client.Object{newDecommissionTestGitRepo(synv1alpha1.ArchiveDecommission, archived)},
+			wantStage:        synv1alpha1.DoneStage,
+			wantGitRepo:      synv1alpha1.ArchiveDecommission,
+			wantDecommission: true,
+		},
+		"no repository": {
+			objs:             []client.Object{tenant},
+			wantStage:        synv1alpha1.DoneStage,
+			wantArchive:      true,
+			wantDecommission: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := newTestCluster()
+			cluster.Spec.DisplayName = "My Cluster"
+			cluster.Spec.GitRepoURL = "ssh://git@git.example.com/cluster-catalogs/c-cluster.git"
+			cluster.Spec.Facts = synv1alpha1.Facts{"distribution": "k3s"}
+			cluster.Spec.Decommission = true
+			cluster.Status.BootstrapToken = &synv1alpha1.BootstrapToken{
+				TokenHash:  synv1alpha1.HashBootstrapToken("token"),
+				SecretRef:  "c-cluster-bootstrap-token",
+				ValidUntil: metav1.Now(),
+				TokenValid: tc.stage == "" || tc.stage == synv1alpha1.RevokeAccessStage,
+			}
+			if tc.stage != "" {
+				cluster.Annotations = map[string]string{pipeline.DeleteProtectionAnnotation: "true"}
+				cluster.Status.Phase = synv1alpha1.DecommissioningPhase
+				cluster.Status.Decommission = &synv1alpha1.DecommissionStatus{
+					StartedAt: metav1.Now(),
+					Stage:     tc.stage,
+				}
+			}
+			if !cluster.Status.BootstrapToken.TokenValid {
+				cluster.Status.BootstrapToken.SecretRef = ""
+			}
+			if tc.modify != nil {
+				tc.modify(cluster)
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "c-cluster-bootstrap-token",
+					Namespace: "lieutenant",
+				},
+			}
+			objs := tc.objs
+			if cluster.Status.BootstrapToken.SecretRef != "" {
+				objs = append(objs, secret)
+			}
+			c := prepareClient(t, testCfg{obj: objs})
+
+			res := decommission(cluster, &pipeline.Context{
+				Context: ctx,
+				Client:  c,
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+
+			if !tc.wantDecommission {
+				assert.Nil(t, cluster.Status.Decommission)
+				assert.Empty(t, cluster.Status.Phase)
+				assert.True(t, cluster.Status.BootstrapToken.TokenValid)
+				return
+			}
+
+			d := cluster.Status.Decommission
+			require.NotNil(t, d)
+			assert.Equal(t, tc.wantStage, d.Stage)
+			assert.False(t, cluster.Status.BootstrapToken.TokenValid)
+			err := c.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{})
+			assert.True(t, errors.IsNotFound(err), "should delete bootstrap token secret")
+
+			if tc.wantStage == synv1alpha1.DoneStage {
+				assert.Equal(t, synv1alpha1.DecommissionedPhase, cluster.Status.Phase)
+				assert.NotNil(t, d.CompletedAt)
+				assert.Equal(t, "false", cluster.Annotations[pipeline.DeleteProtectionAnnotation])
+				assert.Zero(t, res.RequeueAfter)
+			} else {
+				assert.Equal(t, synv1alpha1.DecommissioningPhase, cluster.Status.Phase)
+				assert.Nil(t, d.CompletedAt)
+				assert.Equal(t, "true", cluster.Annotations[pipeline.DeleteProtectionAnnotation])
+				assert.Equal(t, decommissionPollInterval, res.RequeueAfter)
+			}
+
+			if tc.wantGitRepo != "" {
+				repo := &synv1alpha1.GitRepo{}
+				require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "c-cluster", Namespace: "lieutenant"}, repo))
+				assert.Equal(t, tc.wantGitRepo, repo.Spec.Decommission)
+			}
+
+			if !tc.wantArchive {
+				return
+			}
+			assert.Equal(t, "c-cluster-archive", d.ArchiveRef)
+			assert.Equal(t, tc.wantCommit, d.CatalogCommit)
+			cm := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(ctx, types.NamespacedName{Name: d.ArchiveRef, Namespace: "lieutenant"}, cm))
+			assert.Equal(t, "t-tenant", cm.Labels[synv1alpha1.LabelNameTenant])
+			require.Len(t, cm.OwnerReferences, 1)
+			assert.Equal(t, "t-tenant", cm.OwnerReferences[0].Name)
+			assert.Equal(t, map[string]string{
+				"cluster":          "c-cluster",
+				"tenant":           "t-tenant",
+				"displayName":      "My Cluster",
+				"catalogURL":       "ssh://git@git.example.com/cluster-catalogs/c-cluster.git",
+				"catalogCommit":    tc.wantCommit,
+				"facts":            `{"distribution":"k3s"}`,
+				"dynamicFacts":     "null",
+				"decommissionedAt": d.StartedAt.UTC().Format("2006-01-02T15:04:05Z07:00"),
+			}, cm.Data)
+		})
+	}
+}
+
+func Test_decommission_deleted(t *testing.T) {
+	ctx := context.Background()
+	cluster := newTestCluster()
+	cluster.Spec.Decommission = true
+
+	res := decommission(cluster, &pipeline.Context{
+		Context: ctx,
+		Client:  prepareClient(t, testCfg{}),
+		Log:     log.FromContext(ctx),
+		Deleted: true,
+	})
+	require.NoError(t, res.Err)
+	assert.Nil(t, cluster.Status.Decommission, "should not start decommissioning a deleted cluster")
+}
@@ -0,0 +1,107 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// handleExpiry enforces the expiry of ephemeral clusters.
+// The cluster is reported as expiring during the expiry warning period and deleted once it has expired.
+func handleExpiry(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+	// A decommissioned cluster is protected from deletion until decommissioning is complete
+	if data.Deleted || cluster.DecommissionRequested() {
+		return pipeline.Result{}
+	}
+
+	expiry, err := cluster.GetExpiry()
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("parsing ttl: %w", err)}
+	}
+	if expiry.IsZero() {
+		cluster.Status.ExpiresAt = nil
+		meta.RemoveStatusCondition(&cluster.Status.Conditions, synv1alpha1.ConditionExpiring)
+		return pipeline.Result{}
+	}
+	expiresAt := metav1.NewTime(expiry)
+	cluster.Status.ExpiresAt = &expiresAt
+
+	now := time.Now()
+	warnAt := expiry.Add(-data.ExpiryWarningPeriod)
+	if now.Before(warnAt) {
+		setExpiringCondition(cluster, metav1.ConditionFalse, "NotExpiring", expiry)
+		return pipeline.Result{RequeueAfter: warnAt.Sub(now)}
+	}
+	if now.Before(expiry) {
+		if !meta.IsStatusConditionTrue(cluster.Status.Conditions, synv1alpha1.ConditionExpiring) {
+			data.Eventf(cluster, corev1.EventTypeWarning, "Expiring", "Expire", "Cluster expires at %s", expiry.UTC().Format(time.RFC3339))
+		}
+		setExpiringCondition(cluster, metav1.ConditionTrue, "ExpiresSoon", expiry)
+		return pipeline.Result{RequeueAfter: expiry.Sub(now)}
+	}
+
+	return deleteExpiredCluster(cluster, data, expiry)
+}
+
+// isExpired returns true if the cluster has expired and is deleted by handleExpiry
+func isExpired(cluster *synv1alpha1.Cluster, data *pipeline.Context) bool {
+	if data.Deleted || cluster.DecommissionRequested() {
+		return false
+	}
+	expiry, err := cluster.GetExpiry()
+	return err == nil && !expiry.IsZero() && !time.Now().Before(expiry)
+}
+
+// deleteExpiredCluster deletes an expired cluster with the ephemeral deletion policy.
+// The deletion policy and the delete protection are updated first, so the GitRepo is updated before the cluster is deleted.
+func deleteExpiredCluster(cluster *synv1alpha1.Cluster, data *pipeline.Context, expiry time.Time) pipeline.Result {
+	policy := data.EphemeralDeletionPolicy
+	if policy == "" {
+		policy = synv1alpha1.DeletePolicy
+	}
+
+	template := cluster.Spec.GitRepoTemplate
+	if cluster.Spec.DeletionPolicy != policy ||
+		(template != nil && template.DeletionPolicy != policy) ||
+		cluster.GetAnnotations()[pipeline.DeleteProtectionAnnotation] != "false" {
+		data.Log.Info("Preparing deletion of expired cluster", "deletionPolicy", policy)
+		cluster.Spec.DeletionPolicy = policy
+		if template != nil {
+			template.DeletionPolicy = policy
+		}
+		setDeleteProtection(cluster, "false")
+		setExpiringCondition(cluster, metav1.ConditionTrue, "Expired", expiry)
+		return pipeline.Result{}
+	}
+
+	data.Log.Info("Deleting expired cluster")
+	data.Eventf(cluster, corev1.EventTypeNormal, "Expired", "Delete", "Deleting expired cluster")
+	if err := data.Client.Delete(data.Context, cluster); client.IgnoreNotFound(err) != nil {
+		return pipeline.Result{Err: fmt.Errorf("deleting expired cluster: %w", err)}
+	}
+	return pipeline.Result{Abort: true}
+}
+
+func setExpiringCondition(cluster *synv1alpha1.Cluster, status metav1.ConditionStatus, reason string, expiry time.Time) {
+	msg := fmt.Sprintf("Cluster expires at %s", expiry.UTC().Format(time.RFC3339))
+	if reason == "Expired" {
+		msg = fmt.Sprintf("Cluster expired at %s", expiry.UTC().Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               synv1alpha1.ConditionExpiring,
+		Status:             status,
+		Reason:             reason,
+		Message:            msg,
+		ObservedGeneration: cluster.Generation,
+	})
+}
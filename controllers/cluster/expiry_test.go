@@ -0,0 +1,204 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func Test_handleExpiry(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	tests := map[string]struct {
+		created time.Time
+		ttl     string
+		modify  func(*synv1alpha1.Cluster)
+		deleted bool
+
+		wantCondition metav1.ConditionStatus
+		wantReason    string
+		wantRequeue   bool
+		wantPrepared  bool
+		wantDeleted   bool
+		wantErr       bool
+	}{
+		"not ephemeral": {
+			created: now,
+		},
+		"not expiring": {
+			created:       now,
+			ttl:           "72h",
+			wantCondition: metav1.ConditionFalse,
+			wantReason:    "NotExpiring",
+			wantRequeue:   true,
+		},
+		"expiring": {
+			created:       now.Add(-70 * time.Hour),
+			ttl:           "72h",
+			wantCondition: metav1.ConditionTrue,
+			wantReason:    "ExpiresSoon",
+			wantRequeue:   true,
+		},
+		"expires at takes precedence": {
+			created: now,
+			ttl:     "72h",
+			modify: func(c *synv1alpha1.Cluster) {
+				c.Spec.ExpiresAt = &metav1.Time{Time: now.Add(time.Hour)}
+			},
+			wantCondition: metav1.ConditionTrue,
+			wantReason:    "ExpiresSoon",
+			wantRequeue:   true,
+		},
+		"expired": {
+			created:       now.Add(-73 * time.Hour),
+			ttl:           "72h",
+			wantCondition: metav1.ConditionTrue,
+			wantReason:    "Expired",
+			wantPrepared:  true,
+		},
+		"expired and prepared": {
+			created: now.Add(-73 * time.Hour),
+			ttl:     "72h",
+			modify: func(c *synv1alpha1.Cluster) {
+				c.Annotations[pipeline.DeleteProtectionAnnotation] = "false"
+				c.Spec.DeletionPolicy = synv1alpha1.DeletePolicy
+				c.Spec.GitRepoTemplate.DeletionPolicy = synv1alpha1.DeletePolicy
+			},
+			wantPrepared: true,
+			wantDeleted:  true,
+		},
+		"decommissioning": {
+			created: now.Add(-73 * time.Hour),
+			ttl:     "72h",
+			modify: func(c *synv1alpha1.Cluster) {
+				c.Spec.Decommission = true
+			},
+		},
+		"deleted": {
+			created: now.Add(-73 * time.Hour),
+			ttl:     "72h",
+			deleted: true,
+		},
+		"invalid ttl": {
+			created: now,
+			ttl:     "three days",
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := newTestCluster()
+			cluster.CreationTimestamp = metav1.NewTime(tc.created)
+			cluster.Finalizers = []string{synv1alpha1.FinalizerName}
+			cluster.Annotations = map[string]string{pipeline.DeleteProtectionAnnotation: "true"}
+			cluster.Spec.TTL = tc.ttl
+			cluster.Spec.DeletionPolicy = synv1alpha1.ArchivePolicy
+			cluster.Spec.GitRepoTemplate = &synv1alpha1.GitRepoTemplate{DeletionPolicy: synv1alpha1.ArchivePolicy}
+			if tc.modify != nil {
+				tc.modify(cluster)
+			}
+			c := prepareClient(t, testCfg{obj: []client.Object{cluster.DeepCopy()}})
+
+			res := handleExpiry(cluster, &pipeline.Context{
+				Context:                 ctx,
+				Client:                  c,
+				Log:                     log.FromContext(ctx),
+				Deleted:                 tc.deleted,
+				EphemeralDeletionPolicy: synv1alpha1.DeletePolicy,
+				ExpiryWarningPeriod:     24 * time.Hour,
+			})
+			if tc.wantErr {
+				require.Error(t, res.Err)
+				return
+			}
+			require.NoError(t, res.Err)
+			assert.Equal(t, tc.wantRequeue, res.RequeueAfter > 0)
+			assert.Equal(t, tc.wantDeleted, res.Abort)
+
+			cond := meta.FindStatusCondition(cluster.Status.Conditions, synv1alpha1.ConditionExpiring)
+			if tc.wantCondition == "" {
+				assert.Nil(t, cond)
+			} else {
+				require.NotNil(t, cond)
+				assert.Equal(t, tc.wantCondition, cond.Status)
+				assert.Equal(t, tc.wantReason, cond.Reason)
+				require.NotNil(t, cluster.Status.ExpiresAt)
+			}
+
+			if tc.wantPrepared {
+				assert.Equal(t, synv1alpha1.DeletePolicy, cluster.Spec.DeletionPolicy)
+				assert.Equal(t, synv1alpha1.DeletePolicy, cluster.Spec.GitRepoTemplate.DeletionPolicy)
+				assert.Equal(t, "false", cluster.Annotations[pipeline.DeleteProtectionAnnotation])
+			} else {
+				assert.Equal(t, synv1alpha1.ArchivePolicy, cluster.Spec.DeletionPolicy)
+			}
+
+			actual := &synv1alpha1.Cluster{}
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), actual))
+			assert.Equal(t, tc.wantDeleted, !actual.DeletionTimestamp.IsZero())
+		})
+	}
+}
+
+func Test_handleExpiry_templateDeletionPolicy(t *testing.T) {
+	ctx := context.Background()
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			ClusterTemplate: &synv1alpha1.ClusterSpec{
+				DeletionPolicy:  synv1alpha1.ArchivePolicy,
+				GitRepoTemplate: &synv1alpha1.GitRepoTemplate{DeletionPolicy: synv1alpha1.ArchivePolicy},
+			},
+			ClusterTemplateMergeStrategies: []synv1alpha1.MergeStrategy{
+				{Path: "deletionPolicy", Strategy: synv1alpha1.OverrideMergeStrategy},
+				{Path: "gitRepoTemplate.deletionPolicy", Strategy: synv1alpha1.OverrideMergeStrategy},
+			},
+		},
+	}
+	cluster := newTestCluster()
+	cluster.CreationTimestamp = metav1.NewTime(time.Now().Add(-73 * time.Hour))
+	cluster.Finalizers = []string{synv1alpha1.FinalizerName}
+	cluster.Spec.TTL = "72h"
+	cluster.Spec.DeletionPolicy = synv1alpha1.ArchivePolicy
+	cluster.Spec.GitRepoTemplate = &synv1alpha1.GitRepoTemplate{DeletionPolicy: synv1alpha1.ArchivePolicy}
+	c := prepareClient(t, testCfg{obj: []client.Object{tenant, cluster}})
+	data := &pipeline.Context{
+		Context:                 ctx,
+		Client:                  c,
+		Log:                     log.FromContext(ctx),
+		EphemeralDeletionPolicy: synv1alpha1.DeletePolicy,
+	}
+
+	// The first reconcile prepares the deletion, the second one deletes the cluster
+	for i := 0; i < 2; i++ {
+		current := &synv1alpha1.Cluster{}
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), current))
+		require.NoError(t, applyClusterTemplateFromTenant(current, data).Err)
+		res := handleExpiry(current, data)
+		require.NoError(t, res.Err)
+		if res.Abort {
+			break
+		}
+		assert.Equal(t, synv1alpha1.DeletePolicy, current.Spec.DeletionPolicy)
+		assert.Equal(t, synv1alpha1.DeletePolicy, current.Spec.GitRepoTemplate.DeletionPolicy)
+		require.NoError(t, c.Update(ctx, current))
+	}
+
+	actual := &synv1alpha1.Cluster{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(cluster), actual))
+	assert.False(t, actual.DeletionTimestamp.IsZero(), "the expired cluster should be deleted")
+	assert.Equal(t, synv1alpha1.DeletePolicy, actual.Spec.DeletionPolicy)
+}
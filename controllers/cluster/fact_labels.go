@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/metrics"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+)
+
+// syncFactLabels mirrors the effective facts listed in data.FactLabels into the labels of the cluster.
+// The managed labels are recorded in the FactLabelsAnnotation to remove them once the fact or the configuration is removed.
+// Labels which exist but aren't recorded in the annotation were set by users and are left alone.
+func syncFactLabels(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	instance, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+
+	labels := instance.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	previous := strings.Split(annotations[synv1alpha1.FactLabelsAnnotation], ",")
+	managed := []string{}
+	for _, fact := range data.FactLabels {
+		value, ok := instance.Status.EffectiveFacts[fact]
+		if !ok || fact == "" {
+			continue
+		}
+		key := factLabelKey(fact)
+		if slices.Contains(managed, key) {
+			continue
+		}
+		// Labels set by users aren't overwritten
+		if _, exists := labels[key]; exists && !slices.Contains(previous, key) {
+			continue
+		}
+		labels[key] = factLabelValue(value)
+		managed = append(managed, key)
+	}
+
+	for _, key := range previous {
+		if key != "" && !slices.Contains(managed, key) {
+			delete(labels, key)
+		}
+	}
+
+	if len(managed) > 0 {
+		slices.Sort(managed)
+		annotations[synv1alpha1.FactLabelsAnnotation] = strings.Join(managed, ",")
+	} else {
+		delete(annotations, synv1alpha1.FactLabelsAnnotation)
+	}
+
+	instance.SetLabels(labels)
+	instance.SetAnnotations(annotations)
+	return pipeline.Result{}
+}
+
+// https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set
+var invalidLabelCharacters = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+const maxLabelLength = 63
+
+// factLabelKey normalizes a fact key to be a valid label name.
+// The key is normalized like the fact labels of the cluster info metric, prefixed with "fact" if it starts with an underscore
+// and truncated to 63 characters.
+func factLabelKey(key string) string {
+	return trimLabel(metrics.NormalizeLabelKey(key, nil, "fact"))
+}
+
+// factLabelValue normalizes a fact value to be a valid label value.
+// Invalid characters are replaced with underscores, the value is truncated to 63 characters
+// and leading and trailing non-alphanumeric characters are removed.
+func factLabelValue(value string) string {
+	value = invalidLabelCharacters.ReplaceAllLiteralString(value, "_")
+	value = strings.TrimLeftFunc(value, func(r rune) bool { return !isAlphanumeric(byte(r)) })
+	return trimLabel(value)
+}
+
+// trimLabel truncates s to the maximum label length and removes trailing non-alphanumeric characters.
+func trimLabel(s string) string {
+	if len(s) > maxLabelLength {
+		s = s[:maxLabelLength]
+	}
+	return strings.TrimRightFunc(s, func(r rune) bool { return !isAlphanumeric(byte(r)) })
+}
+
+func isAlphanumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var syncFactLabelsCases = map[string]struct {
+	factLabels      []string
+	labels          map[string]string
+	annotations     map[string]string
+	facts           synv1alpha1.Facts
+	wantLabels      map[string]string
+	wantAnnotations map[string]string
+}{
+	"no fact labels": {
+		labels:          map[string]string{"team": "a"},
+		facts:           synv1alpha1.Facts{"cloud": "cloudscale"},
+		wantLabels:      map[string]string{"team": "a"},
+		wantAnnotations: map[string]string{},
+	},
+	"mirror facts": {
+		factLabels: []string{"cloud", "distribution", "missing"},
+		labels:     map[string]string{"team": "a"},
+		facts: synv1alpha1.Facts{
+			"cloud":        "cloudscale",
+			"distribution": "openshift4",
+			"region":       "rma1",
+		},
+		wantLabels: map[string]string{
+			"team":         "a",
+			"cloud":        "cloudscale",
+			"distribution": "openshift4",
+		},
+		wantAnnotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "cloud,distribution",
+		},
+	},
+	"normalize": {
+		factLabels: []string{"_key with/invalid chars", "version", "kubernetes.version"},
+		facts: synv1alpha1.Facts{
+			"_key with/invalid chars": "-a value/with spaces-",
+			"version":                 "v1.2.3+build",
+			"kubernetes.version":      "1.30",
+		},
+		wantLabels: map[string]string{
+			"fact_key_with_invalid_chars": "a_value_with_spaces",
+			"version":                     "v1.2.3_build",
+			"kubernetes_version":          "1.30",
+		},
+		wantAnnotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "fact_key_with_invalid_chars,kubernetes_version,version",
+		},
+	},
+	"keep user labels": {
+		factLabels: []string{"cloud", "region"},
+		labels: map[string]string{
+			"cloud":  "custom",
+			"region": "rma1",
+		},
+		annotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "region",
+		},
+		facts: synv1alpha1.Facts{
+			"cloud":  "cloudscale",
+			"region": "lpg1",
+		},
+		wantLabels: map[string]string{
+			"cloud":  "custom",
+			"region": "lpg1",
+		},
+		wantAnnotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "region",
+		},
+	},
+	"remove stale labels": {
+		factLabels: []string{"cloud"},
+		labels: map[string]string{
+			"team":         "a",
+			"cloud":        "exoscale",
+			"distribution": "openshift4",
+		},
+		annotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "cloud,distribution",
+		},
+		facts: synv1alpha1.Facts{
+			"cloud": "cloudscale",
+		},
+		wantLabels: map[string]string{
+			"team":  "a",
+			"cloud": "cloudscale",
+		},
+		wantAnnotations: map[string]string{
+			synv1alpha1.FactLabelsAnnotation: "cloud",
+		},
+	},
+}
+
+func Test_syncFactLabels(t *testing.T) {
+	for name, tc := range syncFactLabelsCases {
+		t.Run(name, func(t *testing.T) {
+			cluster := &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "c-cluster",
+					Labels:      tc.labels,
+					Annotations: tc.annotations,
+				},
+				Status: synv1alpha1.ClusterStatus{
+					EffectiveFacts: tc.facts,
+				},
+			}
+
+			res := syncFactLabels(cluster, &pipeline.Context{FactLabels: tc.factLabels})
+			require.NoError(t, res.Err)
+			assert.Equal(t, tc.wantLabels, cluster.Labels)
+			assert.Equal(t, tc.wantAnnotations, cluster.Annotations)
+		})
+	}
+}
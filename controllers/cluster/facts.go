@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func validateFacts(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	nsName := types.NamespacedName{Name: obj.GetTenantRef().Name, Namespace: obj.GetNamespace()}
+
+	tenant := &synv1alpha1.Tenant{}
+	if err := data.Client.Get(data.Context, nsName, tenant); err != nil {
+		return pipeline.Result{Err: fmt.Errorf("couldn't find tenant: %w", err)}
+	}
+
+	instance, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+
+	if tenant.Spec.FactSchema == nil {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, synv1alpha1.ConditionFactsValid)
+		return pipeline.Result{}
+	}
+
+	violations := ValidateFacts(tenant.Spec.FactSchema, instance, tenant)
+	if len(violations) == 0 {
+		meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               synv1alpha1.ConditionFactsValid,
+			Status:             metav1.ConditionTrue,
+			Reason:             "FactsMatchSchema",
+			Message:            "The facts match the fact schema of the tenant",
+			ObservedGeneration: instance.Generation,
+		})
+		return pipeline.Result{}
+	}
+
+	data.Log.Info("Facts of the cluster violate the fact schema of the tenant", "violations", violations)
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               synv1alpha1.ConditionFactsValid,
+		Status:             metav1.ConditionFalse,
+		Reason:             "FactSchemaViolation",
+		Message:            strings.Join(violations, "; "),
+		ObservedGeneration: instance.Generation,
+	})
+	return pipeline.Result{}
+}
+
+// ValidateFacts validates the static and dynamic facts of the cluster and the facts of the tenant against the given schema.
+// Required facts must be set by any of them.
+// It returns a sorted list of violations, which is empty if the facts are valid.
+func ValidateFacts(schema *synv1alpha1.FactSchema, cluster *synv1alpha1.Cluster, tenant *synv1alpha1.Tenant) []string {
+	violations := []string{}
+	if schema == nil {
+		return violations
+	}
+
+	sources := []struct {
+		name  string
+		facts synv1alpha1.Facts
+	}{
+		{"static", cluster.Spec.Facts},
+		{"dynamic", cluster.Status.Facts},
+		{"tenant", tenant.Spec.Facts},
+	}
+	for _, source := range sources {
+		for key, value := range source.facts {
+			def, ok := schema.Facts[key]
+			if !ok {
+				if schema.Strict {
+					violations = append(violations, fmt.Sprintf("%s fact %q is not declared", source.name, key))
+				}
+				continue
+			}
+			if err := validateFact(def, value); err != nil {
+				violations = append(violations, fmt.Sprintf("%s fact %q: %s", source.name, key, err))
+			}
+		}
+	}
+
+	effective := cluster.ComputeEffectiveFacts(tenant.Spec.Facts)
+	for key, def := range schema.Facts {
+		if _, ok := effective[key]; def.Required && !ok {
+			violations = append(violations, fmt.Sprintf("fact %q is required", key))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations
+}
+
+func validateFact(def synv1alpha1.FactDefinition, value string) error {
+	switch def.Type {
+	case synv1alpha1.StringFactType, "":
+	case synv1alpha1.IntegerFactType:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("value %q is not an integer", value)
+		}
+	case synv1alpha1.BooleanFactType:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("value %q is not a boolean", value)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", def.Type)
+	}
+
+	if len(def.Enum) > 0 && !slices.Contains(def.Enum, value) {
+		return fmt.Errorf("value %q is not one of %v", value, def.Enum)
+	}
+
+	if def.Pattern != "" {
+		re, err := regexp.Compile(def.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", def.Pattern, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("value %q doesn't match pattern %q", value, def.Pattern)
+		}
+	}
+
+	return nil
+}
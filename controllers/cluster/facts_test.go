@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+var factSchema = &synv1alpha1.FactSchema{
+	Facts: map[string]synv1alpha1.FactDefinition{
+		"distribution": {
+			Enum:     []string{"openshift4", "k3s"},
+			Required: true,
+		},
+		"lieutenant-instance": {
+			Type: synv1alpha1.IntegerFactType,
+		},
+		"region": {
+			Pattern: "^[a-z]{2,3}-[a-z]{3}-[0-9]$",
+		},
+		"managed": {
+			Type: synv1alpha1.BooleanFactType,
+		},
+	},
+}
+
+var validateFactsCases = map[string]struct {
+	static  synv1alpha1.Facts
+	dynamic synv1alpha1.Facts
+	tenant  synv1alpha1.Facts
+	strict  bool
+	want    []string
+}{
+	"valid": {
+		static: synv1alpha1.Facts{
+			"distribution": "openshift4",
+			"region":       "ch-gva-2",
+			"unknown":      "value",
+		},
+		dynamic: synv1alpha1.Facts{
+			"lieutenant-instance": "12",
+		},
+		tenant: synv1alpha1.Facts{
+			"managed": "true",
+		},
+		want: []string{},
+	},
+	"required fact from tenant": {
+		tenant: synv1alpha1.Facts{
+			"distribution": "k3s",
+		},
+		want: []string{},
+	},
+	"missing required fact": {
+		want: []string{`fact "distribution" is required`},
+	},
+	"invalid values": {
+		static: synv1alpha1.Facts{
+			"distribution": "opneshift4",
+			"region":       "rma1",
+		},
+		dynamic: synv1alpha1.Facts{
+			"lieutenant-instance": "prod",
+		},
+		tenant: synv1alpha1.Facts{
+			"managed": "maybe",
+		},
+		want: []string{
+			`dynamic fact "lieutenant-instance": value "prod" is not an integer`,
+			`static fact "distribution": value "opneshift4" is not one of [openshift4 k3s]`,
+			`static fact "region": value "rma1" doesn't match pattern "^[a-z]{2,3}-[a-z]{3}-[0-9]$"`,
+			`tenant fact "managed": value "maybe" is not a boolean`,
+		},
+	},
+	"strict": {
+		static: synv1alpha1.Facts{
+			"distribution": "k3s",
+			"unknown":      "value",
+		},
+		strict: true,
+		want:   []string{`static fact "unknown" is not declared`},
+	},
+}
+
+func Test_ValidateFacts(t *testing.T) {
+	for name, tc := range validateFactsCases {
+		t.Run(name, func(t *testing.T) {
+			schema := factSchema.DeepCopy()
+			schema.Strict = tc.strict
+			cluster := &synv1alpha1.Cluster{
+				Spec:   synv1alpha1.ClusterSpec{Facts: tc.static},
+				Status: synv1alpha1.ClusterStatus{Facts: tc.dynamic},
+			}
+			tenant := &synv1alpha1.Tenant{
+				Spec: synv1alpha1.TenantSpec{Facts: tc.tenant},
+			}
+			assert.Equal(t, tc.want, ValidateFacts(schema, cluster, tenant))
+		})
+	}
+}
+
+func Test_validateFacts(t *testing.T) {
+	ctx := context.Background()
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			FactSchema: factSchema,
+		},
+	}
+	c := prepareClient(t, testCfg{obj: []client.Object{tenant}})
+	data := &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	}
+
+	cluster := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+		},
+	}
+
+	res := validateFacts(cluster, data)
+	require.NoError(t, res.Err)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, synv1alpha1.ConditionFactsValid)
+	require.NotNil(t, cond)
+	assert.Equal(t, metav1.ConditionFalse, cond.Status)
+	assert.Equal(t, `fact "distribution" is required`, cond.Message)
+
+	cluster.Spec.Facts = synv1alpha1.Facts{"distribution": "k3s"}
+	res = validateFacts(cluster, data)
+	require.NoError(t, res.Err)
+	assert.True(t, meta.IsStatusConditionTrue(cluster.Status.Conditions, synv1alpha1.ConditionFactsValid))
+}
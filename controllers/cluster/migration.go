@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/git/manager"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tenantMigrationPollInterval is the interval in which the tenants are checked while a cluster is moved to another tenant
+const tenantMigrationPollInterval = 10 * time.Second
+
+// startTenantMigration starts moving the cluster to another tenant if its tenant reference changed.
+func startTenantMigration(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+	if data.Deleted {
+		return pipeline.Result{}
+	}
+
+	tenant := cluster.GetTenantRef().Name
+	if cluster.Status.Tenant == "" {
+		cluster.Status.Tenant = tenant
+		return pipeline.Result{}
+	}
+
+	if m := cluster.Status.TenantMigration; m != nil && m.To != tenant {
+		// The cluster was moved again before the previous move was completed.
+		// The Vault secrets are moved from wherever they are now.
+		if m.VaultSecretsMoved {
+			cluster.Status.Tenant = m.To
+		}
+		cluster.Status.TenantMigration = nil
+	}
+
+	if cluster.Status.Tenant == tenant || cluster.Status.TenantMigration != nil {
+		return pipeline.Result{}
+	}
+
+	data.Log.Info("Moving cluster to another tenant", "from", cluster.Status.Tenant, "to", tenant)
+	cluster.Status.TenantMigration = &synv1alpha1.TenantMigration{
+		From:      cluster.Status.Tenant,
+		To:        tenant,
+		StartedAt: metav1.Now(),
+	}
+	data.Eventf(cluster, corev1.EventTypeNormal, "TenantMigrationStarted", "MoveToTenant",
+		"Moving cluster from tenant %s to tenant %s", cluster.Status.Tenant, tenant)
+	return pipeline.Result{}
+}
+
+// completeTenantMigration completes moving the cluster to another tenant once both tenants were updated.
+// The tenants are updated by their controllers, the step requeues the cluster until they are.
+func completeTenantMigration(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+	m := cluster.Status.TenantMigration
+	if m == nil || data.Deleted {
+		return pipeline.Result{}
+	}
+
+	if !m.SourceTenantUpdated {
+		refs, err := getTenantClusterReferences(data, m.From, cluster)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("check tenant %s: %w", m.From, err)}
+		}
+		m.SourceTenantUpdated = !refs.ClassFile && !refs.Role && !refs.CompilePipeline
+	}
+	if !m.TargetTenantUpdated {
+		refs, err := getTenantClusterReferences(data, m.To, cluster)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("check tenant %s: %w", m.To, err)}
+		}
+		m.TargetTenantUpdated = refs.ClassFile && refs.Role
+	}
+
+	if !m.VaultSecretsMoved || !m.SourceTenantUpdated || !m.TargetTenantUpdated {
+		return pipeline.Result{RequeueAfter: tenantMigrationPollInterval}
+	}
+
+	data.Log.Info("Moved cluster to another tenant", "from", m.From, "to", m.To)
+	data.Eventf(cluster, corev1.EventTypeNormal, "TenantMigrated", "MoveToTenant",
+		"Moved cluster from tenant %s to tenant %s", m.From, m.To)
+	cluster.Status.Tenant = m.To
+	cluster.Status.TenantMigration = nil
+	return pipeline.Result{}
+}
+
+// tenantClusterReferences describes where a tenant references a cluster
+type tenantClusterReferences struct {
+	// ClassFile is true if the tenant's repository contains the class file of the cluster
+	ClassFile bool
+	// Role is true if the tenant's Role grants access to the cluster
+	Role bool
+	// CompilePipeline is true if the compile pipeline of the tenant includes the cluster
+	CompilePipeline bool
+}
+
+// getTenantClusterReferences returns where the tenant references the cluster.
+// A tenant which doesn't exist doesn't reference the cluster.
+func getTenantClusterReferences(data *pipeline.Context, tenantName string, cluster *synv1alpha1.Cluster) (tenantClusterReferences, error) {
+	refs := tenantClusterReferences{}
+	nsName := types.NamespacedName{Name: tenantName, Namespace: cluster.Namespace}
+
+	tenant := &synv1alpha1.Tenant{}
+	if err := data.Client.Get(data.Context, nsName, tenant); err != nil {
+		if errors.IsNotFound(err) {
+			return refs, nil
+		}
+		return refs, err
+	}
+	content, ok := tenant.GetGitTemplate().TemplateFiles[cluster.Name+".yml"]
+	refs.ClassFile = ok && content != manager.DeletionMagicString
+	refs.CompilePipeline = slices.Contains(tenant.GetCompilePipelineStatus().Clusters, cluster.Name)
+
+	role := &rbacv1.Role{}
+	if err := data.Client.Get(data.Context, nsName, role); err != nil {
+		if errors.IsNotFound(err) {
+			return refs, nil
+		}
+		return refs, err
+	}
+	for _, rule := range role.Rules {
+		if slices.Contains(rule.Resources, "clusters") && slices.Contains(rule.ResourceNames, cluster.Name) {
+			refs.Role = true
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,213 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/git/manager"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newMigrationTestTenant(name string, files map[string]string, pipelineClusters ...string) *synv1alpha1.Tenant {
+	return &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+				TemplateFiles: files,
+			},
+		},
+		Status: synv1alpha1.TenantStatus{
+			CompilePipeline: &synv1alpha1.CompilePipelineStatus{
+				Clusters: pipelineClusters,
+			},
+		},
+	}
+}
+
+func newMigrationTestRole(name string, clusters ...string) *rbacv1.Role {
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "lieutenant",
+		},
+	}
+	if len(clusters) > 0 {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{synv1alpha1.GroupVersion.Group},
+			Verbs:         []string{"get"},
+			Resources:     []string{"clusters"},
+			ResourceNames: clusters,
+		}}
+	}
+	return role
+}
+
+func Test_startTenantMigration(t *testing.T) {
+	tests := map[string]struct {
+		tenant       string
+		statusTenant string
+		migration    *synv1alpha1.TenantMigration
+
+		wantTenant    string
+		wantMigration *synv1alpha1.TenantMigration
+	}{
+		"initial tenant": {
+			tenant:     "t-a",
+			wantTenant: "t-a",
+		},
+		"unchanged tenant": {
+			tenant:       "t-a",
+			statusTenant: "t-a",
+			wantTenant:   "t-a",
+		},
+		"changed tenant": {
+			tenant:        "t-b",
+			statusTenant:  "t-a",
+			wantTenant:    "t-a",
+			wantMigration: &synv1alpha1.TenantMigration{From: "t-a", To: "t-b"},
+		},
+		"migration in progress": {
+			tenant:        "t-b",
+			statusTenant:  "t-a",
+			migration:     &synv1alpha1.TenantMigration{From: "t-a", To: "t-b", StartedAt: metav1.Now(), VaultSecretsMoved: true},
+			wantTenant:    "t-a",
+			wantMigration: &synv1alpha1.TenantMigration{From: "t-a", To: "t-b", VaultSecretsMoved: true},
+		},
+		"moved again after the vault secrets were moved": {
+			tenant:        "t-c",
+			statusTenant:  "t-a",
+			migration:     &synv1alpha1.TenantMigration{From: "t-a", To: "t-b", VaultSecretsMoved: true},
+			wantTenant:    "t-b",
+			wantMigration: &synv1alpha1.TenantMigration{From: "t-b", To: "t-c"},
+		},
+		"moved back before the vault secrets were moved": {
+			tenant:       "t-a",
+			statusTenant: "t-a",
+			migration:    &synv1alpha1.TenantMigration{From: "t-a", To: "t-b"},
+			wantTenant:   "t-a",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := newTestCluster()
+			cluster.Spec.TenantRef.Name = tc.tenant
+			cluster.Status.Tenant = tc.statusTenant
+			cluster.Status.TenantMigration = tc.migration
+			res := startTenantMigration(cluster, &pipeline.Context{
+				Context: ctx,
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+
+			assert.Equal(t, tc.wantTenant, cluster.Status.Tenant)
+			m := cluster.Status.TenantMigration
+			if tc.wantMigration == nil {
+				assert.Nil(t, m)
+				return
+			}
+			require.NotNil(t, m)
+			assert.False(t, m.StartedAt.IsZero())
+			m.StartedAt = metav1.Time{}
+			assert.Equal(t, tc.wantMigration, m)
+		})
+	}
+}
+
+func Test_completeTenantMigration(t *testing.T) {
+	classFile := map[string]string{"c-cluster.yml": "classes:\n- t-b.common\n"}
+	deletedClassFile := map[string]string{"c-cluster.yml": manager.DeletionMagicString}
+
+	tests := map[string]struct {
+		objs         []client.Object
+		vaultMoved   bool
+		wantSource   bool
+		wantTarget   bool
+		wantComplete bool
+	}{
+		"tenants not updated": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-a", classFile, "c-cluster"), newMigrationTestRole("t-a", "c-cluster"),
+				newMigrationTestTenant("t-b", nil), newMigrationTestRole("t-b"),
+			},
+			vaultMoved: true,
+		},
+		"source still references the cluster in its compile pipeline": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-a", deletedClassFile, "c-cluster"), newMigrationTestRole("t-a"),
+				newMigrationTestTenant("t-b", classFile), newMigrationTestRole("t-b", "c-cluster"),
+			},
+			vaultMoved: true,
+			wantTarget: true,
+		},
+		"target role not updated": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-a", deletedClassFile), newMigrationTestRole("t-a"),
+				newMigrationTestTenant("t-b", classFile), newMigrationTestRole("t-b"),
+			},
+			vaultMoved: true,
+			wantSource: true,
+		},
+		"vault secrets not moved": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-a", deletedClassFile), newMigrationTestRole("t-a"),
+				newMigrationTestTenant("t-b", classFile), newMigrationTestRole("t-b", "c-cluster"),
+			},
+			wantSource: true,
+			wantTarget: true,
+		},
+		"complete": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-a", deletedClassFile), newMigrationTestRole("t-a"),
+				newMigrationTestTenant("t-b", classFile), newMigrationTestRole("t-b", "c-cluster"),
+			},
+			vaultMoved:   true,
+			wantComplete: true,
+		},
+		"source tenant deleted": {
+			objs: []client.Object{
+				newMigrationTestTenant("t-b", classFile), newMigrationTestRole("t-b", "c-cluster"),
+			},
+			vaultMoved:   true,
+			wantComplete: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			cluster := newTestCluster()
+			cluster.Spec.TenantRef.Name = "t-b"
+			cluster.Status.Tenant = "t-a"
+			cluster.Status.TenantMigration = &synv1alpha1.TenantMigration{From: "t-a", To: "t-b", VaultSecretsMoved: tc.vaultMoved}
+
+			res := completeTenantMigration(cluster, &pipeline.Context{
+				Context: ctx,
+				Client:  prepareClient(t, testCfg{obj: tc.objs}),
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+
+			if tc.wantComplete {
+				assert.Nil(t, cluster.Status.TenantMigration)
+				assert.Equal(t, "t-b", cluster.Status.Tenant)
+				assert.Zero(t, res.RequeueAfter)
+				return
+			}
+			require.NotNil(t, cluster.Status.TenantMigration)
+			assert.Equal(t, "t-a", cluster.Status.Tenant)
+			assert.Equal(t, tc.wantSource, cluster.Status.TenantMigration.SourceTenantUpdated)
+			assert.Equal(t, tc.wantTarget, cluster.Status.TenantMigration.TargetTenantUpdated)
+			assert.Equal(t, tenantMigrationPollInterval, res.RequeueAfter)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/stretchr/testify/assert"
 
 	corev1 "k8s.io/api/core/v1"
@@ -71,6 +72,7 @@ type testCfg struct {
 func prepareClient(t *testing.T, cfg testCfg) client.Client {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(synv1alpha1.AddToScheme(scheme))
 
 	client := fake.NewClientBuilder().
 		WithScheme(scheme).
@@ -79,3 +81,18 @@ func prepareClient(t *testing.T, cfg testCfg) client.Client {
 
 	return client
 }
+
+// newTestCluster returns the cluster c-cluster of tenant t-tenant, which the
+// tests customize as needed.
+func newTestCluster() *synv1alpha1.Cluster {
+	return &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster",
+			Namespace: "lieutenant",
+			UID:       "d1f4f1a0-4b0a-4d3a-9a57-0cf3c2d2a4b1",
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+		},
+	}
+}
@@ -9,11 +9,24 @@ func SpecificSteps(obj pipeline.Object, data *pipeline.Context) pipeline.Result
 	steps := []pipeline.Step{
 		{Name: "create cluster RBAC", F: createClusterRBAC},
 		{Name: "deletion check", F: pipeline.CheckIfDeleted},
+		{Name: "check deletion protection", F: pipeline.CheckDeletionProtection},
 		{Name: "set bootstrap token", F: setBootstrapToken},
+		{Name: "start tenant migration", F: startTenantMigration},
+		{Name: "migrate vault secrets", F: vault.MigrateVaultSecrets},
+		{Name: "move vault secrets", F: vault.MoveVaultSecrets},
 		{Name: "create or update vault", F: vault.CreateOrUpdateVault},
+		{Name: "create generated secrets", F: vault.CreateGeneratedSecrets},
+		{Name: "reconcile vault access", F: vault.ReconcileClusterAccess},
 		{Name: "delete vault entries", F: vault.HandleVaultDeletion},
+		{Name: "delete vault access", F: vault.HandleClusterAccessDeletion},
 		{Name: "set tenant owner", F: setTenantOwner},
 		{Name: "apply cluster template from tenant", F: applyClusterTemplateFromTenant},
+		{Name: "handle expiry", F: handleExpiry},
+		{Name: "set effective facts", F: setEffectiveFacts},
+		{Name: "validate facts", F: validateFacts},
+		{Name: "sync fact labels", F: syncFactLabels},
+		{Name: "complete tenant migration", F: completeTenantMigration},
+		{Name: "decommission", F: decommission},
 	}
 
 	return pipeline.RunPipeline(obj, data, steps)
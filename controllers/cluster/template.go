@@ -2,16 +2,20 @@ package cluster
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
 	"text/template"
 
-	"dario.cat/mergo"
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/ryankurte/go-structparse"
 )
 
 type templateParser struct {
 	data templateData
+	opts RenderOptions
 	err  error
 }
 
@@ -20,11 +24,19 @@ type templateData struct {
 	Tenant *synv1alpha1.Tenant
 }
 
+// RenderOptions configures the rendering of a template
+type RenderOptions struct {
+	// Strict makes rendering fail if a map key referenced by the template is missing.
+	Strict bool
+	// Facts are the facts available to the fact functions.
+	Facts synv1alpha1.Facts
+}
+
 func (r *templateParser) ParseString(in string) interface{} {
 	if r.err != nil || len(in) == 0 {
 		return in
 	}
-	str, err := RenderTemplate(in, r.data)
+	str, err := RenderTemplateWithOptions(in, r.data, r.opts)
 	if err != nil {
 		r.err = err
 		return in
@@ -45,7 +57,8 @@ func applyClusterTemplate(cluster *synv1alpha1.Cluster, tenant *synv1alpha1.Tena
 			Cluster: cluster,
 			Tenant:  tenant,
 		},
-		err: nil,
+		opts: renderOptionsFor(cluster, tenant),
+		err:  nil,
 	}
 
 	structparse.Strings(parser, tenant.Spec.ClusterTemplate)
@@ -53,18 +66,33 @@ func applyClusterTemplate(cluster *synv1alpha1.Cluster, tenant *synv1alpha1.Tena
 		return fmt.Errorf("An error occured during template manifestation: %w", parser.err)
 	}
 
-	if err := mergo.Merge(&cluster.Spec, tenant.Spec.ClusterTemplate); err != nil {
+	if err := synv1alpha1.MergeWithStrategies(&cluster.Spec, tenant.Spec.ClusterTemplate, tenant.Spec.ClusterTemplateMergeStrategies); err != nil {
 		return fmt.Errorf("an error occured during cluster template merging: %w", err)
 	}
 
 	return nil
 }
 
+// renderOptionsFor returns the options to render the cluster template of the tenant for the given cluster.
+func renderOptionsFor(cluster *synv1alpha1.Cluster, tenant *synv1alpha1.Tenant) RenderOptions {
+	strict, _ := strconv.ParseBool(tenant.GetAnnotations()[synv1alpha1.StrictTemplatesAnnotation])
+
+	return RenderOptions{
+		Strict: strict,
+		Facts:  cluster.ComputeEffectiveFacts(tenant.Spec.Facts),
+	}
+}
+
 // RenderTemplate renders a given template with the given data
 func RenderTemplate(tmpl string, data interface{}) (string, error) {
-	tmp, err := template.New("template").Parse(tmpl)
+	return RenderTemplateWithOptions(tmpl, data, RenderOptions{})
+}
+
+// RenderTemplateWithOptions renders a given template with the given data and options
+func RenderTemplateWithOptions(tmpl string, data interface{}, opts RenderOptions) (string, error) {
+	tmp, err := parseTemplate(tmpl, opts)
 	if err != nil {
-		return "", fmt.Errorf("could not parse template: %w", err)
+		return "", err
 	}
 
 	buf := new(bytes.Buffer)
@@ -74,3 +102,107 @@ func RenderTemplate(tmpl string, data interface{}) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+func parseTemplate(tmpl string, opts RenderOptions) (*template.Template, error) {
+	tmp := template.New("template").Funcs(templateFuncs(opts.Facts))
+	if opts.Strict {
+		tmp = tmp.Option("missingkey=error")
+	}
+	tmp, err := tmp.Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %w", err)
+	}
+	return tmp, nil
+}
+
+type templateValidator struct {
+	errs []error
+}
+
+func (v *templateValidator) ParseString(in string) interface{} {
+	if _, err := parseTemplate(in, RenderOptions{}); err != nil {
+		v.errs = append(v.errs, fmt.Errorf("%q: %w", in, err))
+	}
+	return in
+}
+
+// ValidateClusterTemplate checks that all templates in the cluster template of the given tenant can be parsed.
+func ValidateClusterTemplate(tenant *synv1alpha1.Tenant) error {
+	if tenant.Spec.ClusterTemplate == nil {
+		return nil
+	}
+	v := &templateValidator{}
+	structparse.Strings(v, tenant.Spec.ClusterTemplate.DeepCopy())
+	if len(v.errs) > 0 {
+		return fmt.Errorf("invalid cluster template: %v", v.errs)
+	}
+	return nil
+}
+
+// FieldChange describes a change of a single field
+type FieldChange struct {
+	// Path is the path to the changed field, for example `spec.gitRepoTemplate.repoName`
+	Path string
+	// Old is the value before the change, nil if the field wasn't set
+	Old interface{}
+	// New is the value after the change, nil if the field was removed
+	New interface{}
+}
+
+// DryRenderClusterTemplate renders the cluster template of the tenant for the given cluster
+// and returns the fields of the cluster which would be changed by the template, sorted by path.
+// Neither the cluster nor the tenant are modified.
+func DryRenderClusterTemplate(cluster *synv1alpha1.Cluster, tenant *synv1alpha1.Tenant) ([]FieldChange, error) {
+	if err := ValidateClusterTemplate(tenant); err != nil {
+		return nil, err
+	}
+
+	rendered := cluster.DeepCopy()
+	if err := applyClusterTemplate(rendered, tenant); err != nil {
+		return nil, err
+	}
+
+	before, err := toUnstructured(cluster.Spec)
+	if err != nil {
+		return nil, err
+	}
+	after, err := toUnstructured(rendered.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := diffValues("spec", before, after, []FieldChange{})
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func toUnstructured(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+func diffValues(path string, a, b interface{}, changes []FieldChange) []FieldChange {
+	am, aok := a.(map[string]interface{})
+	bm, bok := b.(map[string]interface{})
+	// Descend into maps, treating a missing map as empty to report the individual fields
+	if (aok || a == nil) && (bok || b == nil) && (aok || bok) {
+		for k, av := range am {
+			changes = diffValues(path+"."+k, av, bm[k], changes)
+		}
+		for k, bv := range bm {
+			if _, ok := am[k]; !ok {
+				changes = diffValues(path+"."+k, nil, bv, changes)
+			}
+		}
+		return changes
+	}
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, FieldChange{Path: path, Old: a, New: b})
+	}
+	return changes
+}
@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"text/template"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+// templateFuncs returns the functions available in templates.
+// The functions are deterministic and have no access to the environment of the operator.
+// Arguments are ordered to allow chaining in pipelines, for example `{{ .Name | trunc 8 | upper }}`.
+// The fact functions look up the given facts.
+func templateFuncs(facts synv1alpha1.Facts) template.FuncMap {
+	return template.FuncMap{
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"trunc":      trunc,
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+		"sha256sum":  sha256sum,
+		"default":    defaultValue,
+		"empty":      empty,
+		"coalesce":   coalesce,
+		"ternary":    ternary,
+
+		"fact": func(key string) string {
+			return facts[key]
+		},
+		"factOr": func(key, fallback string) string {
+			if v, ok := facts[key]; ok && v != "" {
+				return v
+			}
+			return fallback
+		},
+		"hasFact": func(key string) bool {
+			_, ok := facts[key]
+			return ok
+		},
+	}
+}
+
+// trunc truncates s to n characters.
+// A negative n truncates from the start of s, keeping the last -n characters.
+func trunc(n int, s string) string {
+	r := []rune(s)
+	if n < 0 {
+		if -n >= len(r) {
+			return s
+		}
+		return string(r[len(r)+n:])
+	}
+	if n >= len(r) {
+		return s
+	}
+	return string(r[:n])
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// defaultValue returns def if value is empty.
+func defaultValue(def interface{}, value ...interface{}) interface{} {
+	if len(value) == 0 || empty(value[0]) {
+		return def
+	}
+	return value[0]
+}
+
+// empty returns true if the given value is the zero value of its type or an empty collection.
+func empty(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !empty(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+// ternary returns t if cond is true, f otherwise.
+func ternary(t, f interface{}, cond bool) interface{} {
+	if cond {
+		return t
+	}
+	return f
+}
@@ -196,6 +196,128 @@ var clusterTemplateTestCases = map[string]struct {
 			},
 		},
 	},
+	"template functions": {
+		cluster: &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "c-Foo-Bar",
+				Namespace: "bar",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				DisplayName: "Foo",
+				Facts: synv1alpha1.Facts{
+					"cloud": "cloudscale",
+				},
+			},
+			Status: synv1alpha1.ClusterStatus{
+				Facts: synv1alpha1.Facts{
+					"distribution": "openshift4",
+				},
+			},
+		},
+		tenant: &synv1alpha1.Tenant{
+			Spec: synv1alpha1.TenantSpec{
+				ClusterTemplate: &synv1alpha1.ClusterSpec{
+					GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+						RepoName:    "{{ .Name | lower | replace \"-\" \"_\" | trunc 7 }}",
+						Path:        "{{ fact \"cloud\" }}/{{ factOr \"region\" \"ch-gva-2\" }}/{{ fact \"missing\" | default \"none\" }}",
+						DisplayName: "{{ if hasFact \"distribution\" }}{{ fact \"distribution\" | upper }}{{ end }}",
+					},
+					TokenLifeTime: "{{ .Name | sha256sum | trunc 8 }}",
+				},
+			},
+		},
+		out: &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "c-Foo-Bar",
+				Namespace: "bar",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				DisplayName: "Foo",
+				Facts: synv1alpha1.Facts{
+					"cloud": "cloudscale",
+				},
+				GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+					RepoName:    "c_foo_b",
+					Path:        "cloudscale/ch-gva-2/none",
+					DisplayName: "OPENSHIFT4",
+				},
+				TokenLifeTime: "f095d172",
+			},
+			Status: synv1alpha1.ClusterStatus{
+				Facts: synv1alpha1.Facts{
+					"distribution": "openshift4",
+				},
+			},
+		},
+	},
+	"merge strategies": {
+		cluster: &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "c-foo",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				DisplayName:    "Foo",
+				DeletionPolicy: synv1alpha1.RetainPolicy,
+				Facts: synv1alpha1.Facts{
+					"cloud":  "cloudscale",
+					"region": "rma1",
+				},
+				GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+					RepoName: "foo",
+					CIVariables: []synv1alpha1.EnvVar{
+						{Name: "A", Value: "cluster"},
+						{Name: "B", Value: "cluster"},
+					},
+				},
+			},
+		},
+		tenant: &synv1alpha1.Tenant{
+			Spec: synv1alpha1.TenantSpec{
+				ClusterTemplate: &synv1alpha1.ClusterSpec{
+					DisplayName:    "Bar",
+					DeletionPolicy: synv1alpha1.DeletePolicy,
+					Facts: synv1alpha1.Facts{
+						"cloud": "exoscale",
+					},
+					GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+						RepoName: "{{ .Name }}",
+						CIVariables: []synv1alpha1.EnvVar{
+							{Name: "B", Value: "tenant"},
+							{Name: "C", Value: "tenant"},
+						},
+					},
+				},
+				ClusterTemplateMergeStrategies: []synv1alpha1.MergeStrategy{
+					{Path: "deletionPolicy", Strategy: synv1alpha1.EnforceMergeStrategy},
+					{Path: "facts", Strategy: synv1alpha1.OverrideMergeStrategy},
+					{Path: "gitRepoTemplate.repoName", Strategy: synv1alpha1.OverrideMergeStrategy},
+					{Path: "gitRepoTemplate.ciVariables", Strategy: synv1alpha1.MergeByNameMergeStrategy},
+					{Path: "displayName", Strategy: synv1alpha1.DefaultMergeStrategy},
+				},
+			},
+		},
+		out: &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "c-foo",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				DisplayName:    "Foo",
+				DeletionPolicy: synv1alpha1.DeletePolicy,
+				Facts: synv1alpha1.Facts{
+					"cloud":  "exoscale",
+					"region": "rma1",
+				},
+				GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+					RepoName: "c-foo",
+					CIVariables: []synv1alpha1.EnvVar{
+						{Name: "A", Value: "cluster"},
+						{Name: "B", Value: "tenant"},
+						{Name: "C", Value: "tenant"},
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestApplyClusterTemplate(t *testing.T) {
@@ -208,3 +330,68 @@ func TestApplyClusterTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyClusterTemplate_Strict(t *testing.T) {
+	cluster := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+	}
+	tenant := &synv1alpha1.Tenant{
+		Spec: synv1alpha1.TenantSpec{
+			ClusterTemplate: &synv1alpha1.ClusterSpec{
+				DisplayName: "{{ .Spec.Facts.missing }}",
+			},
+		},
+	}
+
+	require.NoError(t, applyClusterTemplate(cluster.DeepCopy(), tenant))
+
+	tenant.Annotations = map[string]string{
+		synv1alpha1.StrictTemplatesAnnotation: "true",
+	}
+	require.Error(t, applyClusterTemplate(cluster.DeepCopy(), tenant))
+}
+
+func TestDryRenderClusterTemplate(t *testing.T) {
+	cluster := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "foo",
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			DisplayName: "Foo",
+			Facts: synv1alpha1.Facts{
+				"cloud": "cloudscale",
+			},
+		},
+	}
+	tenant := &synv1alpha1.Tenant{
+		Spec: synv1alpha1.TenantSpec{
+			ClusterTemplate: &synv1alpha1.ClusterSpec{
+				DisplayName:    "Ignored",
+				DeletionPolicy: synv1alpha1.DeletePolicy,
+				Facts: synv1alpha1.Facts{
+					"cloud":  "exoscale",
+					"region": "ch-gva-2",
+				},
+				GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+					RepoName: "{{ .Name }}",
+				},
+			},
+		},
+	}
+	orig := cluster.DeepCopy()
+
+	changes, err := DryRenderClusterTemplate(cluster, tenant)
+	require.NoError(t, err)
+	assert.Equal(t, orig, cluster)
+	assert.Equal(t, []FieldChange{
+		{Path: "spec.deletionPolicy", New: "Delete"},
+		{Path: "spec.facts.region", New: "ch-gva-2"},
+		{Path: "spec.gitRepoTemplate.repoName", New: "foo"},
+	}, changes)
+
+	tenant.Spec.ClusterTemplate.DisplayName = "{{ .Name "
+	_, err = DryRenderClusterTemplate(cluster, tenant)
+	require.Error(t, err)
+}
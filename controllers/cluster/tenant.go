@@ -38,8 +38,40 @@ func applyClusterTemplateFromTenant(obj pipeline.Object, data *pipeline.Context)
 		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
 	}
 
+	// The deletion policies of an expired cluster are owned by handleExpiry, the template must not reset them
+	deletionPolicy := instance.Spec.DeletionPolicy
+	hadGitRepoTemplate := instance.Spec.GitRepoTemplate != nil
+	var gitRepoDeletionPolicy synv1alpha1.DeletionPolicy
+	if hadGitRepoTemplate {
+		gitRepoDeletionPolicy = instance.Spec.GitRepoTemplate.DeletionPolicy
+	}
+
 	if err := applyClusterTemplate(instance, tenant); err != nil {
 		return pipeline.Result{Err: fmt.Errorf("apply cluster template: %w", err)}
 	}
+
+	if isExpired(instance, data) {
+		instance.Spec.DeletionPolicy = deletionPolicy
+		if hadGitRepoTemplate && instance.Spec.GitRepoTemplate != nil {
+			instance.Spec.GitRepoTemplate.DeletionPolicy = gitRepoDeletionPolicy
+		}
+	}
+	return pipeline.Result{}
+}
+
+func setEffectiveFacts(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	nsName := types.NamespacedName{Name: obj.GetTenantRef().Name, Namespace: obj.GetNamespace()}
+
+	tenant := &synv1alpha1.Tenant{}
+	if err := data.Client.Get(data.Context, nsName, tenant); err != nil {
+		return pipeline.Result{Err: fmt.Errorf("couldn't find tenant: %w", err)}
+	}
+
+	instance, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a cluster")}
+	}
+
+	instance.Status.EffectiveFacts = instance.ComputeEffectiveFacts(tenant.Spec.Facts)
 	return pipeline.Result{}
 }
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func Test_setEffectiveFacts(t *testing.T) {
+	ctx := context.Background()
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			Facts: synv1alpha1.Facts{
+				"billing-entity": "acme",
+				"distribution":   "openshift4",
+				"region":         "ch-gva-2",
+			},
+		},
+	}
+	c := prepareClient(t, testCfg{obj: []client.Object{tenant}})
+
+	cluster := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+			Facts: synv1alpha1.Facts{
+				"region": "rma1",
+			},
+		},
+		Status: synv1alpha1.ClusterStatus{
+			Facts: synv1alpha1.Facts{
+				"distribution": "k3s",
+				"region":       "lpg1",
+			},
+		},
+	}
+
+	res := setEffectiveFacts(cluster, &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	})
+	require.NoError(t, res.Err)
+
+	assert.Equal(t, synv1alpha1.Facts{
+		"billing-entity": "acme",
+		"distribution":   "k3s",
+		"region":         "rma1",
+	}, cluster.Status.EffectiveFacts)
+	assert.Equal(t, synv1alpha1.Facts{"region": "rma1"}, cluster.Spec.Facts, "the static facts must not be changed")
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/projectsyn/lieutenant-operator/controllers/cluster"
 	"github.com/projectsyn/lieutenant-operator/controllers/gitrepo"
@@ -10,6 +11,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -29,6 +31,19 @@ type ClusterReconciler struct {
 	DefaultDeletionPolicy synv1alpha1.DeletionPolicy
 	UseVault              bool
 	DeleteProtection      bool
+	// DeleteConfirmation makes the default deletion protection require confirming the deletion by name
+	DeleteConfirmation bool
+	// DeletionGracePeriod is the time after the deletion of a cluster before its external resources are touched
+	DeletionGracePeriod time.Duration
+	// FactLabels are the keys of the facts which are mirrored into the labels of the clusters
+	FactLabels []string
+	Recorder   events.EventRecorder
+	// EphemeralDeletionPolicy is the deletion policy applied to expired ephemeral clusters
+	EphemeralDeletionPolicy synv1alpha1.DeletionPolicy
+	// ExpiryWarningPeriod is the time before the expiry of an ephemeral cluster from which on it's reported as expiring
+	ExpiryWarningPeriod time.Duration
+	// ServiceAccountTokenLifetime is the lifetime of the Steward tokens requested with the TokenRequest API
+	ServiceAccountTokenLifetime time.Duration
 }
 
 //+kubebuilder:rbac:groups=syn.tools,resources=clusters,verbs=get;list;watch;create;update;patch;delete
@@ -36,7 +51,10 @@ type ClusterReconciler struct {
 //+kubebuilder:rbac:groups=syn.tools,resources=clusters/finalizers,verbs=update
 //+kubebuilder:rbac:groups=syn.tools,resources=tenants/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="",resources=secrets;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=serviceaccounts/token,verbs=create
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings;roles,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
 
 func (r *ClusterReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
 	reqLogger := log.FromContext(ctx)
@@ -53,16 +71,23 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	}
 
 	data := &pipeline.Context{
-		Context:               ctx,
-		Client:                r.Client,
-		Log:                   reqLogger,
-		FinalizerName:         synv1alpha1.FinalizerName,
-		Reconciler:            r,
-		CreateSATokenSecret:   r.CreateSATokenSecret,
-		DefaultCreationPolicy: r.DefaultCreationPolicy,
-		DefaultDeletionPolicy: r.DefaultDeletionPolicy,
-		UseVault:              r.UseVault,
-		UseDeletionProtection: r.DeleteProtection,
+		Context:                     ctx,
+		Client:                      r.Client,
+		Log:                         reqLogger,
+		FinalizerName:               synv1alpha1.FinalizerName,
+		Reconciler:                  r,
+		CreateSATokenSecret:         r.CreateSATokenSecret,
+		DefaultCreationPolicy:       r.DefaultCreationPolicy,
+		DefaultDeletionPolicy:       r.DefaultDeletionPolicy,
+		UseVault:                    r.UseVault,
+		UseDeletionProtection:       r.DeleteProtection,
+		UseDeletionConfirmation:     r.DeleteConfirmation,
+		DeletionGracePeriod:         r.DeletionGracePeriod,
+		FactLabels:                  r.FactLabels,
+		Recorder:                    r.Recorder,
+		EphemeralDeletionPolicy:     r.EphemeralDeletionPolicy,
+		ExpiryWarningPeriod:         r.ExpiryWarningPeriod,
+		ServiceAccountTokenLifetime: r.ServiceAccountTokenLifetime,
 	}
 
 	steps := []pipeline.Step{
@@ -76,7 +101,7 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 
 	res := pipeline.RunPipeline(instance, data, steps)
 
-	return reconcile.Result{Requeue: res.Requeue}, res.Err
+	return reconcile.Result{Requeue: res.Requeue, RequeueAfter: res.RequeueAfter}, res.Err
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -21,7 +21,7 @@ func preparePipelineTestClient(t *testing.T, initObjs ...client.Object) client.C
 	client := fake.NewClientBuilder().
 		WithScheme(scheme).
 		WithObjects(initObjs...).
-		WithStatusSubresource(&synv1alpha1.Tenant{}).
+		WithStatusSubresource(&synv1alpha1.Tenant{}, &synv1alpha1.TenantTemplate{}).
 		Build()
 
 	return client
@@ -56,9 +56,14 @@ func steps(obj pipeline.Object, data *pipeline.Context, getGitClient gitClientFa
 		return pipeline.Result{}
 	}
 
+	if instance.Spec.Decommission != "" {
+		return decommission(instance, data, getGitClient)
+	}
+
 	// NOTE(aa): Generate deploy keys before creating Git repo client, since the list of
 	// deploy keys which the client is aware of is frozen at client-creation time.
-	if err := ensureGeneratedDeployKeys(data.Context, data.Client, instance); err != nil {
+	rotateAt, err := ensureGeneratedDeployKeys(data.Context, data.Client, instance)
+	if err != nil {
 		return pipeline.Result{Err: handleRepoError(data.Context, fmt.Errorf("ensure generated deploy keys: %w", err), instance, data.Client)}
 	}
 
@@ -108,7 +113,8 @@ func steps(obj pipeline.Object, data *pipeline.Context, getGitClient gitClientFa
 		return pipeline.Result{}
 	}
 
-	if err := ensureAccessToken(data.Context, data.Client, instance, repo); err != nil {
+	renewAt, err := ensureAccessToken(data.Context, data.Client, instance, repo)
+	if err != nil {
 		return pipeline.Result{Err: handleRepoError(data.Context, fmt.Errorf("ensure access token: %w", err), instance, data.Client)}
 	}
 
@@ -135,9 +141,107 @@ func steps(obj pipeline.Object, data *pipeline.Context, getGitClient gitClientFa
 	instance.Status.URL = repo.FullURL().String()
 	instance.Status.Type = synv1alpha1.GitType(repo.Type())
 
+	// Reconcile again once the access token enters its renewal window or a generated deploy key is due for rotation
+	res := pipeline.Result{}
+	for _, deadline := range []time.Time{renewAt, rotateAt} {
+		if !deadline.IsZero() {
+			res.RequeueAfter = pipeline.EarliestRequeue(res.RequeueAfter, time.Until(deadline))
+		}
+	}
+	return res
+}
+
+// decommission revokes all access to the repository and archives it if requested.
+// The repository isn't created and its template files and CI variables aren't updated anymore.
+func decommission(instance *synv1alpha1.GitRepo, data *pipeline.Context, getGitClient gitClientFactory) pipeline.Result {
+	if instance.Status.Decommission == nil {
+		instance.Status.Decommission = &synv1alpha1.GitRepoDecommissionStatus{}
+	}
+	status := instance.Status.Decommission
+	if data.Deleted && status.Archived {
+		// An archived repository is retained
+		return pipeline.Result{}
+	}
+
+	// The client is created without any deploy keys so that updating the repository removes all of them
+	clientInstance := instance.DeepCopy()
+	clientInstance.Spec.DeployKeys = nil
+	clientInstance.Status.GeneratedDeployKeys = nil
+	if !data.Deleted && instance.Spec.Decommission == synv1alpha1.ArchiveDecommission {
+		clientInstance.Spec.DeletionPolicy = synv1alpha1.ArchivePolicy
+	}
+	repo, hostKeys, err := getGitClient(data.Context, clientInstance, data.Log, data.Client)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get Git client: %w", err)}
+	}
+	instance.Status.HostKeys = hostKeys
+
+	exists, err := repoExists(repo)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("failed to check if repo exists: %w", err)}
+	}
+	if !exists || (instance.Status.URL != repo.FullURL().String() && instance.Spec.CreationPolicy != synv1alpha1.AdoptPolicy) {
+		data.Log.Info("Repository doesn't exist or isn't managed, nothing to decommission")
+		status.AccessRevoked = true
+		status.Archived = instance.Spec.Decommission == synv1alpha1.ArchiveDecommission
+		return pipeline.Result{}
+	}
+
+	if data.Deleted {
+		if err := repo.Remove(); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("remove repo: %w", err)}
+		}
+		return pipeline.Result{}
+	}
+
+	if !status.AccessRevoked {
+		if err := revokeAccess(data.Context, data.Client, instance, repo); err != nil {
+			return pipeline.Result{Err: handleRepoError(data.Context, fmt.Errorf("revoke access: %w", err), instance, data.Client)}
+		}
+		data.Log.Info("Revoked access to repository", "lastCommit", status.LastCommit)
+	}
+
+	if instance.Spec.Decommission == synv1alpha1.ArchiveDecommission && !status.Archived {
+		if err := repo.Remove(); err != nil {
+			return pipeline.Result{Err: handleRepoError(data.Context, fmt.Errorf("archive repo: %w", err), instance, data.Client)}
+		}
+		status.Archived = true
+		data.Log.Info("Archived repository")
+	}
+
 	return pipeline.Result{}
 }
 
+// revokeAccess removes all deploy keys from the repository, revokes the project access tokens and deletes the access token secret.
+// The latest commit of the repository is recorded once no one can push to it anymore.
+func revokeAccess(ctx context.Context, cli client.Client, instance *synv1alpha1.GitRepo, repo manager.Repo) error {
+	if _, err := repo.Update(); err != nil {
+		return fmt.Errorf("error removing deploy keys: %w", err)
+	}
+	if err := repo.RevokeProjectAccessTokens(ctx, instance.GetName()); err != nil {
+		return fmt.Errorf("error revoking project access tokens: %w", err)
+	}
+	if name := instance.Spec.AccessToken.SecretRef; name != "" {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: instance.Namespace,
+			},
+		}
+		if err := cli.Delete(ctx, secret); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting access token secret: %w", err)
+		}
+	}
+
+	head, err := repo.HeadCommit(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting latest commit: %w", err)
+	}
+	instance.Status.Decommission.LastCommit = head
+	instance.Status.Decommission.AccessRevoked = true
+	return nil
+}
+
 func repoExists(repo manager.Repo) (bool, error) {
 	err := repo.Read()
 	if err != nil {
@@ -159,17 +263,13 @@ func handleRepoError(ctx context.Context, err error, instance *synv1alpha1.GitRe
 	return err
 }
 
-const (
-	LieutenantAccessTokenUIDAnnotation       = "lieutenant.syn.tools/accessTokenUID"
-	LieutenantAccessTokenExpiresAtAnnotation = "lieutenant.syn.tools/accessTokenExpiresAt"
-)
-
 // ensureAccessToken ensures that an up-to-date access token returned from the manager is stored in the referenced secret.
 // It passes the UID of the previous access token to the manager to ensure that the same access token is returned if it has not expired.
-func ensureAccessToken(ctx context.Context, cli client.Client, instance *synv1alpha1.GitRepo, repo manager.Repo) error {
+// It returns the time at which the access token enters its renewal window, or the zero time if no access token is managed.
+func ensureAccessToken(ctx context.Context, cli client.Client, instance *synv1alpha1.GitRepo, repo manager.Repo) (time.Time, error) {
 	name := instance.Spec.AccessToken.SecretRef
 	if name == "" {
-		return nil
+		return time.Time{}, nil
 	}
 
 	secret := &corev1.Secret{
@@ -178,10 +278,12 @@ func ensureAccessToken(ctx context.Context, cli client.Client, instance *synv1al
 			Namespace: instance.Namespace,
 		},
 	}
+	var pat manager.ProjectAccessToken
 	op, err := controllerutil.CreateOrUpdate(ctx, cli, secret, func() error {
-		uid := secret.Annotations[LieutenantAccessTokenUIDAnnotation]
+		uid := secret.Annotations[synv1alpha1.LieutenantAccessTokenUIDAnnotation]
 
-		pat, err := repo.EnsureProjectAccessToken(ctx, instance.GetName(), manager.EnsureProjectAccessTokenOptions{
+		var err error
+		pat, err = repo.EnsureProjectAccessToken(ctx, instance.GetName(), manager.EnsureProjectAccessTokenOptions{
 			UID: &uid,
 		})
 		if err != nil {
@@ -192,8 +294,8 @@ func ensureAccessToken(ctx context.Context, cli client.Client, instance *synv1al
 			if secret.Annotations == nil {
 				secret.Annotations = make(map[string]string)
 			}
-			secret.Annotations[LieutenantAccessTokenUIDAnnotation] = pat.UID
-			secret.Annotations[LieutenantAccessTokenExpiresAtAnnotation] = pat.ExpiresAt.Format(time.RFC3339)
+			secret.Annotations[synv1alpha1.LieutenantAccessTokenUIDAnnotation] = pat.UID
+			secret.Annotations[synv1alpha1.LieutenantAccessTokenExpiresAtAnnotation] = pat.ExpiresAt.Format(time.RFC3339)
 			secret.Data = map[string][]byte{
 				"token": []byte(pat.Token),
 			}
@@ -202,15 +304,15 @@ func ensureAccessToken(ctx context.Context, cli client.Client, instance *synv1al
 		return controllerutil.SetControllerReference(instance, secret, cli.Scheme())
 	})
 	if err != nil {
-		return fmt.Errorf("error creating or updating access token secret: %w", err)
+		return time.Time{}, fmt.Errorf("error creating or updating access token secret: %w", err)
 	}
 	log.FromContext(ctx).Info("Reconciled secret",
 		"secret", secret.Name,
-		"pat_uid", secret.Annotations[LieutenantAccessTokenUIDAnnotation],
-		"pat_expires_at", secret.Annotations[LieutenantAccessTokenExpiresAtAnnotation],
+		"pat_uid", secret.Annotations[synv1alpha1.LieutenantAccessTokenUIDAnnotation],
+		"pat_expires_at", secret.Annotations[synv1alpha1.LieutenantAccessTokenExpiresAtAnnotation],
 		"op", op)
 
-	return nil
+	return pat.RenewAt(), nil
 }
 
 // ensureCIVariables ensures that the CI variables are set on the repository.
@@ -270,7 +372,9 @@ func ensureCIVariables(ctx context.Context, cli client.Client, instance *synv1al
 // ensureGeneratedDeployKeys ensures that the repo's `generateDeployKey` entries
 // all have a corresponding `deployKey` entry, generating SSH keys as required
 // and storing them in individual secrets.
-func ensureGeneratedDeployKeys(ctx context.Context, cli client.Client, instance *synv1alpha1.GitRepo) error {
+// Keys older than their rotation period are replaced by new keys.
+// It returns the time at which the next key is due for rotation, or the zero time if no key is rotated.
+func ensureGeneratedDeployKeys(ctx context.Context, cli client.Client, instance *synv1alpha1.GitRepo) (time.Time, error) {
 	errors := []error{}
 	deletions := []string{}
 	for oldKey, settings := range instance.Status.GeneratedDeployKeys {
@@ -298,11 +402,23 @@ func ensureGeneratedDeployKeys(ctx context.Context, cli client.Client, instance
 		delete(instance.Status.GeneratedDeployKeys, del)
 	}
 
+	now := time.Now()
+	var rotateAt time.Time
 	for genKey, settings := range instance.Spec.GeneratedDeployKeys {
 		secretName := instance.Name + DEPLOY_KEY_NAME_INFIX + genKey
 		secretNSName := types.NamespacedName{Name: secretName, Namespace: instance.Namespace}
 		secret := &corev1.Secret{}
 
+		var rotation time.Duration
+		if settings.RotationPeriod != "" {
+			var err error
+			rotation, err = time.ParseDuration(settings.RotationPeriod)
+			if err != nil {
+				errors = append(errors, fmt.Errorf("could not parse rotation period of deploy key %q: %w", genKey, err))
+				continue
+			}
+		}
+
 		err := cli.Get(ctx, secretNSName, secret)
 		if err != nil {
 			if !apierrors.IsNotFound(err) {
@@ -314,6 +430,22 @@ func ensureGeneratedDeployKeys(ctx context.Context, cli client.Client, instance
 				errors = append(errors, fmt.Errorf("Could not create new deploy key secret: %w", err))
 				continue
 			}
+		} else if rotation > 0 && !now.Before(synv1alpha1.DeployKeyGeneratedAt(secret).Add(rotation)) {
+			log.FromContext(ctx).Info("Rotating deploy key", "secret", secretName)
+			if err := setDeployKeyPair(settings, secret); err != nil {
+				errors = append(errors, fmt.Errorf("Could not generate new deploy key: %w", err))
+				continue
+			}
+			if err := cli.Update(ctx, secret); err != nil {
+				errors = append(errors, fmt.Errorf("Could not rotate deploy key secret: %w", err))
+				continue
+			}
+		}
+		if rotation > 0 {
+			next := synv1alpha1.DeployKeyGeneratedAt(secret).Add(rotation)
+			if rotateAt.IsZero() || next.Before(rotateAt) {
+				rotateAt = next
+			}
 		}
 
 		pubkeyB, ok := secret.Data[DEPLOY_KEY_SECRET_PUBKEY]
@@ -342,36 +474,49 @@ func ensureGeneratedDeployKeys(ctx context.Context, cli client.Client, instance
 		}
 	}
 
-	return multierr.Combine(errors...)
+	return rotateAt, multierr.Combine(errors...)
 }
 
 func generateNewDeployKeySecret(ctx context.Context, cli client.Client, settings synv1alpha1.DeployKeyTemplate, secretName types.NamespacedName, owner *synv1alpha1.GitRepo, secretRef *corev1.Secret) error {
+	secretRef.Name = secretName.Name
+	secretRef.Namespace = secretName.Namespace
+
+	secretRef.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(owner, owner.GroupVersionKind()),
+	}
+
+	if err := setDeployKeyPair(settings, secretRef); err != nil {
+		return err
+	}
+
+	return cli.Create(ctx, secretRef)
+}
+
+// setDeployKeyPair generates a new SSH key pair and stores it in the secret, together with the time it was generated.
+func setDeployKeyPair(settings synv1alpha1.DeployKeyTemplate, secret *corev1.Secret) error {
 	keyType := keygen.Ed25519
 	if settings.Type == "ssh-rsa" {
 		keyType = keygen.RSA
 	}
 
 	kp, err := keygen.New(
-		"/tmp/"+secretName.Name,
+		"/tmp/"+secret.Name,
 		keygen.WithKeyType(keyType),
 	)
 	if err != nil {
 		return err
 	}
 
-	secretRef.Name = secretName.Name
-	secretRef.Namespace = secretName.Namespace
-
-	secretRef.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
-		*metav1.NewControllerRef(owner, owner.GroupVersionKind()),
-	}
-
-	secretRef.Data = make(map[string][]byte)
+	secret.Data = make(map[string][]byte)
 
-	secretRef.Data[DEPLOY_KEY_SECRET_PUBKEY] = kp.RawAuthorizedKey()
-	secretRef.Data[DEPLOY_KEY_SECRET_PRIVKEY] = kp.RawPrivateKey()
+	secret.Data[DEPLOY_KEY_SECRET_PUBKEY] = kp.RawAuthorizedKey()
+	secret.Data[DEPLOY_KEY_SECRET_PRIVKEY] = kp.RawPrivateKey()
 
-	return cli.Create(ctx, secretRef)
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[synv1alpha1.DeployKeyGeneratedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	return nil
 }
 
 // valueFromEnvVar returns the value of an envVar. It returns an error if the envVar is invalid or the value cannot be retrieved.
@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -279,6 +280,7 @@ func TestSteps_AccessToken(t *testing.T) {
 	assert.Equal(t, fr.accessToken.UID, secret.Annotations["lieutenant.syn.tools/accessTokenUID"])
 	assert.Equal(t, fr.accessToken.Token, string(secret.Data["token"]))
 	assert.Equal(t, fr.accessToken.ExpiresAt.Format(time.RFC3339), secret.Annotations["lieutenant.syn.tools/accessTokenExpiresAt"])
+	assert.InDelta(t, 20*24*time.Hour, res.RequeueAfter, float64(time.Minute), "Should requeue when the token enters its renewal window")
 
 	oldToken := fr.accessToken.Token
 	fr.accessToken.Token = ""
@@ -489,6 +491,80 @@ func TestSteps_GenerateDeployKeys(t *testing.T) {
 
 }
 
+func TestSteps_RotateDeployKeys(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(synv1alpha1.AddToScheme(scheme))
+
+	repo := &synv1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-bar",
+			Namespace: "foo",
+		},
+		Spec: synv1alpha1.GitRepoSpec{
+			GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+				GeneratedDeployKeys: map[string]synv1alpha1.DeployKeyTemplate{
+					"due": {
+						Type:           "ssh-ed25519",
+						RotationPeriod: "1h",
+					},
+					"fresh": {
+						Type:           "ssh-ed25519",
+						RotationPeriod: "1h",
+					},
+				},
+			},
+		},
+	}
+	keySecret := func(name string, generatedAt time.Time) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "foo",
+				Annotations: map[string]string{
+					synv1alpha1.DeployKeyGeneratedAtAnnotation: generatedAt.UTC().Format(time.RFC3339),
+				},
+			},
+			Data: map[string][]byte{
+				"privateKey": []byte("itsasecret"),
+				"publicKey":  []byte("ssh-ed25519 old"),
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			repo,
+			keySecret("c-bar-deploy-key-due", time.Now().Add(-2*time.Hour)),
+			keySecret("c-bar-deploy-key-fresh", time.Now().Add(-10*time.Minute)),
+		).
+		WithStatusSubresource(&synv1alpha1.GitRepo{}).
+		Build()
+	pContext := &pipeline.Context{
+		Context:       context.TODO(),
+		FinalizerName: "foo",
+		Client:        c,
+		Log:           testr.New(t),
+	}
+	fr := &fakeRepo{
+		exists: true,
+		url:    new(url.URL),
+	}
+	res := steps(repo, pContext, fakeGitClientFactory(fr))
+	require.NoError(t, res.Err)
+
+	assert.NotEqual(t, "old", repo.Status.GeneratedDeployKeys["generated-due"].Key, "should rotate the key which is due")
+	assert.Equal(t, "old", repo.Status.GeneratedDeployKeys["generated-fresh"].Key)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, c.Get(context.TODO(), types.NamespacedName{Namespace: "foo", Name: "c-bar-deploy-key-due"}, secret))
+	assert.Equal(t, "ssh-ed25519 "+repo.Status.GeneratedDeployKeys["generated-due"].Key, strings.TrimSpace(string(secret.Data["publicKey"])))
+	assert.WithinDuration(t, time.Now(), synv1alpha1.DeployKeyGeneratedAt(secret), time.Minute)
+
+	assert.InDelta(t, 50*time.Minute, res.RequeueAfter, float64(time.Minute), "should requeue when the fresh key is due")
+}
+
 func TestSteps_CIVariables(t *testing.T) {
 	scheme := runtime.NewScheme()
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
@@ -636,6 +712,159 @@ func TestSteps_CIVariables(t *testing.T) {
 	assert.ElementsMatch(t, varNames[1:], callVarNames)
 }
 
+func TestStepsDecommission(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(synv1alpha1.AddToScheme(scheme))
+
+	tcs := map[string]struct {
+		decommission synv1alpha1.GitRepoDecommission
+		exists       bool
+
+		wantRevoked  bool
+		wantArchived bool
+		wantPolicy   synv1alpha1.DeletionPolicy
+	}{
+		"revoke access": {
+			decommission: synv1alpha1.RevokeAccessDecommission,
+			exists:       true,
+			wantRevoked:  true,
+			wantPolicy:   synv1alpha1.DeletePolicy,
+		},
+		"archive": {
+			decommission: synv1alpha1.ArchiveDecommission,
+			exists:       true,
+			wantRevoked:  true,
+			wantArchived: true,
+			wantPolicy:   synv1alpha1.ArchivePolicy,
+		},
+		"missing repo": {
+			decommission: synv1alpha1.RevokeAccessDecommission,
+			wantPolicy:   synv1alpha1.DeletePolicy,
+		},
+	}
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			repo := &synv1alpha1.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "c-bar",
+					Namespace: "foo",
+				},
+				Spec: synv1alpha1.GitRepoSpec{
+					GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+						Path:           "foo",
+						RepoName:       "bar",
+						RepoType:       synv1alpha1.AutoRepoType,
+						DeletionPolicy: synv1alpha1.DeletePolicy,
+						DeployKeys: map[string]synv1alpha1.DeployKey{
+							"steward": {Type: "ssh-ed25519", Key: "AAAA"},
+						},
+						AccessToken: synv1alpha1.AccessToken{
+							SecretRef: "c-bar-api-token",
+						},
+						CIVariables: []synv1alpha1.EnvVar{{Name: "FOO", Value: "bar"}},
+					},
+					Decommission: tc.decommission,
+				},
+				Status: synv1alpha1.GitRepoStatus{
+					URL: "https://git.example.com/foo/bar",
+				},
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "c-bar-api-token",
+					Namespace: "foo",
+				},
+			}
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(repo, secret).
+				WithStatusSubresource(&synv1alpha1.GitRepo{}).
+				Build()
+			repoURL, err := url.Parse(repo.Status.URL)
+			require.NoError(t, err)
+			fr := &fakeRepo{
+				exists:     tc.exists,
+				url:        repoURL,
+				headCommit: "0123456789abcdef",
+			}
+			var clientInstance *synv1alpha1.GitRepo
+			gc := func(ctx context.Context, instance *synv1alpha1.GitRepo, reqLogger logr.Logger, client client.Client) (manager.Repo, string, error) {
+				clientInstance = instance
+				return fr, "", nil
+			}
+
+			res := steps(repo, &pipeline.Context{
+				Context: context.TODO(),
+				Client:  c,
+				Log:     testr.New(t),
+			}, gc)
+			require.NoError(t, res.Err)
+
+			require.NotNil(t, clientInstance)
+			assert.Empty(t, clientInstance.Spec.DeployKeys, "Git client should be created without deploy keys")
+			assert.Equal(t, tc.wantPolicy, clientInstance.Spec.DeletionPolicy)
+
+			assert.False(t, fr.created, "should not create repo")
+			assert.False(t, fr.committed, "should not commit template files")
+			assert.Empty(t, fr.ensureCIVariablesCalls, "should not update CI variables")
+
+			require.NotNil(t, repo.Status.Decommission)
+			assert.True(t, repo.Status.Decommission.AccessRevoked)
+			assert.Equal(t, tc.wantArchived, repo.Status.Decommission.Archived)
+			assert.Equal(t, tc.wantArchived, fr.removed)
+			if !tc.wantRevoked {
+				assert.Empty(t, fr.revokedTokens)
+				return
+			}
+			assert.True(t, fr.updated, "should remove deploy keys")
+			assert.Equal(t, []string{"c-bar"}, fr.revokedTokens)
+			assert.Equal(t, "0123456789abcdef", repo.Status.Decommission.LastCommit)
+			err = c.Get(context.TODO(), client.ObjectKeyFromObject(secret), &corev1.Secret{})
+			assert.True(t, apierrors.IsNotFound(err), "should delete access token secret")
+		})
+	}
+}
+
+func TestStepsDecommissionDeleteArchived(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(synv1alpha1.AddToScheme(scheme))
+
+	repo := &synv1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-bar",
+			Namespace: "foo",
+		},
+		Spec: synv1alpha1.GitRepoSpec{
+			GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+				RepoType:       synv1alpha1.AutoRepoType,
+				DeletionPolicy: synv1alpha1.DeletePolicy,
+			},
+			Decommission: synv1alpha1.ArchiveDecommission,
+		},
+		Status: synv1alpha1.GitRepoStatus{
+			Decommission: &synv1alpha1.GitRepoDecommissionStatus{
+				AccessRevoked: true,
+				Archived:      true,
+			},
+		},
+	}
+	fr := &fakeRepo{
+		exists: true,
+		url:    new(url.URL),
+	}
+
+	res := steps(repo, &pipeline.Context{
+		Context: context.TODO(),
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithObjects(repo).Build(),
+		Log:     testr.New(t),
+		Deleted: true,
+	}, fakeGitClientFactory(fr))
+	require.NoError(t, res.Err)
+	assert.False(t, fr.removed, "should retain archived repo")
+}
+
 func fakeGitClientFactory(r *fakeRepo) gitClientFactory {
 	return func(ctx context.Context, instance *synv1alpha1.GitRepo, reqLogger logr.Logger, client client.Client) (manager.Repo, string, error) {
 		return r, "", nil
@@ -662,6 +891,9 @@ type fakeRepo struct {
 	failCommit   bool
 
 	accessToken manager.ProjectAccessToken
+	headCommit  string
+
+	revokedTokens []string
 
 	ensureCIVariablesCalls []ensureCIVariablesCall
 }
@@ -717,3 +949,13 @@ func (r *fakeRepo) EnsureCIVariables(ctx context.Context, managed []string, vars
 	})
 	return nil
 }
+func (r *fakeRepo) RevokeProjectAccessTokens(ctx context.Context, name string) error {
+	r.revokedTokens = append(r.revokedTokens, name)
+	return nil
+}
+func (r *fakeRepo) HeadCommit(ctx context.Context) (string, error) {
+	return r.headCommit, nil
+}
+func (r *fakeRepo) CheckCredentials(ctx context.Context) error {
+	return nil
+}
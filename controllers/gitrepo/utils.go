@@ -70,6 +70,10 @@ func CreateOrUpdate(obj pipeline.Object, data *pipeline.Context) pipeline.Result
 	err := data.Client.Get(data.Context, client.ObjectKeyFromObject(repo), found)
 	if err != nil {
 		if errors.IsNotFound(err) {
+			// The catalog repository of a decommissioned cluster isn't recreated
+			if cluster, ok := obj.(*synv1alpha1.Cluster); ok && cluster.DecommissionRequested() {
+				return pipeline.Result{}
+			}
 			err := data.Client.Create(data.Context, repo)
 			return pipeline.Result{Err: err}
 		}
@@ -78,8 +82,16 @@ func CreateOrUpdate(obj pipeline.Object, data *pipeline.Context) pipeline.Result
 
 	data.Log.Info("Comparing gitrepo to template", "oldDeployKeys", repo.Spec.DeployKeys, "newDeployKeys", found.Spec.DeployKeys)
 
-	if !equality.Semantic.DeepEqual(found.Spec.GitRepoTemplate, repo.Spec.GitRepoTemplate) {
+	// A GitRepo which was orphaned by undeleting its owner is adopted again
+	orphaned := metav1.GetControllerOf(found) == nil
+
+	if !equality.Semantic.DeepEqual(found.Spec.GitRepoTemplate, repo.Spec.GitRepoTemplate) ||
+		found.Spec.TenantRef != repo.Spec.TenantRef || orphaned {
 		found.Spec.GitRepoTemplate = repo.Spec.GitRepoTemplate
+		found.Spec.TenantRef = repo.Spec.TenantRef
+		if orphaned {
+			found.OwnerReferences = append(found.OwnerReferences, repo.OwnerReferences...)
+		}
 		data.Log.Info("Updating gitrepo based on template", "repo", obj.GetMeta().Name)
 		if err := data.Client.Update(data.Context, found); err != nil {
 			return pipeline.Result{Err: err}
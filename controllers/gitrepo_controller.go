@@ -31,6 +31,10 @@ type GitRepoReconciler struct {
 	MaxReconcileInterval time.Duration
 
 	DeleteProtection bool
+	// DeleteConfirmation makes the default deletion protection require confirming the deletion by name
+	DeleteConfirmation bool
+	// DeletionGracePeriod is the time after the deletion of a GitRepo before the repository is touched
+	DeletionGracePeriod time.Duration
 }
 
 //+kubebuilder:rbac:groups=syn.tools,resources=gitrepos,verbs=get;list;watch;create;update;patch;delete
@@ -58,18 +62,21 @@ func (r *GitRepoReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	}
 
 	data := &pipeline.Context{
-		Context:               ctx,
-		Client:                r.Client,
-		Log:                   reqLogger,
-		FinalizerName:         synv1alpha1.FinalizerName,
-		Reconciler:            r,
-		DefaultCreationPolicy: r.DefaultCreationPolicy,
-		UseDeletionProtection: r.DeleteProtection,
+		Context:                 ctx,
+		Client:                  r.Client,
+		Log:                     reqLogger,
+		FinalizerName:           synv1alpha1.FinalizerName,
+		Reconciler:              r,
+		DefaultCreationPolicy:   r.DefaultCreationPolicy,
+		UseDeletionProtection:   r.DeleteProtection,
+		UseDeletionConfirmation: r.DeleteConfirmation,
+		DeletionGracePeriod:     r.DeletionGracePeriod,
 	}
 
 	steps := []pipeline.Step{
 		{Name: "copy original object", F: pipeline.DeepCopyOriginal},
 		{Name: "deletion check", F: pipeline.CheckIfDeleted},
+		{Name: "check deletion protection", F: pipeline.CheckDeletionProtection},
 		{Name: "git repo specific steps", F: gitrepo.Steps},
 		{Name: "add tenant label", F: pipeline.AddTenantLabel},
 		{Name: "Common", F: pipeline.Common},
@@ -82,9 +89,9 @@ func (r *GitRepoReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return reconcile.Result{Requeue: true}, res.Err
 	}
 
-	// Requeue after the maximum interval
+	// Requeue at the next deadline of a step, but at least after the maximum interval
 	return reconcile.Result{
-		RequeueAfter: r.MaxReconcileInterval,
+		RequeueAfter: pipeline.EarliestRequeue(res.RequeueAfter, r.MaxReconcileInterval),
 	}, res.Err
 }
 
@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/controllers/cluster"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+)
+
+// validateClusterTemplate reports whether the cluster template of the tenant can be rendered in the ClusterTemplateValid condition.
+// An invalid template doesn't stop the reconciliation of the tenant, the clusters of the tenant fail to apply it instead.
+func validateClusterTemplate(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	tenant, ok := obj.(*synv1alpha1.Tenant)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a tenant")}
+	}
+
+	cond := metav1.Condition{
+		Type:               synv1alpha1.ConditionClusterTemplateValid,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Valid",
+		Message:            "Cluster template is valid",
+		ObservedGeneration: tenant.Generation,
+	}
+	if err := cluster.ValidateClusterTemplate(tenant); err != nil {
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = "Invalid"
+		cond.Message = err.Error()
+		data.Log.Info("Cluster template is invalid", "error", err.Error())
+	}
+	meta.SetStatusCondition(&tenant.Status.Conditions, cond)
+
+	return pipeline.Result{}
+}
+
+// dryRenderClusterTemplate renders the cluster template of the tenant for the cluster named in the dry render annotation
+// and reports the changes it would make in the status of the tenant.
+func dryRenderClusterTemplate(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	tenant, ok := obj.(*synv1alpha1.Tenant)
+	if !ok {
+		return pipeline.Result{Err: fmt.Errorf("object is not a tenant")}
+	}
+
+	name := tenant.GetAnnotations()[synv1alpha1.DryRenderClusterAnnotation]
+	if name == "" {
+		tenant.Status.ClusterTemplatePreview = nil
+		return pipeline.Result{}
+	}
+
+	preview := &synv1alpha1.ClusterTemplatePreview{Cluster: name}
+	tenant.Status.ClusterTemplatePreview = preview
+
+	c := &synv1alpha1.Cluster{}
+	if err := data.Client.Get(data.Context, client.ObjectKey{Name: name, Namespace: tenant.Namespace}, c); err != nil {
+		preview.Error = fmt.Sprintf("failed to get cluster: %s", err)
+		return pipeline.Result{}
+	}
+	if c.Spec.TenantRef.Name != tenant.Name {
+		preview.Error = fmt.Sprintf("cluster belongs to tenant %q", c.Spec.TenantRef.Name)
+		return pipeline.Result{}
+	}
+
+	changes, err := cluster.DryRenderClusterTemplate(c, tenant)
+	if err != nil {
+		preview.Error = err.Error()
+		return pipeline.Result{}
+	}
+	for _, change := range changes {
+		before, err := encodeFieldValue(change.Old)
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
+		after, err := encodeFieldValue(change.New)
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
+		preview.Changes = append(preview.Changes, synv1alpha1.ClusterTemplateFieldChange{
+			Path: change.Path,
+			Old:  before,
+			New:  after,
+		})
+	}
+
+	return pipeline.Result{}
+}
+
+// encodeFieldValue returns the JSON encoding of the value, or an empty string if the value isn't set
+func encodeFieldValue(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field value: %w", err)
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,157 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func Test_validateClusterTemplate(t *testing.T) {
+	tests := map[string]struct {
+		displayName string
+		wantStatus  metav1.ConditionStatus
+	}{
+		"valid": {
+			displayName: "{{ .Name }}",
+			wantStatus:  metav1.ConditionTrue,
+		},
+		"invalid": {
+			displayName: "{{ .Name ",
+			wantStatus:  metav1.ConditionFalse,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tenant := &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "t-tenant",
+					Namespace: "lieutenant",
+				},
+				Spec: synv1alpha1.TenantSpec{
+					ClusterTemplate: &synv1alpha1.ClusterSpec{
+						DisplayName: tc.displayName,
+					},
+				},
+			}
+
+			res := validateClusterTemplate(tenant, &pipeline.Context{
+				Context: ctx,
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+			assert.False(t, res.Abort, "an invalid template must not stop the reconciliation")
+
+			cond := meta.FindStatusCondition(tenant.Status.Conditions, synv1alpha1.ConditionClusterTemplateValid)
+			require.NotNil(t, cond)
+			assert.Equal(t, tc.wantStatus, cond.Status)
+		})
+	}
+}
+
+func Test_dryRenderClusterTemplate(t *testing.T) {
+	newCluster := func(name, tenant string) *synv1alpha1.Cluster {
+		return &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "lieutenant",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				TenantRef: corev1.LocalObjectReference{Name: tenant},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		annotation  string
+		displayName string
+		previous    *synv1alpha1.ClusterTemplatePreview
+
+		wantPreview *synv1alpha1.ClusterTemplatePreview
+	}{
+		"no annotation": {
+			previous: &synv1alpha1.ClusterTemplatePreview{Cluster: "c-cluster"},
+		},
+		"changes": {
+			annotation:  "c-cluster",
+			displayName: "{{ .Name }}",
+			wantPreview: &synv1alpha1.ClusterTemplatePreview{
+				Cluster: "c-cluster",
+				Changes: []synv1alpha1.ClusterTemplateFieldChange{
+					{Path: "spec.displayName", New: `"c-cluster"`},
+				},
+			},
+		},
+		"invalid template": {
+			annotation:  "c-cluster",
+			displayName: "{{ .Name ",
+			wantPreview: &synv1alpha1.ClusterTemplatePreview{
+				Cluster: "c-cluster",
+				Error:   "unclosed action",
+			},
+		},
+		"other tenant": {
+			annotation:  "c-other",
+			displayName: "{{ .Name }}",
+			wantPreview: &synv1alpha1.ClusterTemplatePreview{
+				Cluster: "c-other",
+				Error:   `cluster belongs to tenant "t-other"`,
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tenant := &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "t-tenant",
+					Namespace:   "lieutenant",
+					Annotations: map[string]string{},
+				},
+				Spec: synv1alpha1.TenantSpec{
+					ClusterTemplate: &synv1alpha1.ClusterSpec{
+						DisplayName: tc.displayName,
+					},
+				},
+				Status: synv1alpha1.TenantStatus{
+					ClusterTemplatePreview: tc.previous,
+				},
+			}
+			if tc.annotation != "" {
+				tenant.Annotations[synv1alpha1.DryRenderClusterAnnotation] = tc.annotation
+			}
+			c := prepareClient(t, testCfg{obj: []client.Object{
+				newCluster("c-cluster", "t-tenant"),
+				newCluster("c-other", "t-other"),
+			}})
+
+			res := dryRenderClusterTemplate(tenant, &pipeline.Context{
+				Context: ctx,
+				Client:  c,
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+			if tc.wantPreview == nil || tc.wantPreview.Error == "" {
+				assert.Equal(t, tc.wantPreview, tenant.Status.ClusterTemplatePreview)
+			} else {
+				require.NotNil(t, tenant.Status.ClusterTemplatePreview)
+				assert.Equal(t, tc.wantPreview.Cluster, tenant.Status.ClusterTemplatePreview.Cluster)
+				assert.Contains(t, tenant.Status.ClusterTemplatePreview.Error, tc.wantPreview.Error)
+				assert.Empty(t, tenant.Status.ClusterTemplatePreview.Changes)
+			}
+
+			current := &synv1alpha1.Cluster{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "c-cluster", Namespace: "lieutenant"}, current))
+			assert.Empty(t, current.Spec.DisplayName, "dry rendering must not change the cluster")
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package tenant
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clusterDeletionPollInterval is the interval in which the clusters are checked while the deletion of a tenant is blocked
+const clusterDeletionPollInterval = 10 * time.Second
+
+// checkClusters blocks the deletion of a tenant while clusters reference it.
+// Clusters which are being deleted still block it, as their finalizers need the tenant.
+// The check is skipped if the tenant is annotated with the force delete annotation.
+func checkClusters(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	if !data.Deleted {
+		return pipeline.Result{}
+	}
+	if force, _ := strconv.ParseBool(obj.GetAnnotations()[synv1alpha1.ForceDeleteAnnotation]); force {
+		return pipeline.Result{}
+	}
+
+	clusters := &synv1alpha1.ClusterList{}
+	if err := data.Client.List(data.Context, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		return pipeline.Result{Err: fmt.Errorf("listing clusters: %w", err)}
+	}
+
+	remaining := []string{}
+	for _, cluster := range clusters.Items {
+		// Clusters which are moved away from the tenant still store resources with it
+		if cluster.Spec.TenantRef.Name == obj.GetName() || cluster.Status.Tenant == obj.GetName() {
+			remaining = append(remaining, cluster.Name)
+		}
+	}
+	if len(remaining) == 0 {
+		return pipeline.Result{}
+	}
+
+	data.Log.Info("Tenant deletion is blocked until its clusters are deleted", "clusters", remaining)
+	// The finalizers of clusters can be blocked for a long time, so the clusters are polled instead of relying on their events
+	return pipeline.Result{Abort: true, RequeueAfter: clusterDeletionPollInterval}
+}
@@ -0,0 +1,95 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func Test_checkClusters(t *testing.T) {
+	tests := map[string]struct {
+		deleted             bool
+		annotations         map[string]string
+		clusterTenant       string
+		clusterStatusTenant string
+		clusterDeleted      bool
+
+		wantAbort bool
+	}{
+		"not deleted": {
+			clusterTenant: "t-tenant",
+		},
+		"no clusters": {
+			deleted:       true,
+			clusterTenant: "t-other",
+		},
+		"clusters exist": {
+			deleted:       true,
+			clusterTenant: "t-tenant",
+			wantAbort:     true,
+		},
+		"clusters are being deleted": {
+			deleted:        true,
+			clusterTenant:  "t-tenant",
+			clusterDeleted: true,
+			wantAbort:      true,
+		},
+		"cluster is moved away": {
+			deleted:             true,
+			clusterTenant:       "t-other",
+			clusterStatusTenant: "t-tenant",
+			wantAbort:           true,
+		},
+		"forced": {
+			deleted:       true,
+			annotations:   map[string]string{synv1alpha1.ForceDeleteAnnotation: "true"},
+			clusterTenant: "t-tenant",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			tenant := &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "t-tenant",
+					Namespace:   "lieutenant",
+					Annotations: tc.annotations,
+				},
+			}
+			cluster := &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "c-cluster",
+					Namespace: "lieutenant",
+				},
+				Spec: synv1alpha1.ClusterSpec{
+					TenantRef: corev1.LocalObjectReference{Name: tc.clusterTenant},
+				},
+				Status: synv1alpha1.ClusterStatus{
+					Tenant: tc.clusterStatusTenant,
+				},
+			}
+			if tc.clusterDeleted {
+				cluster.Finalizers = []string{synv1alpha1.FinalizerName}
+				cluster.DeletionTimestamp = &metav1.Time{Time: metav1.Now().Time}
+			}
+
+			res := checkClusters(tenant, &pipeline.Context{
+				Context: ctx,
+				Client:  prepareClient(t, testCfg{obj: []client.Object{cluster}}),
+				Log:     log.FromContext(ctx),
+				Deleted: tc.deleted,
+			})
+			require.NoError(t, res.Err)
+			assert.Equal(t, tc.wantAbort, res.Abort)
+			assert.Equal(t, tc.wantAbort, res.RequeueAfter > 0, "a blocked deletion should be checked again")
+		})
+	}
+}
@@ -27,10 +27,13 @@ func reconcileRole(obj pipeline.Object, data *pipeline.Context) pipeline.Result
 		return pipeline.Result{Err: fmt.Errorf("failed to list clusters: %w", err)}
 	}
 	clusterNames := make([]string, 0, len(cls.Items))
+	tokenSecretNames := make([]string, 0, len(cls.Items))
 	for _, c := range cls.Items {
 		clusterNames = append(clusterNames, c.Name)
+		tokenSecretNames = append(tokenSecretNames, c.GetBootstrapTokenSecretName())
 	}
 	slices.Sort(clusterNames)
+	slices.Sort(tokenSecretNames)
 
 	role := rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
@@ -60,6 +63,12 @@ func reconcileRole(obj pipeline.Object, data *pipeline.Context) pipeline.Result
 					Verbs:         []string{"get", "update", "patch"},
 					Resources:     []string{"clusters/status"},
 					ResourceNames: clusterNames,
+				},
+				rbacv1.PolicyRule{
+					APIGroups:     []string{""},
+					Verbs:         []string{"get"},
+					Resources:     []string{"secrets"},
+					ResourceNames: tokenSecretNames,
 				})
 		}
 		return controllerutil.SetControllerReference(tenant, &role, data.Client.Scheme())
@@ -0,0 +1,117 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func Test_reconcileRole(t *testing.T) {
+	newCluster := func(name, tenant string) *synv1alpha1.Cluster {
+		return &synv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "lieutenant",
+			},
+			Spec: synv1alpha1.ClusterSpec{
+				TenantRef: corev1.LocalObjectReference{Name: tenant},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		clusters []client.Object
+
+		wantRules []rbacv1.PolicyRule
+	}{
+		"no clusters": {
+			clusters: []client.Object{newCluster("c-other", "t-other")},
+			wantRules: []rbacv1.PolicyRule{
+				{
+					APIGroups:     []string{synv1alpha1.GroupVersion.Group},
+					Verbs:         []string{"get"},
+					Resources:     []string{"tenants"},
+					ResourceNames: []string{"t-tenant"},
+				},
+			},
+		},
+		"clusters": {
+			clusters: []client.Object{
+				newCluster("c-b", "t-tenant"),
+				newCluster("c-a", "t-tenant"),
+				newCluster("c-other", "t-other"),
+			},
+			wantRules: []rbacv1.PolicyRule{
+				{
+					APIGroups:     []string{synv1alpha1.GroupVersion.Group},
+					Verbs:         []string{"get"},
+					Resources:     []string{"tenants"},
+					ResourceNames: []string{"t-tenant"},
+				},
+				{
+					APIGroups:     []string{synv1alpha1.GroupVersion.Group},
+					Verbs:         []string{"get"},
+					Resources:     []string{"clusters"},
+					ResourceNames: []string{"c-a", "c-b"},
+				},
+				{
+					APIGroups:     []string{synv1alpha1.GroupVersion.Group},
+					Verbs:         []string{"get", "update", "patch"},
+					Resources:     []string{"clusters/status"},
+					ResourceNames: []string{"c-a", "c-b"},
+				},
+				{
+					APIGroups:     []string{""},
+					Verbs:         []string{"get"},
+					Resources:     []string{"secrets"},
+					ResourceNames: []string{"c-a-bootstrap-token", "c-b-bootstrap-token"},
+				},
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			scheme := runtime.NewScheme()
+			utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+			utilruntime.Must(synv1alpha1.AddToScheme(scheme))
+			c := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(tc.clusters...).
+				WithIndex(&synv1alpha1.Cluster{}, "spec.tenantRef.name", func(o client.Object) []string {
+					return []string{o.(*synv1alpha1.Cluster).Spec.TenantRef.Name}
+				}).
+				Build()
+
+			tenant := &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "t-tenant",
+					Namespace: "lieutenant",
+				},
+			}
+			res := reconcileRole(tenant, &pipeline.Context{
+				Context: ctx,
+				Client:  c,
+				Log:     log.FromContext(ctx),
+			})
+			require.NoError(t, res.Err)
+
+			role := &rbacv1.Role{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Name: "t-tenant", Namespace: "lieutenant"}, role))
+			assert.Equal(t, tc.wantRules, role.Rules)
+		})
+	}
+}
@@ -2,11 +2,19 @@ package tenant
 
 import (
 	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/projectsyn/lieutenant-operator/vault"
 )
 
 func Steps(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
 	steps := []pipeline.Step{
+		{Name: "deletion check", F: pipeline.CheckIfDeleted},
+		{Name: "check deletion protection", F: pipeline.CheckDeletionProtection},
+		{Name: "check clusters", F: checkClusters},
+		{Name: "delete vault entries", F: vault.HandleTenantVaultDeletion},
 		{Name: "apply template from TenantTemplate", F: applyTemplateFromTenantTemplate},
+		{Name: "validate cluster template", F: validateClusterTemplate},
+		{Name: "dry render cluster template", F: dryRenderClusterTemplate},
+		{Name: "sync shared secrets", F: vault.SyncSharedSecrets},
 		{Name: "add default class file", F: addDefaultClassFile},
 		{Name: "update tenant git repo", F: updateTenantGitRepo},
 		{Name: "set global git repo url", F: setGlobalGitRepoURL},
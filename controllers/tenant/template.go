@@ -2,12 +2,17 @@ package tenant
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/controllers/cluster"
 	"github.com/projectsyn/lieutenant-operator/pipeline"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"github.com/ryankurte/go-structparse"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func applyTemplateFromTenantTemplate(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
@@ -16,10 +21,9 @@ func applyTemplateFromTenantTemplate(obj pipeline.Object, data *pipeline.Context
 		return pipeline.Result{Err: fmt.Errorf("object is not a tenant")}
 	}
 
-	key := types.NamespacedName{Name: "default", Namespace: obj.GetNamespace()}
-	template := &synv1alpha1.TenantTemplate{}
-	if err := data.Client.Get(data.Context, key, template); err != nil {
-		if errors.IsNotFound(err) || runtime.IsNotRegisteredError(err) {
+	templates := &synv1alpha1.TenantTemplateList{}
+	if err := data.Client.List(data.Context, templates, client.InNamespace(obj.GetNamespace())); err != nil {
+		if runtime.IsNotRegisteredError(err) {
 			// The absence of a template is not an error.
 			// It simply means that there is nothing to do.
 			data.Log.Info("No template found to apply to tenant.")
@@ -31,9 +35,160 @@ func applyTemplateFromTenantTemplate(obj pipeline.Object, data *pipeline.Context
 		}
 	}
 
-	if err := tenant.ApplyTemplate(template); err != nil {
+	selected, err := SelectTemplates(tenant, templates.Items)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("select tenant templates: %w", err)}
+	}
+	if len(selected) == 0 {
+		data.Log.Info("No template found to apply to tenant.")
+	}
+
+	for i := range selected {
+		if err := renderTemplate(&selected[i], tenant); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("render tenant template %q: %w", selected[i].Name, err)}
+		}
+	}
+
+	if err := tenant.ApplyTemplates(selected); err != nil {
 		return pipeline.Result{Err: fmt.Errorf("apply tenant template: %w", err)}
 	}
 
 	return pipeline.Result{}
 }
+
+type templateParser struct {
+	data *synv1alpha1.Tenant
+	err  error
+}
+
+func (r *templateParser) ParseString(in string) interface{} {
+	if r.err != nil || len(in) == 0 {
+		return in
+	}
+	str, err := cluster.RenderTemplate(in, r.data)
+	if err != nil {
+		r.err = err
+		return in
+	}
+	return str
+}
+
+// renderTemplate renders the string fields of the given template with the tenant as context.
+// The cluster template is skipped, it's rendered with the cluster as context when applied to a cluster.
+func renderTemplate(template *synv1alpha1.TenantTemplate, tenant *synv1alpha1.Tenant) error {
+	clusterTemplate := template.Spec.ClusterTemplate
+	template.Spec.ClusterTemplate = nil
+	defer func() { template.Spec.ClusterTemplate = clusterTemplate }()
+
+	// To avoid rendering the template in the actual tenant
+	parser := &templateParser{
+		data: tenant.DeepCopy(),
+	}
+	structparse.Strings(parser, &template.Spec.TenantSpec)
+	if parser.err != nil {
+		return fmt.Errorf("an error occurred during template manifestation: %w", parser.err)
+	}
+	return nil
+}
+
+// SelectTemplates returns the templates selected by the given tenant, ordered by precedence.
+// Templates listed in the TenantTemplatesAnnotation come first, in the given order.
+// They are followed by the templates matching the TenantTemplateSelectorAnnotation, ordered by their priority and name.
+// If the tenant selects no templates at all, the template named DefaultTenantTemplateName is selected if it exists.
+func SelectTemplates(tenant *synv1alpha1.Tenant, templates []synv1alpha1.TenantTemplate) ([]synv1alpha1.TenantTemplate, error) {
+	byName := make(map[string]synv1alpha1.TenantTemplate, len(templates))
+	for _, t := range templates {
+		byName[t.Name] = t
+	}
+
+	names, selector, err := templateSelection(tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(names) == 0 && selector == nil {
+		if t, ok := byName[synv1alpha1.DefaultTenantTemplateName]; ok {
+			return []synv1alpha1.TenantTemplate{t}, nil
+		}
+		return []synv1alpha1.TenantTemplate{}, nil
+	}
+
+	selected := make([]synv1alpha1.TenantTemplate, 0, len(names))
+	for _, name := range names {
+		t, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("tenant template %q not found", name)
+		}
+		selected = append(selected, t)
+	}
+
+	if selector == nil {
+		return selected, nil
+	}
+
+	matching := make([]synv1alpha1.TenantTemplate, 0, len(templates))
+	for _, t := range templates {
+		if slices.Contains(names, t.Name) || !selector.Matches(labels.Set(t.GetLabels())) {
+			continue
+		}
+		matching = append(matching, t)
+	}
+	slices.SortStableFunc(matching, func(a, b synv1alpha1.TenantTemplate) int {
+		if pa, pb := templatePriority(a), templatePriority(b); pa != pb {
+			return pb - pa
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return append(selected, matching...), nil
+}
+
+// SelectsTemplate returns true if the given template would be selected by the tenant.
+func SelectsTemplate(tenant *synv1alpha1.Tenant, template *synv1alpha1.TenantTemplate) bool {
+	names, selector, err := templateSelection(tenant)
+	if err != nil {
+		return false
+	}
+	if len(names) == 0 && selector == nil {
+		return template.Name == synv1alpha1.DefaultTenantTemplateName
+	}
+	if slices.Contains(names, template.Name) {
+		return true
+	}
+	return selector != nil && selector.Matches(labels.Set(template.GetLabels()))
+}
+
+// templateSelection parses the template selection annotations of the tenant.
+// The returned selector is nil if the tenant doesn't select templates by label.
+func templateSelection(tenant *synv1alpha1.Tenant) ([]string, labels.Selector, error) {
+	annotations := tenant.GetAnnotations()
+
+	names := []string{}
+	for _, name := range strings.Split(annotations[synv1alpha1.TenantTemplatesAnnotation], ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	rawSelector := strings.TrimSpace(annotations[synv1alpha1.TenantTemplateSelectorAnnotation])
+	if rawSelector == "" {
+		return names, nil, nil
+	}
+	selector, err := labels.Parse(rawSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid tenant template selector %q: %w", rawSelector, err)
+	}
+
+	return names, selector, nil
+}
+
+// templatePriority returns the priority of the template.
+// Templates without or with an invalid priority annotation have a priority of 0.
+func templatePriority(template synv1alpha1.TenantTemplate) int {
+	p, err := strconv.Atoi(template.GetAnnotations()[synv1alpha1.TenantTemplatePriorityAnnotation])
+	if err != nil {
+		return 0
+	}
+	return p
+}
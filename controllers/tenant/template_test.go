@@ -0,0 +1,281 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func newTemplate(name string, labels, annotations map[string]string, spec synv1alpha1.TenantSpec) synv1alpha1.TenantTemplate {
+	return synv1alpha1.TenantTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "lieutenant",
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: synv1alpha1.TenantTemplateSpec{TenantSpec: spec},
+	}
+}
+
+var selectTemplatesTemplates = []synv1alpha1.TenantTemplate{
+	newTemplate("default", nil, nil, synv1alpha1.TenantSpec{}),
+	newTemplate("managed", map[string]string{"flavor": "managed"}, nil, synv1alpha1.TenantSpec{}),
+	newTemplate("managed-base", map[string]string{"flavor": "managed"}, map[string]string{
+		synv1alpha1.TenantTemplatePriorityAnnotation: "-10",
+	}, synv1alpha1.TenantSpec{}),
+	newTemplate("managed-high", map[string]string{"flavor": "managed"}, map[string]string{
+		synv1alpha1.TenantTemplatePriorityAnnotation: "10",
+	}, synv1alpha1.TenantSpec{}),
+	newTemplate("internal", map[string]string{"flavor": "internal"}, nil, synv1alpha1.TenantSpec{}),
+}
+
+var selectTemplatesCases = map[string]struct {
+	annotations map[string]string
+	want        []string
+	wantErr     bool
+}{
+	"default template": {
+		want: []string{"default"},
+	},
+	"explicit list": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplatesAnnotation: "internal, default",
+		},
+		want: []string{"internal", "default"},
+	},
+	"explicit list with missing template": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplatesAnnotation: "internal,missing",
+		},
+		wantErr: true,
+	},
+	"selector ordered by priority": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplateSelectorAnnotation: "flavor=managed",
+		},
+		want: []string{"managed-high", "managed", "managed-base"},
+	},
+	"explicit list before selector": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplatesAnnotation:        "managed-base",
+			synv1alpha1.TenantTemplateSelectorAnnotation: "flavor in (managed)",
+		},
+		want: []string{"managed-base", "managed-high", "managed"},
+	},
+	"selector without match": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplateSelectorAnnotation: "flavor=self-service",
+		},
+		want: []string{},
+	},
+	"invalid selector": {
+		annotations: map[string]string{
+			synv1alpha1.TenantTemplateSelectorAnnotation: "flavor in in",
+		},
+		wantErr: true,
+	},
+}
+
+func Test_SelectTemplates(t *testing.T) {
+	for name, tc := range selectTemplatesCases {
+		t.Run(name, func(t *testing.T) {
+			tenant := &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "t-tenant",
+					Annotations: tc.annotations,
+				},
+			}
+			selected, err := SelectTemplates(tenant, selectTemplatesTemplates)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(selected))
+			for _, s := range selected {
+				names = append(names, s.Name)
+				assert.True(t, SelectsTemplate(tenant, &s))
+			}
+			assert.Equal(t, tc.want, names)
+		})
+	}
+}
+
+func Test_applyTemplateFromTenantTemplate_Layered(t *testing.T) {
+	ctx := context.Background()
+	base := newTemplate("base", map[string]string{"flavor": "managed"}, nil, synv1alpha1.TenantSpec{
+		DisplayName:      "Base",
+		GlobalGitRepoURL: "https://git.example.com/global-base.git",
+		DeletionPolicy:   synv1alpha1.RetainPolicy,
+	})
+	managed := newTemplate("managed", map[string]string{"flavor": "managed"}, map[string]string{
+		synv1alpha1.TenantTemplatePriorityAnnotation: "10",
+	}, synv1alpha1.TenantSpec{
+		GlobalGitRepoURL: "https://git.example.com/global-managed.git",
+	})
+	c := prepareClient(t, testCfg{obj: []client.Object{&base, &managed}})
+
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+			Annotations: map[string]string{
+				synv1alpha1.TenantTemplateSelectorAnnotation: "flavor=managed",
+			},
+		},
+		Spec: synv1alpha1.TenantSpec{
+			DisplayName: "My Tenant",
+		},
+	}
+
+	res := applyTemplateFromTenantTemplate(tenant, &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	})
+	require.NoError(t, res.Err)
+
+	assert.Equal(t, "My Tenant", tenant.Spec.DisplayName)
+	assert.Equal(t, "https://git.example.com/global-managed.git", tenant.Spec.GlobalGitRepoURL)
+	assert.Equal(t, synv1alpha1.RetainPolicy, tenant.Spec.DeletionPolicy)
+	assert.Equal(t, []string{"managed", "base"}, tenant.GetAppliedTemplates())
+	assert.Equal(t, "managed,base", tenant.Annotations[synv1alpha1.TenantTemplateAnnotation])
+}
+
+func Test_applyTemplateFromTenantTemplate_Render(t *testing.T) {
+	ctx := context.Background()
+	template := newTemplate("default", nil, nil, synv1alpha1.TenantSpec{
+		GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+			RepoName: "{{ .Name }}",
+			Path:     "tenants/{{ .Spec.DisplayName | printf \"%.3s\" }}",
+			CIVariables: []synv1alpha1.EnvVar{
+				{Name: "TENANT", Value: "{{ .Name }}"},
+			},
+		},
+		ClusterTemplate: &synv1alpha1.ClusterSpec{
+			DisplayName: "{{ .Name }}",
+		},
+	})
+	c := prepareClient(t, testCfg{obj: []client.Object{&template}})
+
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			DisplayName: "Acme Corp",
+		},
+	}
+
+	res := applyTemplateFromTenantTemplate(tenant, &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	})
+	require.NoError(t, res.Err)
+
+	require.NotNil(t, tenant.Spec.GitRepoTemplate)
+	assert.Equal(t, "t-tenant", tenant.Spec.GitRepoTemplate.RepoName)
+	assert.Equal(t, "tenants/Acm", tenant.Spec.GitRepoTemplate.Path)
+	assert.Equal(t, []synv1alpha1.EnvVar{{Name: "TENANT", Value: "t-tenant"}}, tenant.Spec.GitRepoTemplate.CIVariables)
+	require.NotNil(t, tenant.Spec.ClusterTemplate)
+	assert.Equal(t, "{{ .Name }}", tenant.Spec.ClusterTemplate.DisplayName, "cluster template must not be rendered with the tenant")
+}
+
+func Test_applyTemplateFromTenantTemplate_RenderError(t *testing.T) {
+	ctx := context.Background()
+	template := newTemplate("default", nil, nil, synv1alpha1.TenantSpec{
+		DisplayName: "{{ .Name ",
+	})
+	c := prepareClient(t, testCfg{obj: []client.Object{&template}})
+
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+	}
+
+	res := applyTemplateFromTenantTemplate(tenant, &pipeline.Context{
+		Context: ctx,
+		Client:  c,
+		Log:     log.FromContext(ctx),
+	})
+	require.Error(t, res.Err)
+	assert.Empty(t, tenant.Spec.DisplayName)
+}
+
+func Test_applyTemplateFromTenantTemplate_MergeStrategies(t *testing.T) {
+	ctx := context.Background()
+	base := newTemplate("base", map[string]string{"flavor": "managed"}, nil, synv1alpha1.TenantSpec{
+		DeletionPolicy: synv1alpha1.ArchivePolicy,
+		GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+			CIVariables: []synv1alpha1.EnvVar{
+				{Name: "BASE", Value: "base"},
+			},
+		},
+	})
+	base.Spec.MergeStrategies = []synv1alpha1.MergeStrategy{
+		{Path: "deletionPolicy", Strategy: synv1alpha1.EnforceMergeStrategy},
+		{Path: "gitRepoTemplate.ciVariables", Strategy: synv1alpha1.AppendMergeStrategy},
+	}
+	managed := newTemplate("managed", map[string]string{"flavor": "managed"}, map[string]string{
+		synv1alpha1.TenantTemplatePriorityAnnotation: "10",
+	}, synv1alpha1.TenantSpec{
+		DeletionPolicy: synv1alpha1.RetainPolicy,
+		GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+			CIVariables: []synv1alpha1.EnvVar{
+				{Name: "MANDATORY", Value: "managed"},
+			},
+		},
+	})
+	managed.Spec.MergeStrategies = []synv1alpha1.MergeStrategy{
+		{Path: "deletionPolicy", Strategy: synv1alpha1.EnforceMergeStrategy},
+		{Path: "gitRepoTemplate.ciVariables", Strategy: synv1alpha1.MergeByNameMergeStrategy},
+	}
+	c := prepareClient(t, testCfg{obj: []client.Object{&base, &managed}})
+
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+			Annotations: map[string]string{
+				synv1alpha1.TenantTemplateSelectorAnnotation: "flavor=managed",
+			},
+		},
+		Spec: synv1alpha1.TenantSpec{
+			DeletionPolicy: synv1alpha1.DeletePolicy,
+			GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+				CIVariables: []synv1alpha1.EnvVar{
+					{Name: "MANDATORY", Value: "tenant"},
+					{Name: "OWN", Value: "tenant"},
+				},
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		res := applyTemplateFromTenantTemplate(tenant, &pipeline.Context{
+			Context: ctx,
+			Client:  c,
+			Log:     log.FromContext(ctx),
+		})
+		require.NoError(t, res.Err)
+	}
+
+	assert.Equal(t, synv1alpha1.RetainPolicy, tenant.Spec.DeletionPolicy, "the template with the highest precedence enforces the field")
+	assert.Equal(t, []synv1alpha1.EnvVar{
+		{Name: "MANDATORY", Value: "managed"},
+		{Name: "OWN", Value: "tenant"},
+		{Name: "BASE", Value: "base"},
+	}, tenant.Spec.GitRepoTemplate.CIVariables)
+}
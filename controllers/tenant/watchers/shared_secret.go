@@ -0,0 +1,61 @@
+package watchers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+const (
+	// TenantSharedSecretRefNameIndex is the index name for the Tenant objects that reference a secret by name.
+	TenantSharedSecretRefNameIndex = "spec.sharedSecrets.secretRef.name"
+)
+
+// SecretTenantSharedSecretsMapFunc returns a handler function that will return a list of reconcile.Requests for Tenant objects
+// that reference the secret in the given Secret object.
+// It requires the field index TenantSharedSecretRefNameIndex to be installed for the Tenant objects.
+func SecretTenantSharedSecretsMapFunc(cli client.Client) func(ctx context.Context, o client.Object) []reconcile.Request {
+	return func(ctx context.Context, o client.Object) []reconcile.Request {
+		l := log.FromContext(ctx).WithName("SecretTenantSharedSecretsMapFunc").WithValues("secret", o.GetName())
+
+		secret := o.(*corev1.Secret)
+
+		var tenants synv1alpha1.TenantList
+		if err := cli.List(ctx, &tenants, client.MatchingFields{
+			TenantSharedSecretRefNameIndex: secret.Name,
+		}, client.InNamespace(secret.GetNamespace())); err != nil {
+			l.Error(err, "unable to list Tenants")
+			return []reconcile.Request{}
+		}
+
+		requests := make([]reconcile.Request, 0, len(tenants.Items))
+		for _, tenant := range tenants.Items {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: tenant.Namespace,
+					Name:      tenant.Name,
+				},
+			})
+		}
+
+		return requests
+	}
+}
+
+// TenantSharedSecretRefNameIndexFunc is an index function for Tenant objects.
+// It indexes the names of the secrets that are referenced by the shared secrets of the Tenant.
+func TenantSharedSecretRefNameIndexFunc(obj client.Object) []string {
+	tenant := obj.(*synv1alpha1.Tenant)
+	values := make([]string, 0, len(tenant.Spec.SharedSecrets))
+	for _, shared := range tenant.Spec.SharedSecrets {
+		if shared.SecretRef.Name != "" {
+			values = append(values, shared.SecretRef.Name)
+		}
+	}
+	return values
+}
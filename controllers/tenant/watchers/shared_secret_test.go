@@ -0,0 +1,93 @@
+package watchers_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/controllers/tenant/watchers"
+)
+
+func TestIndexAndMapFunc(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, synv1alpha1.AddToScheme(scheme))
+
+	defaultNs := "test-namespace"
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registry-credentials",
+			Namespace: defaultNs,
+		},
+		Data: map[string][]byte{
+			"password": []byte("secret"),
+		},
+	}
+
+	tenantWithSecretRef := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tenant-with-secret-ref",
+			Namespace: defaultNs,
+		},
+		Spec: synv1alpha1.TenantSpec{
+			SharedSecrets: []synv1alpha1.SharedSecret{
+				{
+					Name:      "registry",
+					SecretRef: corev1.LocalObjectReference{Name: secret.Name},
+				},
+				{
+					Name:      "api",
+					SecretRef: corev1.LocalObjectReference{Name: "other-secret"},
+				},
+			},
+		},
+	}
+	tenantWithOtherSecretRef := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tenant-with-other-secret-ref",
+			Namespace: defaultNs,
+		},
+		Spec: synv1alpha1.TenantSpec{
+			SharedSecrets: []synv1alpha1.SharedSecret{{
+				Name:      "api",
+				SecretRef: corev1.LocalObjectReference{Name: "other-secret"},
+			}},
+		},
+	}
+	tenantWithSecretRefInOtherNs := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tenant-with-secret-ref-in-other-ns",
+			Namespace: "other-namespace",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			SharedSecrets: []synv1alpha1.SharedSecret{{
+				Name:      "registry",
+				SecretRef: corev1.LocalObjectReference{Name: secret.Name},
+			}},
+		},
+	}
+	tenantWithoutRef := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tenant-without-ref",
+			Namespace: defaultNs,
+		},
+	}
+
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(secret, tenantWithSecretRef, tenantWithOtherSecretRef, tenantWithSecretRefInOtherNs, tenantWithoutRef).
+		WithIndex(&synv1alpha1.Tenant{}, watchers.TenantSharedSecretRefNameIndex, watchers.TenantSharedSecretRefNameIndexFunc).
+		Build()
+
+	requests := watchers.SecretTenantSharedSecretsMapFunc(c)(context.Background(), secret)
+	require.Len(t, requests, 1)
+	require.Equal(t, tenantWithSecretRef.Name, requests[0].Name)
+}
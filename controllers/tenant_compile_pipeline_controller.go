@@ -63,7 +63,8 @@ func (r *TenantCompilePipelineReconciler) Reconcile(ctx context.Context, request
 	}
 	clustersWithPipelineEnabled := make([]synv1alpha1.Cluster, 0, len(clusters.Items))
 	for _, cluster := range clusters.Items {
-		if cluster.GetEnableCompilePipeline() {
+		// Decommissioned clusters aren't compiled anymore
+		if cluster.GetEnableCompilePipeline() && !cluster.DecommissionRequested() {
 			clustersWithPipelineEnabled = append(clustersWithPipelineEnabled, cluster)
 		}
 	}
@@ -89,6 +90,17 @@ func (r *TenantCompilePipelineReconciler) Reconcile(ctx context.Context, request
 		changed = ensureClusterCiVariable(tenant, cluster) || changed
 	}
 
+	// Clusters which are moved to another tenant aren't labeled with this tenant anymore
+	var allClusters synv1alpha1.ClusterList
+	if err := r.Client.List(ctx, &allClusters, client.InNamespace(tenant.GetNamespace())); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error listing clusters: %w", err)
+	}
+	for _, cluster := range allClusters.Items {
+		if isMovedFromTenant(tenant, cluster) {
+			changed = removeClusterCiVariable(tenant, cluster) || changed
+		}
+	}
+
 	if changed {
 		if err := r.Client.Update(ctx, tenant); err != nil {
 			return reconcile.Result{}, fmt.Errorf("error updating tenant: %w", err)
@@ -194,15 +206,36 @@ func sortedClusterNames(clusters []synv1alpha1.Cluster) []string {
 	return names
 }
 
+// isMovedFromTenant returns true if the cluster is being moved from the given tenant to another tenant.
+func isMovedFromTenant(t *synv1alpha1.Tenant, c synv1alpha1.Cluster) bool {
+	m := c.Status.TenantMigration
+	return m != nil && m.From == t.Name && c.GetTenantRef().Name != t.Name
+}
+
+func removeClusterCiVariable(t *synv1alpha1.Tenant, c synv1alpha1.Cluster) bool {
+	template := t.GetGitTemplate()
+	list, changed := removeEnvVar(clusterCiVariableName(c), template.CIVariables)
+	if changed {
+		template.CIVariables = list
+		t.Spec.GitRepoTemplate = template
+	}
+	return changed
+}
+
+func clusterCiVariableName(c synv1alpha1.Cluster) string {
+	return fmt.Sprintf("%s%s", CI_VARIABLE_PREFIX_CLUSTER_ACCESS_TOKEN, strings.Replace(c.GetName(), "-", "_", -1))
+}
+
 func ensureClusterCiVariable(t *synv1alpha1.Tenant, c synv1alpha1.Cluster) bool {
 	remove :=
 		!c.GetDeletionTimestamp().IsZero() ||
 			c.GetGitTemplate().AccessToken.SecretRef == "" ||
 			!t.GetCompilePipelineSpec().Enabled ||
-			!c.GetEnableCompilePipeline()
+			!c.GetEnableCompilePipeline() ||
+			c.DecommissionRequested()
 
 	template := t.GetGitTemplate()
-	envVarName := fmt.Sprintf("%s%s", CI_VARIABLE_PREFIX_CLUSTER_ACCESS_TOKEN, strings.Replace(c.GetName(), "-", "_", -1))
+	envVarName := clusterCiVariableName(c)
 
 	var list []synv1alpha1.EnvVar
 	var changed bool
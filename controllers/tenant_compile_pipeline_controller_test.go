@@ -621,6 +621,147 @@ func Test_TenantCompilePipelineReconciler_DeleteClusterRemoveVariableStatusFinal
 	require.True(t, apierrors.IsNotFound(err))
 }
 
+func Test_TenantCompilePipelineReconciler_MovedClusterRemoveVariableStatus(t *testing.T) {
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			CompilePipeline: &synv1alpha1.CompilePipelineSpec{
+				Enabled: true,
+			},
+			GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+				CIVariables: []synv1alpha1.EnvVar{
+					{
+						Name:  "ACCESS_TOKEN_c_cluster1",
+						Value: "foo",
+					},
+					{
+						Name:  "CLUSTERS",
+						Value: "c-cluster1",
+					},
+				},
+			},
+		},
+		Status: synv1alpha1.TenantStatus{
+			CompilePipeline: &synv1alpha1.CompilePipelineStatus{
+				Clusters: []string{"c-cluster1"},
+			},
+		},
+	}
+	c1 := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster1",
+			Namespace: "lieutenant",
+			Labels: map[string]string{
+				synv1alpha1.LabelNameTenant: "t-other",
+			},
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			TenantRef:             corev1.LocalObjectReference{Name: "t-other"},
+			EnableCompilePipeline: true,
+		},
+		Status: synv1alpha1.ClusterStatus{
+			Tenant: "t-tenant",
+			TenantMigration: &synv1alpha1.TenantMigration{
+				From: "t-tenant",
+				To:   "t-other",
+			},
+		},
+	}
+
+	c := preparePipelineTestClient(t, tenant, c1)
+	r := tenantCompilePipelineReconciler(c)
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, requestFor(tenant))
+	require.NoError(t, err)
+
+	mod_tenant := &synv1alpha1.Tenant{}
+	err = c.Get(ctx, types.NamespacedName{Name: "t-tenant", Namespace: "lieutenant"}, mod_tenant)
+	require.NoError(t, err)
+
+	_, found := findEnvVar("ACCESS_TOKEN_c_cluster1", mod_tenant.GetGitTemplate().CIVariables)
+	assert.False(t, found)
+	v, found := findEnvVar("CLUSTERS", mod_tenant.GetGitTemplate().CIVariables)
+	require.True(t, found)
+	assert.Equal(t, "", v.Value)
+	assert.Empty(t, mod_tenant.GetCompilePipelineStatus().Clusters)
+}
+
+func Test_TenantCompilePipelineReconciler_DecommissionedClusterRemoveVariableStatus(t *testing.T) {
+	tenant := &synv1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "t-tenant",
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.TenantSpec{
+			CompilePipeline: &synv1alpha1.CompilePipelineSpec{
+				Enabled: true,
+			},
+			GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+				CIVariables: []synv1alpha1.EnvVar{
+					{
+						Name:  "ACCESS_TOKEN_c_cluster1",
+						Value: "foo",
+					},
+					{
+						Name:  "CLUSTERS",
+						Value: "c-cluster1",
+					},
+				},
+			},
+		},
+		Status: synv1alpha1.TenantStatus{
+			CompilePipeline: &synv1alpha1.CompilePipelineStatus{
+				Clusters: []string{"c-cluster1"},
+			},
+		},
+	}
+	c1 := &synv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "c-cluster1",
+			Namespace: "lieutenant",
+			Labels: map[string]string{
+				synv1alpha1.LabelNameTenant: "t-tenant",
+			},
+		},
+		Spec: synv1alpha1.ClusterSpec{
+			TenantRef:             corev1.LocalObjectReference{Name: "t-tenant"},
+			EnableCompilePipeline: true,
+			GitRepoTemplate: &synv1alpha1.GitRepoTemplate{
+				AccessToken: synv1alpha1.AccessToken{
+					SecretRef: "c-cluster1-api-token",
+				},
+			},
+		},
+		Status: synv1alpha1.ClusterStatus{
+			Decommission: &synv1alpha1.DecommissionStatus{
+				Stage: synv1alpha1.RevokeAccessStage,
+			},
+		},
+	}
+
+	c := preparePipelineTestClient(t, tenant, c1)
+	r := tenantCompilePipelineReconciler(c)
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, requestFor(tenant))
+	require.NoError(t, err)
+
+	mod_tenant := &synv1alpha1.Tenant{}
+	err = c.Get(ctx, types.NamespacedName{Name: "t-tenant", Namespace: "lieutenant"}, mod_tenant)
+	require.NoError(t, err)
+
+	_, found := findEnvVar("ACCESS_TOKEN_c_cluster1", mod_tenant.GetGitTemplate().CIVariables)
+	assert.False(t, found)
+	v, found := findEnvVar("CLUSTERS", mod_tenant.GetGitTemplate().CIVariables)
+	require.True(t, found)
+	assert.Equal(t, "", v.Value)
+	assert.Empty(t, mod_tenant.GetCompilePipelineStatus().Clusters)
+}
+
 func Test_TenantCompilePipelineReconciler_IgnoreUnmanagedRepoForCIVariableUpdate(t *testing.T) {
 	for _, tc := range []struct {
 		preExisting            bool
@@ -2,9 +2,12 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"slices"
 
 	"github.com/projectsyn/lieutenant-operator/controllers/gitrepo"
 	"github.com/projectsyn/lieutenant-operator/controllers/tenant"
+	"github.com/projectsyn/lieutenant-operator/controllers/tenant/watchers"
 	"github.com/projectsyn/lieutenant-operator/pipeline"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -30,6 +33,9 @@ type TenantReconciler struct {
 
 	DefaultGlobalGitRepoUrl string
 	DeleteProtection        bool
+	// DeleteConfirmation makes the default deletion protection require confirming the deletion by name
+	DeleteConfirmation bool
+	UseVault           bool
 }
 
 //+kubebuilder:rbac:groups=syn.tools,resources=tenants,verbs=get;list;watch;create;update;patch;delete
@@ -59,13 +65,15 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		Context:                 ctx,
 		Client:                  r.Client,
 		Log:                     reqLogger,
-		FinalizerName:           "",
+		FinalizerName:           synv1alpha1.FinalizerName,
 		Reconciler:              r,
 		CreateSATokenSecret:     r.CreateSATokenSecret,
 		DefaultCreationPolicy:   r.DefaultCreationPolicy,
 		DefaultDeletionPolicy:   r.DefaultDeletionPolicy,
 		DefaultGlobalGitRepoUrl: r.DefaultGlobalGitRepoUrl,
 		UseDeletionProtection:   r.DeleteProtection,
+		UseDeletionConfirmation: r.DeleteConfirmation,
+		UseVault:                r.UseVault,
 	}
 
 	steps := []pipeline.Step{
@@ -77,11 +85,21 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 	}
 	res := pipeline.RunPipeline(instance, data, steps)
 
-	return reconcile.Result{Requeue: res.Requeue}, res.Err
+	return reconcile.Result{Requeue: res.Requeue, RequeueAfter: res.RequeueAfter}, res.Err
 }
 
 // SetupWithManager sets up the controller with the Manager.
-func (r *TenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+func (r *TenantReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(
+		ctx,
+		&synv1alpha1.Tenant{},
+		watchers.TenantSharedSecretRefNameIndex,
+		watchers.TenantSharedSecretRefNameIndexFunc,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to create index for Tenant: %w", err)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&synv1alpha1.Tenant{}).
 		Owns(&synv1alpha1.GitRepo{}).
@@ -90,16 +108,23 @@ func (r *TenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&corev1.Secret{}).
 		Owns(&rbacv1.Role{}).
 		Owns(&rbacv1.RoleBinding{}).
-		// Reconcile all tenants when a TenantTemplate changes to ensure that all tenants are up to date
-		Watches(&synv1alpha1.TenantTemplate{}, handler.EnqueueRequestsFromMapFunc(enqueueAllTenantsMapFunc(mgr.GetClient()))).
+		// Reconcile the tenants using a TenantTemplate when it changes to ensure that they are up to date
+		Watches(&synv1alpha1.TenantTemplate{}, handler.EnqueueRequestsFromMapFunc(enqueueTenantsForTemplateMapFunc(mgr.GetClient()))).
+		// Reconcile the tenants referencing a Secret in their shared secrets when it changes
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(watchers.SecretTenantSharedSecretsMapFunc(mgr.GetClient()))).
 		Complete(r)
 }
 
-// enqueueAllTenantsMapFunc returns a function that lists all tenants in the namespace of the given object and returns a list of reconcile.Requests for all of them.
-func enqueueAllTenantsMapFunc(cli client.Client) func(ctx context.Context, o client.Object) []reconcile.Request {
+// enqueueTenantsForTemplateMapFunc returns a function that returns a list of reconcile.Requests for all tenants affected by a change of the given TenantTemplate.
+// These are the tenants listed in the template's status and the tenants currently selecting the template.
+func enqueueTenantsForTemplateMapFunc(cli client.Client) func(ctx context.Context, o client.Object) []reconcile.Request {
 	return func(ctx context.Context, o client.Object) []reconcile.Request {
-		l := log.FromContext(ctx).WithName("enqueueAllTenantsMapFunc")
-		l.Info("Enqueue all tenants")
+		l := log.FromContext(ctx).WithName("enqueueTenantsForTemplateMapFunc")
+
+		template, ok := o.(*synv1alpha1.TenantTemplate)
+		if !ok {
+			return []reconcile.Request{}
+		}
 
 		tenants := &synv1alpha1.TenantList{}
 		err := cli.List(ctx, tenants, client.InNamespace(o.GetNamespace()))
@@ -108,15 +133,19 @@ func enqueueAllTenantsMapFunc(cli client.Client) func(ctx context.Context, o cli
 			return []reconcile.Request{}
 		}
 
-		requests := make([]reconcile.Request, len(tenants.Items))
-		for i, tenant := range tenants.Items {
-			requests[i] = reconcile.Request{
+		requests := make([]reconcile.Request, 0, len(template.Status.Tenants))
+		for _, t := range tenants.Items {
+			if !slices.Contains(template.Status.Tenants, t.Name) && !tenant.SelectsTemplate(&t, template) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
 				NamespacedName: client.ObjectKey{
-					Namespace: tenant.Namespace,
-					Name:      tenant.Name,
+					Namespace: t.Namespace,
+					Name:      t.Name,
 				},
-			}
+			})
 		}
+		l.Info("Enqueue tenants using template", "template", template.Name, "count", len(requests))
 		return requests
 	}
 }
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+// TenantTemplateReconciler reconciles a TenantTemplate object, updating its status with the tenants it was applied to.
+type TenantTemplateReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=syn.tools,resources=tenanttemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=syn.tools,resources=tenanttemplates/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=syn.tools,resources=tenants,verbs=get;list;watch
+
+func (r *TenantTemplateReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx)
+	reqLogger.Info("Reconciling TenantTemplate")
+
+	template := &synv1alpha1.TenantTemplate{}
+	if err := r.Client.Get(ctx, request.NamespacedName, template); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	tenants := &synv1alpha1.TenantList{}
+	if err := r.Client.List(ctx, tenants, client.InNamespace(template.Namespace)); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error listing tenants: %w", err)
+	}
+
+	names := make([]string, 0, len(tenants.Items))
+	for _, t := range tenants.Items {
+		if slices.Contains(t.GetAppliedTemplates(), template.Name) {
+			names = append(names, t.Name)
+		}
+	}
+	slices.Sort(names)
+
+	if slices.Equal(template.Status.Tenants, names) {
+		return reconcile.Result{}, nil
+	}
+	template.Status.Tenants = names
+	if err := r.Client.Status().Update(ctx, template); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error updating tenant template status: %w", err)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TenantTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&synv1alpha1.TenantTemplate{}).
+		Watches(&synv1alpha1.Tenant{}, AppliedTenantTemplatesEventHandler()).
+		Complete(r)
+}
+
+// AppliedTenantTemplatesEventHandler returns an event handler which enqueues the tenant templates applied to a tenant.
+// On updates, the templates in the old and the new applied templates annotation are enqueued, but only if the annotation changed.
+func AppliedTenantTemplatesEventHandler() handler.Funcs {
+	return handler.Funcs{
+		CreateFunc: func(_ context.Context, e event.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueueAppliedTenantTemplates(q, e.Object)
+		},
+		UpdateFunc: func(_ context.Context, e event.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			if e.ObjectOld.GetAnnotations()[synv1alpha1.TenantTemplateAnnotation] == e.ObjectNew.GetAnnotations()[synv1alpha1.TenantTemplateAnnotation] {
+				return
+			}
+			enqueueAppliedTenantTemplates(q, e.ObjectOld, e.ObjectNew)
+		},
+		DeleteFunc: func(_ context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueueAppliedTenantTemplates(q, e.Object)
+		},
+		GenericFunc: func(_ context.Context, e event.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+			enqueueAppliedTenantTemplates(q, e.Object)
+		},
+	}
+}
+
+func enqueueAppliedTenantTemplates(q workqueue.TypedRateLimitingInterface[reconcile.Request], objs ...client.Object) {
+	for _, o := range objs {
+		tenant, ok := o.(*synv1alpha1.Tenant)
+		if !ok {
+			continue
+		}
+		for _, name := range tenant.GetAppliedTemplates() {
+			q.Add(reconcile.Request{
+				NamespacedName: client.ObjectKey{
+					Namespace: tenant.Namespace,
+					Name:      name,
+				},
+			})
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package controllers_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/controllers"
+)
+
+func Test_TenantTemplateReconciler_Status(t *testing.T) {
+	template := &synv1alpha1.TenantTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "managed",
+			Namespace: "lieutenant",
+		},
+		Status: synv1alpha1.TenantTemplateStatus{
+			Tenants: []string{"t-stale"},
+		},
+	}
+	tenantWithTemplate := func(name, templates string) *synv1alpha1.Tenant {
+		return &synv1alpha1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "lieutenant",
+				Annotations: map[string]string{
+					synv1alpha1.TenantTemplateAnnotation: templates,
+				},
+			},
+		}
+	}
+
+	c := preparePipelineTestClient(t,
+		template,
+		tenantWithTemplate("t-b", "managed,base"),
+		tenantWithTemplate("t-a", "managed"),
+		tenantWithTemplate("t-c", "base"),
+	)
+	r := &controllers.TenantTemplateReconciler{
+		Client: c,
+		Scheme: c.Scheme(),
+	}
+	ctx := context.Background()
+
+	_, err := r.Reconcile(ctx, requestFor(template))
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(template), template))
+	assert.Equal(t, []string{"t-a", "t-b"}, template.Status.Tenants)
+}
+
+func Test_AppliedTenantTemplatesEventHandler(t *testing.T) {
+	tenantWithTemplates := func(templates string, displayName string) *synv1alpha1.Tenant {
+		return &synv1alpha1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "t-tenant",
+				Namespace: "lieutenant",
+				Annotations: map[string]string{
+					synv1alpha1.TenantTemplateAnnotation: templates,
+				},
+			},
+			Spec: synv1alpha1.TenantSpec{
+				DisplayName: displayName,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		send func(ctx context.Context, h handler.EventHandler, q workqueue.TypedRateLimitingInterface[reconcile.Request])
+
+		want []string
+	}{
+		"create": {
+			send: func(ctx context.Context, h handler.EventHandler, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				h.Create(ctx, event.CreateEvent{Object: tenantWithTemplates("managed,base", "")}, q)
+			},
+			want: []string{"base", "managed"},
+		},
+		"update of applied templates": {
+			send: func(ctx context.Context, h handler.EventHandler, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				h.Update(ctx, event.UpdateEvent{
+					ObjectOld: tenantWithTemplates("managed,base", ""),
+					ObjectNew: tenantWithTemplates("other", ""),
+				}, q)
+			},
+			want: []string{"base", "managed", "other"},
+		},
+		"other update": {
+			send: func(ctx context.Context, h handler.EventHandler, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				h.Update(ctx, event.UpdateEvent{
+					ObjectOld: tenantWithTemplates("managed,base", ""),
+					ObjectNew: tenantWithTemplates("managed,base", "Tenant"),
+				}, q)
+			},
+			want: []string{},
+		},
+		"delete": {
+			send: func(ctx context.Context, h handler.EventHandler, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+				h.Delete(ctx, event.DeleteEvent{Object: tenantWithTemplates("managed", "")}, q)
+			},
+			want: []string{"managed"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			q := workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+			defer q.ShutDown()
+
+			tc.send(context.Background(), controllers.AppliedTenantTemplatesEventHandler(), q)
+
+			got := []string{}
+			for q.Len() > 0 {
+				req, _ := q.Get()
+				assert.Equal(t, "lieutenant", req.Namespace)
+				got = append(got, req.Name)
+				q.Done(req)
+			}
+			slices.Sort(got)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
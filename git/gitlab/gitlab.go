@@ -469,7 +469,8 @@ func (g *Gitlab) EnsureProjectAccessToken(ctx context.Context, name string, opts
 		if token.ExpiresAt == nil {
 			continue
 		}
-		if time.Time(*token.ExpiresAt).Before(g.ops.Now().Add(-10 * 24 * time.Hour)) {
+		// Tokens in their renewal window are replaced by a new token
+		if time.Time(*token.ExpiresAt).Before(g.ops.Now().Add(manager.ProjectAccessTokenRenewalWindow)) {
 			continue
 		}
 		validATs = append(validATs, *token)
@@ -528,6 +529,49 @@ func (g *Gitlab) EnsureProjectAccessToken(ctx context.Context, name string, opts
 	}, nil
 }
 
+// RevokeProjectAccessTokens revokes all active project access tokens with the given name.
+func (g *Gitlab) RevokeProjectAccessTokens(ctx context.Context, name string) error {
+	at, _, err := g.client.ProjectAccessTokens.ListProjectAccessTokens(g.project.ID, &gitlab.ListProjectAccessTokensOptions{}, gitlab.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	for _, token := range at {
+		if token == nil || !token.Active || token.Revoked || token.Name != name {
+			continue
+		}
+		g.log.Info("revoking project access token", "id", token.ID, "name", token.Name)
+		if _, err := g.client.ProjectAccessTokens.RevokeProjectAccessToken(g.project.ID, token.ID, gitlab.WithContext(ctx)); err != nil {
+			return fmt.Errorf("error response from gitlab when revoking ProjectAccessToken %d: %w", token.ID, err)
+		}
+	}
+	return nil
+}
+
+// HeadCommit returns the SHA of the latest commit on the default branch.
+// It returns an empty string if the repository has no commits.
+func (g *Gitlab) HeadCommit(ctx context.Context) (string, error) {
+	if g.project.DefaultBranch == "" {
+		return "", nil
+	}
+	branch, _, err := g.client.Branches.GetBranch(g.project.ID, g.project.DefaultBranch, gitlab.WithContext(ctx))
+	if err != nil {
+		if errors.Is(err, gitlab.ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	if branch.Commit == nil {
+		return "", nil
+	}
+	return branch.Commit.ID, nil
+}
+
+// CheckCredentials verifies that the GitLab API accepts the token by looking up the user of the token.
+func (g *Gitlab) CheckCredentials(ctx context.Context) error {
+	_, _, err := g.client.Users.CurrentUser(gitlab.WithContext(ctx))
+	return err
+}
+
 // EnsureCIVariables ensures that the given variables are set in the CI/CD pipeline.
 // The managedVariables is used to identify the variables that are managed by the operator.
 // Variables that are not managed by the operator will be ignored.
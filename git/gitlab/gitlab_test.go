@@ -839,6 +839,13 @@ func TestGitlab_EnsureProjectAccessToken(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, newerPat.UID, newerPat2.UID, "Should return the newest created token")
 
+	clock.Advance(time.Hour * 24 * 21)
+
+	windowPat, err := g.EnsureProjectAccessToken(context.Background(), "test", manager.EnsureProjectAccessTokenOptions{UID: &newerPat.UID})
+	require.NoError(t, err)
+	assert.NotEmpty(t, windowPat.Token, "Should return new token if old token is in its renewal window")
+	assert.NotEqual(t, newerPat.UID, windowPat.UID, "Should return new token if old token is in its renewal window")
+
 	clock.Advance(time.Hour * 24 * 90)
 
 	renewedPat, err := g.EnsureProjectAccessToken(context.Background(), "test", manager.EnsureProjectAccessTokenOptions{UID: &pat.UID})
@@ -847,6 +854,106 @@ func TestGitlab_EnsureProjectAccessToken(t *testing.T) {
 	assert.NotEqual(t, pat.UID, renewedPat.UID, "Should return new token if old token is expired")
 }
 
+func TestGitlab_RevokeProjectAccessTokens(t *testing.T) {
+	clock := &mockClock{now: time.Now()}
+
+	serv := testProjectAccessTokenServer(t, clock.Now)
+	defer serv.Close()
+
+	url, err := url.Parse(serv.URL)
+	require.NoError(t, err)
+
+	g := &Gitlab{
+		project: &gitlab.Project{
+			ID: 3,
+		},
+		ops: manager.RepoOptions{
+			URL:   url,
+			Clock: clock,
+		},
+	}
+
+	require.NoError(t, g.Connect())
+
+	_, err = g.EnsureProjectAccessToken(context.Background(), "test", manager.EnsureProjectAccessTokenOptions{})
+	require.NoError(t, err)
+	_, err = g.EnsureProjectAccessToken(context.Background(), "other", manager.EnsureProjectAccessTokenOptions{UID: ptr.To("other")})
+	require.NoError(t, err)
+
+	require.NoError(t, g.RevokeProjectAccessTokens(context.Background(), "test"))
+
+	pats, _, err := g.client.ProjectAccessTokens.ListProjectAccessTokens(g.project.ID, &gitlab.ListProjectAccessTokensOptions{})
+	require.NoError(t, err)
+	require.Len(t, pats, 2)
+	for _, pat := range pats {
+		assert.Equal(t, pat.Name == "test", pat.Revoked, "should only revoke tokens with the given name")
+	}
+}
+
+func TestGitlab_HeadCommit(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/projects/3/repository/branches/master", func(res http.ResponseWriter, req *http.Request) {
+		_, _ = res.Write([]byte(`{"name":"master","commit":{"id":"7b5c3cc8be40ee161ae89a06bba6229da1032a0c"}}`))
+	})
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	serv := httptest.NewServer(mux)
+	defer serv.Close()
+
+	url, err := url.Parse(serv.URL)
+	require.NoError(t, err)
+
+	g := &Gitlab{
+		project: &gitlab.Project{
+			ID:            3,
+			DefaultBranch: "master",
+		},
+		ops: manager.RepoOptions{
+			URL: url,
+		},
+	}
+	require.NoError(t, g.Connect())
+
+	sha, err := g.HeadCommit(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "7b5c3cc8be40ee161ae89a06bba6229da1032a0c", sha)
+
+	g.project.DefaultBranch = ""
+	sha, err = g.HeadCommit(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, sha, "should return no commit for an empty repository")
+}
+
+func TestGitlab_CheckCredentials(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v4/user", func(res http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("PRIVATE-TOKEN") != "valid" {
+			res.WriteHeader(http.StatusUnauthorized)
+			_, _ = res.Write([]byte(`{"message":"401 Unauthorized"}`))
+			return
+		}
+		_, _ = res.Write([]byte(`{"id":1,"username":"lieutenant"}`))
+	})
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	serv := httptest.NewServer(mux)
+	defer serv.Close()
+
+	url, err := url.Parse(serv.URL)
+	require.NoError(t, err)
+
+	g := &Gitlab{
+		credentials: manager.Credentials{Token: "valid"},
+		ops: manager.RepoOptions{
+			URL: url,
+		},
+	}
+	require.NoError(t, g.Connect())
+	assert.NoError(t, g.CheckCredentials(context.Background()))
+
+	g.credentials.Token = "expired"
+	require.NoError(t, g.Connect())
+	assert.Error(t, g.CheckCredentials(context.Background()))
+}
+
 func TestGitlab_EnsureCIVariables(t *testing.T) {
 	clock := &mockClock{now: time.Now()}
 
@@ -968,6 +1075,20 @@ func testProjectAccessTokenServer(t *testing.T, clock func() time.Time) *httptes
 		_ = json.NewEncoder(res).Encode(nPat)
 	})
 
+	mux.HandleFunc("DELETE /api/v4/projects/3/access_tokens/{id}", func(res http.ResponseWriter, req *http.Request) {
+		patsMux.Lock()
+		defer patsMux.Unlock()
+		for i := range pats {
+			if strconv.FormatInt(pats[i].ID, 10) == req.PathValue("id") {
+				pats[i].Active = false
+				pats[i].Revoked = true
+				res.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+		res.WriteHeader(http.StatusNotFound)
+	})
+
 	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
 
 	return httptest.NewServer(mux)
@@ -126,6 +126,14 @@ type Repo interface {
 	// Variables that are not managed by the operator will be ignored.
 	// Variables that are managed but not in variables will be deleted.
 	EnsureCIVariables(ctx context.Context, managedVariables []string, variables []EnvVar) error
+	// RevokeProjectAccessTokens revokes all active project access tokens with the given name.
+	RevokeProjectAccessTokens(ctx context.Context, name string) error
+	// HeadCommit returns the SHA of the latest commit on the default branch.
+	// It returns an empty string if the repository has no commits.
+	HeadCommit(ctx context.Context) (string, error)
+	// CheckCredentials verifies that the API is reachable and accepts the credentials of the repository.
+	// It doesn't require the repository to exist.
+	CheckCredentials(ctx context.Context) error
 }
 
 // EnvVar represents a CI/CD environment variable.
@@ -152,6 +160,9 @@ type EnsureProjectAccessTokenOptions struct {
 	UID *string
 }
 
+// ProjectAccessTokenRenewalWindow is the time before the expiry of a project access token in which a new token is issued.
+const ProjectAccessTokenRenewalWindow = 10 * 24 * time.Hour
+
 type ProjectAccessToken struct {
 	UID       string
 	Token     string
@@ -163,6 +174,11 @@ func (p ProjectAccessToken) Updated() bool {
 	return p.Token != ""
 }
 
+// RenewAt returns the time at which the token enters its renewal window.
+func (p ProjectAccessToken) RenewAt() time.Time {
+	return p.ExpiresAt.Add(-ProjectAccessTokenRenewalWindow)
+}
+
 // Implementation is a set of functions needed to get the right git implementation
 // for the given URL.
 type Implementation interface {
@@ -185,16 +201,7 @@ type CommitFile struct {
 // reconcile function, this is the way to go.
 func GetGitClient(ctx context.Context, instance *synv1alpha1.GitRepo, reqLogger logr.Logger, client client.Client) (Repo, string, error) {
 	secret := &corev1.Secret{}
-	namespacedName := types.NamespacedName{
-		Name:      instance.Spec.APISecretRef.Name,
-		Namespace: instance.Namespace,
-	}
-
-	if len(instance.Spec.APISecretRef.Namespace) > 0 {
-		namespacedName.Namespace = instance.Spec.APISecretRef.Namespace
-	}
-
-	err := client.Get(ctx, namespacedName, secret)
+	err := client.Get(ctx, APISecretKey(instance), secret)
 	if err != nil {
 		return nil, "", fmt.Errorf("error getting git secret: %v", err)
 	}
@@ -259,6 +266,48 @@ func GetGitClient(ctx context.Context, instance *synv1alpha1.GitRepo, reqLogger
 	return repo, hostKeysString, err
 }
 
+// APISecretKey returns the key of the secret containing the connection information of the git host of the repository.
+// The secret is in the namespace of the repository, unless the reference specifies a namespace.
+func APISecretKey(instance *synv1alpha1.GitRepo) types.NamespacedName {
+	key := types.NamespacedName{
+		Name:      instance.Spec.APISecretRef.Name,
+		Namespace: instance.Namespace,
+	}
+	if len(instance.Spec.APISecretRef.Namespace) > 0 {
+		key.Namespace = instance.Spec.APISecretRef.Namespace
+	}
+	return key
+}
+
+// CheckAPISecret verifies that the git host of the secret is reachable and accepts the token of the secret.
+func CheckAPISecret(ctx context.Context, secret *corev1.Secret, reqLogger logr.Logger) error {
+	endpoint, ok := secret.Data[SecretEndpointName]
+	if !ok {
+		return fmt.Errorf("secret %s does not contain endpoint data", secret.GetName())
+	}
+	token, ok := secret.Data[SecretTokenName]
+	if !ok {
+		return fmt.Errorf("secret %s does not contain token", secret.GetName())
+	}
+	endpointURL, err := url.Parse(string(endpoint))
+	if err != nil {
+		return err
+	}
+
+	repo, err := NewRepo(RepoOptions{
+		Credentials: Credentials{Token: string(token)},
+		Logger:      reqLogger,
+		URL:         endpointURL,
+	})
+	if err != nil {
+		return err
+	}
+	if err := repo.Connect(); err != nil {
+		return err
+	}
+	return repo.CheckCredentials(ctx)
+}
+
 func parseSSHEndpoint(raw string) (string, error) {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -0,0 +1,261 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/git/manager"
+)
+
+//+kubebuilder:rbac:groups=syn.tools,resources=gitrepos,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Strictness selects which failing checks mark the operator as not ready
+type Strictness string
+
+const (
+	// Strict marks the operator as not ready if any check fails
+	Strict Strictness = "strict"
+	// Lenient marks the operator as not ready if the Vault check fails or if the checks of all git hosts fail.
+	// A single broken git host doesn't affect the readiness.
+	Lenient Strictness = "lenient"
+	// ReportOnly never marks the operator as not ready. The results are only exported as metrics.
+	ReportOnly Strictness = "report-only"
+)
+
+// gitHostCheckTimeout limits the time of the check of a single git host
+const gitHostCheckTimeout = 10 * time.Second
+
+var vaultCheckDesc = prometheus.NewDesc(
+	"syn_lieutenant_vault_check_success",
+	"Whether the last check of the connection to Vault succeeded. Only exported if Vault is used.",
+	nil,
+	nil,
+)
+
+var gitHostCheckDesc = prometheus.NewDesc(
+	"syn_lieutenant_git_host_check_success",
+	"Whether the last check of a git host API secret referenced by GitRepos succeeded.",
+	[]string{"secret", "endpoint"},
+	nil,
+)
+
+var checkTimestampDesc = prometheus.NewDesc(
+	"syn_lieutenant_readiness_check_timestamp_seconds",
+	"The time of the last readiness check as a unix timestamp.",
+	nil,
+	nil,
+)
+
+// Checker periodically verifies the connections to Vault and to the git hosts referenced by GitRepos.
+// The results are served as readiness checks and exported as Prometheus metrics.
+// The checks run in the background, so probing the readiness doesn't put load on the external services.
+type Checker struct {
+	Client client.Client
+
+	Namespace string
+
+	// Strictness selects which failing checks mark the operator as not ready
+	Strictness Strictness
+	// Interval is the time between two checks
+	Interval time.Duration
+
+	// CheckVault verifies the connection to Vault. Vault isn't checked if it's nil.
+	CheckVault func() error
+	// CheckGitHost verifies an API secret of a git host. Git hosts aren't checked if it's nil.
+	CheckGitHost func(ctx context.Context, secret *corev1.Secret) error
+
+	mu        sync.RWMutex
+	checkedAt time.Time
+	vault     error
+	gitHosts  map[types.NamespacedName]gitHostResult
+}
+
+type gitHostResult struct {
+	endpoint string
+	err      error
+}
+
+var _ prometheus.Collector = &Checker{}
+
+// Start implements manager.Runnable.
+// It checks the connections until the context is cancelled.
+func (c *Checker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+// Every replica checks its connections, since every replica reports its readiness.
+func (c *Checker) NeedLeaderElection() bool {
+	return false
+}
+
+// Check checks the connections once and stores the results
+func (c *Checker) Check(ctx context.Context) {
+	l := log.FromContext(ctx).WithName("readiness")
+
+	var vaultErr error
+	if c.CheckVault != nil {
+		vaultErr = c.CheckVault()
+		if vaultErr != nil {
+			l.Error(vaultErr, "vault check failed")
+		}
+	}
+
+	gitHosts, err := c.checkGitHosts(ctx)
+	if err != nil {
+		l.Error(err, "git host checks failed")
+	}
+	for key, res := range gitHosts {
+		if res.err != nil {
+			l.Error(res.err, "git host check failed", "secret", key.String(), "endpoint", res.endpoint)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkedAt = time.Now()
+	c.vault = vaultErr
+	if err == nil {
+		c.gitHosts = gitHosts
+	}
+}
+
+// checkGitHosts checks each API secret referenced by a managed GitRepo
+func (c *Checker) checkGitHosts(ctx context.Context) (map[types.NamespacedName]gitHostResult, error) {
+	results := map[types.NamespacedName]gitHostResult{}
+	if c.CheckGitHost == nil {
+		return results, nil
+	}
+
+	repos := synv1alpha1.GitRepoList{}
+	if err := c.Client.List(ctx, &repos, client.InNamespace(c.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list git repos: %w", err)
+	}
+
+	for _, repo := range repos.Items {
+		if repo.Spec.RepoType == synv1alpha1.UnmanagedRepoType || repo.Spec.APISecretRef.Name == "" {
+			continue
+		}
+		key := manager.APISecretKey(&repo)
+		if _, ok := results[key]; ok {
+			continue
+		}
+
+		secret := &corev1.Secret{}
+		if err := c.Client.Get(ctx, key, secret); err != nil {
+			results[key] = gitHostResult{err: fmt.Errorf("error getting git secret: %w", err)}
+			continue
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, gitHostCheckTimeout)
+		err := c.CheckGitHost(checkCtx, secret)
+		cancel()
+		results[key] = gitHostResult{endpoint: string(secret.Data[manager.SecretEndpointName]), err: err}
+	}
+	return results, nil
+}
+
+// VaultCheck is a readiness check reporting the result of the last Vault check
+func (c *Checker) VaultCheck(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.checkedAt.IsZero() {
+		return c.unready(errors.New("not checked yet"))
+	}
+	if c.vault != nil {
+		return c.unready(c.vault)
+	}
+	return nil
+}
+
+// GitHostsCheck is a readiness check reporting the results of the last git host checks.
+// The error lists the failing API secrets.
+func (c *Checker) GitHostsCheck(_ *http.Request) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.checkedAt.IsZero() {
+		return c.unready(errors.New("not checked yet"))
+	}
+
+	failed := []string{}
+	for key, res := range c.gitHosts {
+		if res.err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s): %s", key, res.endpoint, res.err))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	err := fmt.Errorf("%d of %d git hosts failed: %s", len(failed), len(c.gitHosts), strings.Join(failed, "; "))
+	if c.Strictness != Strict && len(failed) < len(c.gitHosts) {
+		return nil
+	}
+	return c.unready(err)
+}
+
+// unready returns the error, unless failing checks don't affect the readiness
+func (c *Checker) unready(err error) error {
+	if c.Strictness == ReportOnly {
+		return nil
+	}
+	return err
+}
+
+// Describe implements prometheus.Collector.
+// Sends the descriptors of the metrics to the channel.
+func (c *Checker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- vaultCheckDesc
+	ch <- gitHostCheckDesc
+	ch <- checkTimestampDesc
+}
+
+// Collect implements prometheus.Collector.
+// Sends the results of the last checks.
+func (c *Checker) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.checkedAt.IsZero() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(checkTimestampDesc, prometheus.GaugeValue, float64(c.checkedAt.Unix()))
+	if c.CheckVault != nil {
+		ch <- prometheus.MustNewConstMetric(vaultCheckDesc, prometheus.GaugeValue, success(c.vault))
+	}
+	for key, res := range c.gitHosts {
+		ch <- prometheus.MustNewConstMetric(gitHostCheckDesc, prometheus.GaugeValue, success(res.err), key.String(), res.endpoint)
+	}
+}
+
+func success(err error) float64 {
+	if err != nil {
+		return 0
+	}
+	return 1
+}
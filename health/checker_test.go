@@ -0,0 +1,156 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/health"
+)
+
+func Test_Checker(t *testing.T) {
+	c := prepareClient(t,
+		gitRepo("c-one", "gitlab-ok", synv1alpha1.AutoRepoType),
+		gitRepo("c-two", "gitlab-ok", synv1alpha1.AutoRepoType),
+		gitRepo("c-three", "gitlab-broken", synv1alpha1.AutoRepoType),
+		gitRepo("c-unmanaged", "gitlab-missing", synv1alpha1.UnmanagedRepoType),
+		apiSecret("gitlab-ok", "https://ok.example.com"),
+		apiSecret("gitlab-broken", "https://broken.example.com"),
+	)
+
+	checked := []string{}
+	subject := &health.Checker{
+		Client:     c,
+		Namespace:  "lieutenant",
+		Strictness: health.Lenient,
+		CheckVault: func() error { return nil },
+		CheckGitHost: func(ctx context.Context, secret *corev1.Secret) error {
+			checked = append(checked, secret.Name)
+			if secret.Name == "gitlab-broken" {
+				return errors.New("401 Unauthorized")
+			}
+			return nil
+		},
+	}
+
+	assert.ErrorContains(t, subject.VaultCheck(nil), "not checked yet")
+	assert.ErrorContains(t, subject.GitHostsCheck(nil), "not checked yet")
+
+	subject.Check(context.Background())
+	assert.ElementsMatch(t, []string{"gitlab-ok", "gitlab-broken"}, checked, "should check each secret once")
+	assert.NoError(t, subject.VaultCheck(nil))
+	assert.NoError(t, subject.GitHostsCheck(nil), "a single broken host shouldn't affect the readiness")
+
+	subject.Strictness = health.Strict
+	assert.ErrorContains(t, subject.GitHostsCheck(nil), "1 of 2 git hosts failed: lieutenant/gitlab-broken (https://broken.example.com): 401 Unauthorized")
+
+	expected := `
+# HELP syn_lieutenant_git_host_check_success Whether the last check of a git host API secret referenced by GitRepos succeeded.
+# TYPE syn_lieutenant_git_host_check_success gauge
+syn_lieutenant_git_host_check_success{endpoint="https://broken.example.com",secret="lieutenant/gitlab-broken"} 0
+syn_lieutenant_git_host_check_success{endpoint="https://ok.example.com",secret="lieutenant/gitlab-ok"} 1
+# HELP syn_lieutenant_vault_check_success Whether the last check of the connection to Vault succeeded. Only exported if Vault is used.
+# TYPE syn_lieutenant_vault_check_success gauge
+syn_lieutenant_vault_check_success 1
+`
+	require.NoError(t, testutil.CollectAndCompare(subject, strings.NewReader(expected),
+		"syn_lieutenant_git_host_check_success", "syn_lieutenant_vault_check_success"))
+}
+
+func Test_Checker_strictness(t *testing.T) {
+	tests := map[string]struct {
+		strictness health.Strictness
+		vaultErr   error
+		hostErr    error
+		wantVault  bool
+		wantHosts  bool
+	}{
+		"lenient, all hosts broken": {
+			strictness: health.Lenient,
+			hostErr:    errors.New("connection refused"),
+			wantHosts:  true,
+		},
+		"lenient, vault sealed": {
+			strictness: health.Lenient,
+			vaultErr:   errors.New("vault is sealed"),
+			wantVault:  true,
+		},
+		"report-only": {
+			strictness: health.ReportOnly,
+			vaultErr:   errors.New("vault is sealed"),
+			hostErr:    errors.New("connection refused"),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			subject := &health.Checker{
+				Client: prepareClient(t,
+					gitRepo("c-one", "gitlab", synv1alpha1.AutoRepoType),
+					apiSecret("gitlab", "https://gitlab.example.com"),
+				),
+				Namespace:  "lieutenant",
+				Strictness: tc.strictness,
+				CheckVault: func() error { return tc.vaultErr },
+				CheckGitHost: func(context.Context, *corev1.Secret) error {
+					return tc.hostErr
+				},
+			}
+			subject.Check(context.Background())
+
+			assert.Equal(t, tc.wantVault, subject.VaultCheck(nil) != nil)
+			assert.Equal(t, tc.wantHosts, subject.GitHostsCheck(nil) != nil)
+		})
+	}
+}
+
+func gitRepo(name, secret string, repoType synv1alpha1.RepoType) *synv1alpha1.GitRepo {
+	return &synv1alpha1.GitRepo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "lieutenant",
+		},
+		Spec: synv1alpha1.GitRepoSpec{
+			GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+				RepoType:     repoType,
+				APISecretRef: corev1.SecretReference{Name: secret},
+			},
+		},
+	}
+}
+
+func apiSecret(name, endpoint string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "lieutenant",
+		},
+		Data: map[string][]byte{
+			"endpoint": []byte(endpoint),
+			"token":    []byte("token"),
+		},
+	}
+}
+
+func prepareClient(t *testing.T, initObjs ...client.Object) client.Client {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, synv1alpha1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(initObjs...).
+		Build()
+}
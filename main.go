@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -11,6 +13,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -25,7 +28,10 @@ import (
 	"github.com/kouhin/envflag"
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/projectsyn/lieutenant-operator/controllers"
+	"github.com/projectsyn/lieutenant-operator/git/manager"
+	"github.com/projectsyn/lieutenant-operator/health"
 	operatorMetrics "github.com/projectsyn/lieutenant-operator/metrics"
+	"github.com/projectsyn/lieutenant-operator/vault"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -51,12 +57,36 @@ func main() {
 	var gitRepoMaxReconcileInterval time.Duration
 
 	var skipVaultSetup bool
+	var secretStore string
+	var vaultPathTemplate string
+	var vaultKeyName string
+	var operatorUID string
+	var vaultSharedPathTemplate string
+	var vaultClusterAccess bool
+	var vaultClusterAuthPath string
+	var vaultClusterServiceAccount string
+	var vaultAuthMethod string
+	var vaultAuthPath string
+	var vaultAuthRole string
+	var vaultJWTFile string
+	var vaultRoleID string
+	var vaultSecretIDFile string
+	var vaultClientCert string
+	var vaultClientKey string
 	var defaultDeletionPolicy string
 	var defaultCreationPolicy string
 	var useDeleteProtection bool
+	var useDeleteConfirmation bool
+	var deletionGracePeriod time.Duration
 	var defGlobalGitRepoUrl string
 	var watchNamespace string
 	var createSaTokenSecret bool
+	var factLabels string
+	var ephemeralDeletionPolicy string
+	var expiryWarningPeriod time.Duration
+	var serviceAccountTokenLifetime time.Duration
+	var readinessCheckInterval time.Duration
+	var readinessStrictness string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.StringVar(&apiUrl, "lieutenant-api-url", "localhost",
@@ -68,12 +98,36 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.BoolVar(&skipVaultSetup, "skip-vault-setup", false, "Set to `true` in order to skip vault setup.")
+	flag.StringVar(&secretStore, "secret-store", string(vault.KVv2Store), "The store for the secrets of clusters. Can be `kv-v2`, `kv-v1` or `kubernetes`.")
+	flag.StringVar(&vaultPathTemplate, "vault-path-template", vault.DefaultPathTemplate, "Go template of the path of the Steward token of a cluster in Vault. The fields `.Tenant` and `.Cluster` are available.")
+	flag.StringVar(&vaultKeyName, "vault-key-name", "token", "The key of the Steward token within its Vault secret.")
+	flag.StringVar(&operatorUID, "operator-uid", "", "Identifies the operator instance in the custom metadata of the Vault secrets.")
+	flag.StringVar(&vaultSharedPathTemplate, "vault-shared-path-template", vault.DefaultSharedPathTemplate, "Go template of the path of the Vault secrets shared by all clusters of a tenant. The field `.Tenant` is available.")
+	flag.BoolVar(&vaultClusterAccess, "vault-cluster-access", false, "Whether to provision a Vault policy and a Kubernetes auth role per cluster.")
+	flag.StringVar(&vaultClusterAuthPath, "vault-cluster-auth-path", vault.DefaultClusterAuthPathTemplate, "Go template of the mount path of the Kubernetes auth method the role of a cluster is created on. It must render a different path for each cluster. The fields `.Tenant` and `.Cluster` are available.")
+	flag.StringVar(&vaultClusterServiceAccount, "vault-cluster-service-account", "syn/steward", "The service account bound to the Vault role of a cluster, in the form `namespace/name`.")
+	flag.StringVar(&vaultAuthMethod, "vault-auth-method", "", "The method the operator authenticates against Vault with. Can be `token`, `kubernetes`, `jwt`, `approle` or `cert`. Detected automatically if empty.")
+	flag.StringVar(&vaultAuthPath, "vault-auth-path", "", "The mount path of the Vault auth method, without the `auth/` prefix. Defaults to the name of the method.")
+	flag.StringVar(&vaultAuthRole, "vault-auth-role", vault.DefaultAuthRole, "The Vault role the operator logs in with.")
+	flag.StringVar(&vaultJWTFile, "vault-jwt-file", "", "The file containing the JWT for the `kubernetes` and `jwt` auth methods. Defaults to the token of the service account.")
+	flag.StringVar(&vaultRoleID, "vault-approle-role-id", "", "The role ID for the `approle` auth method.")
+	flag.StringVar(&vaultSecretIDFile, "vault-approle-secret-id-file", "", "The file containing the secret ID for the `approle` auth method.")
+	flag.StringVar(&vaultClientCert, "vault-client-cert", "", "The file of the TLS client certificate for the `cert` auth method.")
+	flag.StringVar(&vaultClientKey, "vault-client-key", "", "The file of the key of the TLS client certificate for the `cert` auth method.")
 	flag.StringVar(&defaultDeletionPolicy, "default-deletion-policy", "Archive", "Default deletion policy for git repos. Can be `Delete`, `Retain` or `Archive`.")
 	flag.StringVar(&defaultCreationPolicy, "default-creation-policy", "Create", "Default creation policy for git repos. Can be `Create` or `Adopt`.")
 	flag.BoolVar(&useDeleteProtection, "lieutenant-delete-protection", false, "Whether to enable deletion protection.")
+	flag.BoolVar(&useDeleteConfirmation, "lieutenant-delete-confirmation", false, "Whether the deletion protection requires confirming the deletion by name.")
+	flag.DurationVar(&deletionGracePeriod, "deletion-grace-period", 0, "The time after the deletion of a Cluster or GitRepo before its external resources are touched. During this period the deletion can be undone.")
 	flag.StringVar(&defGlobalGitRepoUrl, "default-global-git-repo-url", "", "Default URL for global git repo; used if global git repo isn't explicitly configured.")
 	flag.StringVar(&watchNamespace, "watch-namespace", "default", "The namespace which should be watched by the operator")
 	flag.BoolVar(&createSaTokenSecret, "lieutenant-create-serviceaccount-token-secret", false, "Whether Lieutenant should create ServiceAccount token secrets")
+	flag.StringVar(&factLabels, "fact-labels", "", "Comma separated list of fact keys which are mirrored into the labels of clusters.")
+	flag.StringVar(&ephemeralDeletionPolicy, "ephemeral-deletion-policy", "Delete", "Deletion policy for expired ephemeral clusters. Can be `Delete`, `Retain` or `Archive`.")
+	flag.DurationVar(&expiryWarningPeriod, "expiry-warning-period", 24*time.Hour, "The time before the expiry of an ephemeral cluster from which on a warning is emitted.")
+	flag.DurationVar(&serviceAccountTokenLifetime, "service-account-token-lifetime", 0, "The lifetime of the Steward tokens requested with the TokenRequest API. Must be at least 10m. If zero, the tokens are read from service account token secrets.")
+	flag.DurationVar(&readinessCheckInterval, "readiness-check-interval", time.Minute, "The time between two checks of the connections to Vault and to the git hosts.")
+	flag.StringVar(&readinessStrictness, "readiness-strictness", string(health.Lenient), "Which failing connection checks mark the operator as not ready. Can be `strict`, `lenient` or `report-only`.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -87,6 +141,22 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if serviceAccountTokenLifetime != 0 && serviceAccountTokenLifetime < 10*time.Minute {
+		setupLog.Error(fmt.Errorf("lifetime %s is shorter than 10m", serviceAccountTokenLifetime), "invalid service account token lifetime")
+		os.Exit(1)
+	}
+
+	switch health.Strictness(readinessStrictness) {
+	case health.Strict, health.Lenient, health.ReportOnly:
+	default:
+		setupLog.Error(fmt.Errorf("unknown strictness %q", readinessStrictness), "invalid readiness strictness")
+		os.Exit(1)
+	}
+	if readinessCheckInterval <= 0 {
+		setupLog.Error(fmt.Errorf("interval %s isn't positive", readinessCheckInterval), "invalid readiness check interval")
+		os.Exit(1)
+	}
+
 	creationPolicy := getDefaultCreationPolicy(defaultCreationPolicy)
 	deletionPolicy := getDefaultDeletionPolicy(defaultDeletionPolicy)
 
@@ -118,6 +188,45 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := vault.ConfigureStore(vault.StoreConfig{
+		Type:      vault.StoreType(secretStore),
+		Client:    mgr.GetClient(),
+		Namespace: watchNamespace,
+	}); err != nil {
+		setupLog.Error(err, "unable to configure secret store")
+		os.Exit(1)
+	}
+	if err := vault.ConfigureAuth(vault.AuthConfig{
+		Method:       vault.AuthMethod(vaultAuthMethod),
+		Path:         vaultAuthPath,
+		Role:         vaultAuthRole,
+		JWTFile:      vaultJWTFile,
+		RoleID:       vaultRoleID,
+		SecretIDFile: vaultSecretIDFile,
+		ClientCert:   vaultClientCert,
+		ClientKey:    vaultClientKey,
+	}); err != nil {
+		setupLog.Error(err, "unable to configure vault authentication")
+		os.Exit(1)
+	}
+	if err := vault.ConfigureLayout(vault.LayoutConfig{
+		PathTemplate:       vaultPathTemplate,
+		KeyName:            vaultKeyName,
+		OperatorUID:        operatorUID,
+		SharedPathTemplate: vaultSharedPathTemplate,
+	}); err != nil {
+		setupLog.Error(err, "unable to configure vault secret layout")
+		os.Exit(1)
+	}
+	if err := vault.ConfigureClusterAccess(vault.ClusterAccessConfig{
+		Enabled:          vaultClusterAccess,
+		AuthPathTemplate: vaultClusterAuthPath,
+		ServiceAccount:   vaultClusterServiceAccount,
+	}); err != nil {
+		setupLog.Error(err, "unable to configure vault cluster access")
+		os.Exit(1)
+	}
+
 	metrics.Registry.MustRegister(&operatorMetrics.CompileMetaCollector{
 		Client:    mgr.GetClient(),
 		Namespace: watchNamespace,
@@ -130,15 +239,26 @@ func main() {
 		Client:    mgr.GetClient(),
 		Namespace: watchNamespace,
 	})
+	metrics.Registry.MustRegister(&operatorMetrics.GitRepoCollector{
+		Client:    mgr.GetClient(),
+		Namespace: watchNamespace,
+	})
 
 	if err = (&controllers.ClusterReconciler{
-		Client:                mgr.GetClient(),
-		Scheme:                mgr.GetScheme(),
-		CreateSATokenSecret:   createSaTokenSecret,
-		DefaultCreationPolicy: creationPolicy,
-		DefaultDeletionPolicy: deletionPolicy,
-		DeleteProtection:      useDeleteProtection,
-		UseVault:              !skipVaultSetup,
+		Client:                      mgr.GetClient(),
+		Scheme:                      mgr.GetScheme(),
+		CreateSATokenSecret:         createSaTokenSecret,
+		DefaultCreationPolicy:       creationPolicy,
+		DefaultDeletionPolicy:       deletionPolicy,
+		DeleteProtection:            useDeleteProtection,
+		DeleteConfirmation:          useDeleteConfirmation,
+		DeletionGracePeriod:         deletionGracePeriod,
+		UseVault:                    !skipVaultSetup,
+		FactLabels:                  splitList(factLabels),
+		Recorder:                    mgr.GetEventRecorder("lieutenant-operator"),
+		EphemeralDeletionPolicy:     getDefaultDeletionPolicy(ephemeralDeletionPolicy),
+		ExpiryWarningPeriod:         expiryWarningPeriod,
+		ServiceAccountTokenLifetime: serviceAccountTokenLifetime,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
@@ -148,6 +268,8 @@ func main() {
 		Scheme:                mgr.GetScheme(),
 		DefaultCreationPolicy: creationPolicy,
 		DeleteProtection:      useDeleteProtection,
+		DeleteConfirmation:    useDeleteConfirmation,
+		DeletionGracePeriod:   deletionGracePeriod,
 		MaxReconcileInterval:  gitRepoMaxReconcileInterval,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GitRepo")
@@ -161,7 +283,9 @@ func main() {
 		DefaultDeletionPolicy:   deletionPolicy,
 		DefaultGlobalGitRepoUrl: defGlobalGitRepoUrl,
 		DeleteProtection:        useDeleteProtection,
-	}).SetupWithManager(mgr); err != nil {
+		DeleteConfirmation:      useDeleteConfirmation,
+		UseVault:                !skipVaultSetup,
+	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Tenant")
 		os.Exit(1)
 	}
@@ -173,6 +297,13 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "TenantCompilePipeline")
 		os.Exit(1)
 	}
+	if err = (&controllers.TenantTemplateReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TenantTemplate")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -183,6 +314,34 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	readinessLog := ctrl.Log.WithName("readiness")
+	checker := &health.Checker{
+		Client:     mgr.GetClient(),
+		Namespace:  watchNamespace,
+		Strictness: health.Strictness(readinessStrictness),
+		Interval:   readinessCheckInterval,
+		CheckGitHost: func(ctx context.Context, secret *corev1.Secret) error {
+			return manager.CheckAPISecret(ctx, secret, readinessLog)
+		},
+	}
+	if !skipVaultSetup {
+		checker.CheckVault = func() error {
+			return vault.CheckConnection(readinessLog)
+		}
+		if err := mgr.AddReadyzCheck("vault", checker.VaultCheck); err != nil {
+			setupLog.Error(err, "unable to set up vault ready check")
+			os.Exit(1)
+		}
+	}
+	if err := mgr.AddReadyzCheck("git-hosts", checker.GitHostsCheck); err != nil {
+		setupLog.Error(err, "unable to set up git hosts ready check")
+		os.Exit(1)
+	}
+	if err := mgr.Add(checker); err != nil {
+		setupLog.Error(err, "unable to set up readiness checks")
+		os.Exit(1)
+	}
+	metrics.Registry.MustRegister(checker)
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctx); err != nil {
@@ -211,3 +370,14 @@ func getDefaultDeletionPolicy(stringArg string) synv1alpha1.DeletionPolicy {
 		return synv1alpha1.ArchivePolicy
 	}
 }
+
+// splitList splits a comma separated list and drops empty elements
+func splitList(list string) []string {
+	out := []string{}
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -6,8 +6,11 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -38,6 +41,34 @@ var clusterDynFactsDesc = prometheus.NewDesc(
 	nil,
 )
 
+var clusterFactsValidDesc = prometheus.NewDesc(
+	"syn_lieutenant_cluster_facts_valid",
+	"Whether the facts of the cluster match the fact schema of its tenant. Only exported for tenants with a fact schema.",
+	[]string{"cluster", "tenant"},
+	nil,
+)
+
+var clusterExpiryDesc = prometheus.NewDesc(
+	"syn_lieutenant_cluster_expiry_timestamp_seconds",
+	"The time an ephemeral cluster expires as a unix timestamp. Only exported for ephemeral clusters.",
+	[]string{"cluster", "tenant"},
+	nil,
+)
+
+var clusterBootstrapTokenValidDesc = prometheus.NewDesc(
+	"syn_lieutenant_cluster_bootstrap_token_valid",
+	"Whether the bootstrap token of the cluster is valid, meaning it wasn't used yet and didn't expire. Only exported for clusters with a bootstrap token.",
+	[]string{"cluster", "tenant"},
+	nil,
+)
+
+var clusterBootstrapTokenExpiryDesc = prometheus.NewDesc(
+	"syn_lieutenant_cluster_bootstrap_token_expiry_timestamp_seconds",
+	"The time the bootstrap token of the cluster expires as a unix timestamp. Only exported for clusters with a bootstrap token.",
+	[]string{"cluster", "tenant"},
+	nil,
+)
+
 // cluster facts has dynamic labels
 func newClusterFactsDesc(lbls ...string) *prometheus.Desc {
 	return prometheus.NewDesc(
@@ -58,6 +89,16 @@ func newClusterDynFactsDesc(lbls ...string) *prometheus.Desc {
 	)
 }
 
+// cluster effective facts has dynamic labels
+func newClusterEffectiveFactsDesc(lbls ...string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		"syn_lieutenant_cluster_effective_facts",
+		"Lieutenant cluster effective facts, including the facts inherited from the tenant. Keys are normalized to be valid Prometheus labels.",
+		lbls,
+		nil,
+	)
+}
+
 // ClusterInfoCollector is a Prometheus collector that collects cluster info metrics.
 type ClusterInfoCollector struct {
 	Client client.Client
@@ -97,6 +138,54 @@ func (m *ClusterInfoCollector) Collect(ch chan<- prometheus.Metric) {
 		if err := clusterFacts(newClusterDynFactsDesc, cl, cl.Status.Facts, ch); err != nil {
 			log.Log.Info("failed to collect cluster dynamic facts", "error", err)
 		}
+
+		if err := clusterFacts(newClusterEffectiveFactsDesc, cl, cl.Status.EffectiveFacts, ch); err != nil {
+			log.Log.Info("failed to collect cluster effective facts", "error", err)
+		}
+
+		if cond := meta.FindStatusCondition(cl.Status.Conditions, synv1alpha1.ConditionFactsValid); cond != nil {
+			valid := 0.0
+			if cond.Status == metav1.ConditionTrue {
+				valid = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				clusterFactsValidDesc,
+				prometheus.GaugeValue,
+				valid,
+				cl.Name, cl.Spec.TenantRef.Name,
+			)
+		}
+
+		if token := cl.Status.BootstrapToken; token != nil {
+			valid := 0.0
+			if token.TokenValid && time.Now().Before(token.ValidUntil.Time) {
+				valid = 1
+			}
+			ch <- prometheus.MustNewConstMetric(
+				clusterBootstrapTokenValidDesc,
+				prometheus.GaugeValue,
+				valid,
+				cl.Name, cl.Spec.TenantRef.Name,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				clusterBootstrapTokenExpiryDesc,
+				prometheus.GaugeValue,
+				float64(token.ValidUntil.Unix()),
+				cl.Name, cl.Spec.TenantRef.Name,
+			)
+		}
+
+		expiry, err := cl.GetExpiry()
+		if err != nil {
+			log.Log.Info("failed to collect cluster expiry", "error", err)
+		} else if !expiry.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				clusterExpiryDesc,
+				prometheus.GaugeValue,
+				float64(expiry.Unix()),
+				cl.Name, cl.Spec.TenantRef.Name,
+			)
+		}
 	}
 }
 
@@ -110,7 +199,7 @@ func clusterFacts(descfn func(lbls ...string) *prometheus.Desc, cl synv1alpha1.C
 	rks, vs := pairs(facts)
 	ks := make([]string, len(rks))
 	for i, k := range rks {
-		ks[i] = normalizeLabelKey(k, []string{"cluster", "tenant"}, "fact_")
+		ks[i] = NormalizeLabelKey(k, []string{"cluster", "tenant"}, "fact_")
 	}
 	seen := make(map[string]int)
 	for i, k := range ks {
@@ -136,11 +225,11 @@ func clusterFacts(descfn func(lbls ...string) *prometheus.Desc, cl synv1alpha1.C
 // https://prometheus.io/docs/concepts/data_model/#metric-names-and-labels
 var validKeyCharacters = regexp.MustCompile(`(?:^[^a-zA-Z_]|[^a-zA-Z0-9_])`)
 
-// normalizeLabelKey normalizes a key to be a valid Prometheus metric name.
+// NormalizeLabelKey normalizes a key to be a valid Prometheus metric name.
 // It replaces invalid characters with underscores and prefixes the key with the given prefix if it starts with an underscore character.
 // If the key is empty it returns "_empty".
 // If the key is in the protected list after normalizing it prefixes the key with "orig_".
-func normalizeLabelKey(key string, protected []string, prefixForUnderscore string) string {
+func NormalizeLabelKey(key string, protected []string, prefixForUnderscore string) string {
 	if key == "" {
 		return "_empty"
 	}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,11 @@ func Test_ClusterInfoCollector(t *testing.T) {
 		"syn_lieutenant_cluster_info",
 		"syn_lieutenant_cluster_facts",
 		"syn_lieutenant_cluster_dynamic_facts",
+		"syn_lieutenant_cluster_effective_facts",
+		"syn_lieutenant_cluster_facts_valid",
+		"syn_lieutenant_cluster_expiry_timestamp_seconds",
+		"syn_lieutenant_cluster_bootstrap_token_valid",
+		"syn_lieutenant_cluster_bootstrap_token_expiry_timestamp_seconds",
 	}
 
 	c := prepareClient(t,
@@ -39,6 +45,7 @@ func Test_ClusterInfoCollector(t *testing.T) {
 				TenantRef: corev1.LocalObjectReference{
 					Name: "t2",
 				},
+				ExpiresAt: &metav1.Time{Time: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
 				Facts: map[string]string{
 					"key":                            "value",
 					"_key":                           "value",
@@ -54,6 +61,21 @@ func Test_ClusterInfoCollector(t *testing.T) {
 				Facts: map[string]string{
 					"test": "value",
 				},
+				EffectiveFacts: map[string]string{
+					"key":  "value",
+					"test": "value",
+					"tier": "gold",
+				},
+				Conditions: []metav1.Condition{
+					{
+						Type:   synv1alpha1.ConditionFactsValid,
+						Status: metav1.ConditionFalse,
+					},
+				},
+				BootstrapToken: &synv1alpha1.BootstrapToken{
+					ValidUntil: metav1.Time{Time: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+					TokenValid: true,
+				},
 			},
 		},
 	)
@@ -64,14 +86,30 @@ func Test_ClusterInfoCollector(t *testing.T) {
 		Namespace: namespace,
 	}
 
-	metrics := `# HELP syn_lieutenant_cluster_dynamic_facts Lieutenant cluster dynamic facts. Keys are normalized to be valid Prometheus labels.
+	metrics := `# HELP syn_lieutenant_cluster_bootstrap_token_expiry_timestamp_seconds The time the bootstrap token of the cluster expires as a unix timestamp. Only exported for clusters with a bootstrap token.
+# TYPE syn_lieutenant_cluster_bootstrap_token_expiry_timestamp_seconds gauge
+syn_lieutenant_cluster_bootstrap_token_expiry_timestamp_seconds{cluster="c2",tenant="t2"} 1.893456e+09
+# HELP syn_lieutenant_cluster_bootstrap_token_valid Whether the bootstrap token of the cluster is valid, meaning it wasn't used yet and didn't expire. Only exported for clusters with a bootstrap token.
+# TYPE syn_lieutenant_cluster_bootstrap_token_valid gauge
+syn_lieutenant_cluster_bootstrap_token_valid{cluster="c2",tenant="t2"} 1
+# HELP syn_lieutenant_cluster_expiry_timestamp_seconds The time an ephemeral cluster expires as a unix timestamp. Only exported for ephemeral clusters.
+# TYPE syn_lieutenant_cluster_expiry_timestamp_seconds gauge
+syn_lieutenant_cluster_expiry_timestamp_seconds{cluster="c2",tenant="t2"} 1.893456e+09
+# HELP syn_lieutenant_cluster_dynamic_facts Lieutenant cluster dynamic facts. Keys are normalized to be valid Prometheus labels.
 # TYPE syn_lieutenant_cluster_dynamic_facts gauge
 syn_lieutenant_cluster_dynamic_facts{cluster="c-empty",tenant=""} 1
 syn_lieutenant_cluster_dynamic_facts{cluster="c2",tenant="t2",test="value"} 1
+# HELP syn_lieutenant_cluster_effective_facts Lieutenant cluster effective facts, including the facts inherited from the tenant. Keys are normalized to be valid Prometheus labels.
+# TYPE syn_lieutenant_cluster_effective_facts gauge
+syn_lieutenant_cluster_effective_facts{cluster="c-empty",tenant=""} 1
+syn_lieutenant_cluster_effective_facts{cluster="c2",key="value",tenant="t2",test="value",tier="gold"} 1
 # HELP syn_lieutenant_cluster_facts Lieutenant cluster facts. Keys are normalized to be valid Prometheus labels.
 # TYPE syn_lieutenant_cluster_facts gauge
 syn_lieutenant_cluster_facts{cluster="c-empty",tenant=""} 1
 syn_lieutenant_cluster_facts{cluster="c2",fact__key="value",fact__key_duplicate_after_normalize="value",fact__key_duplicate_after_normalize_1="value",fact__key_duplicate_after_normalize_2="value",key="value",key_with847_____invalid_chars="value",orig_cluster="value",orig_tenant="value",tenant="t2"} 1
+# HELP syn_lieutenant_cluster_facts_valid Whether the facts of the cluster match the fact schema of its tenant. Only exported for tenants with a fact schema.
+# TYPE syn_lieutenant_cluster_facts_valid gauge
+syn_lieutenant_cluster_facts_valid{cluster="c2",tenant="t2"} 0
 # HELP syn_lieutenant_cluster_info Cluster information metric.
 # TYPE syn_lieutenant_cluster_info gauge
 syn_lieutenant_cluster_info{cluster="c-empty",display_name="",tenant=""} 1
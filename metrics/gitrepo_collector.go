@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups=syn.tools,resources=gitrepos,verbs=get;list;watch
+//+kubebuilder:rbac:groups=syn.tools,resources=gitrepos/status,verbs=get
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+var gitRepoPhaseDesc = prometheus.NewDesc(
+	"syn_lieutenant_gitrepo_phase",
+	"The phase of the GitRepo. The value is 1 for the current phase and 0 for the other phases.",
+	[]string{"gitrepo", "tenant", "cluster", "phase"},
+	nil,
+)
+
+var gitRepoAccessTokenExpiryDesc = prometheus.NewDesc(
+	"syn_lieutenant_gitrepo_access_token_expiry_timestamp_seconds",
+	"The time the project access token of the GitRepo expires as a unix timestamp. Only exported for GitRepos with an access token.",
+	[]string{"gitrepo", "tenant", "cluster"},
+	nil,
+)
+
+var gitRepoDeployKeyCreatedDesc = prometheus.NewDesc(
+	"syn_lieutenant_gitrepo_generated_deploy_key_created_timestamp_seconds",
+	"The time the generated deploy key of the GitRepo was created as a unix timestamp.",
+	[]string{"gitrepo", "tenant", "cluster", "key"},
+	nil,
+)
+
+var gitRepoCIVariablesDesc = prometheus.NewDesc(
+	"syn_lieutenant_gitrepo_ci_variables",
+	"The number of CI variables of the GitRepo managed by the operator.",
+	[]string{"gitrepo", "tenant", "cluster"},
+	nil,
+)
+
+// gitRepoPhases are the phases exported for each GitRepo
+var gitRepoPhases = []synv1alpha1.GitPhase{
+	synv1alpha1.Creating,
+	synv1alpha1.Created,
+	synv1alpha1.Failed,
+}
+
+// GitRepoCollector is a Prometheus collector that collects the state of GitRepos and the expiry of their credentials.
+type GitRepoCollector struct {
+	Client client.Client
+
+	Namespace string
+}
+
+var _ prometheus.Collector = &GitRepoCollector{}
+
+// Describe implements prometheus.Collector.
+// Sends the descriptors of the metrics to the channel.
+func (*GitRepoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gitRepoPhaseDesc
+	ch <- gitRepoAccessTokenExpiryDesc
+	ch <- gitRepoDeployKeyCreatedDesc
+	ch <- gitRepoCIVariablesDesc
+}
+
+// Collect implements prometheus.Collector.
+// Iterates over all managed GitRepos and sends their phase and the state of their credentials.
+func (m *GitRepoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	repos := synv1alpha1.GitRepoList{}
+	if err := m.Client.List(ctx, &repos, client.InNamespace(m.Namespace)); err != nil {
+		err := fmt.Errorf("failed to list git repos: %w", err)
+		ch <- prometheus.NewInvalidMetric(gitRepoPhaseDesc, err)
+		return
+	}
+
+	for _, repo := range repos.Items {
+		if repo.Spec.RepoType == synv1alpha1.UnmanagedRepoType {
+			continue
+		}
+		lbls := []string{repo.Name, repo.Spec.TenantRef.Name, gitRepoCluster(repo)}
+
+		phase := synv1alpha1.PhaseUnknown
+		if repo.Status.Phase != nil {
+			phase = *repo.Status.Phase
+		}
+		for _, p := range gitRepoPhases {
+			v := 0.0
+			if p == phase {
+				v = 1
+			}
+			ch <- prometheus.MustNewConstMetric(gitRepoPhaseDesc, prometheus.GaugeValue, v, append(lbls, string(p))...)
+		}
+
+		if expiry, err := m.accessTokenExpiry(ctx, repo); err != nil {
+			log.Log.Info("failed to collect access token expiry", "gitrepo", repo.Name, "error", err)
+		} else if !expiry.IsZero() {
+			ch <- prometheus.MustNewConstMetric(gitRepoAccessTokenExpiryDesc, prometheus.GaugeValue, float64(expiry.Unix()), lbls...)
+		}
+
+		for key, dk := range repo.Status.GeneratedDeployKeys {
+			secret := &corev1.Secret{}
+			if err := m.Client.Get(ctx, client.ObjectKey{Name: dk.SecretRef.Name, Namespace: repo.Namespace}, secret); err != nil {
+				log.Log.Info("failed to collect deploy key age", "gitrepo", repo.Name, "key", key, "error", err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(gitRepoDeployKeyCreatedDesc, prometheus.GaugeValue, float64(synv1alpha1.DeployKeyGeneratedAt(secret).Unix()), append(lbls, key)...)
+		}
+
+		vars := []synv1alpha1.EnvVar{}
+		if repo.Status.LastAppliedCIVariables != "" {
+			if err := json.Unmarshal([]byte(repo.Status.LastAppliedCIVariables), &vars); err != nil {
+				log.Log.Info("failed to collect CI variables", "gitrepo", repo.Name, "error", err)
+				continue
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(gitRepoCIVariablesDesc, prometheus.GaugeValue, float64(len(vars)), lbls...)
+	}
+}
+
+// accessTokenExpiry returns the expiry of the project access token of the repo.
+// It returns the zero time if the repo has no access token or the token wasn't issued yet.
+func (m *GitRepoCollector) accessTokenExpiry(ctx context.Context, repo synv1alpha1.GitRepo) (time.Time, error) {
+	if repo.Spec.AccessToken.SecretRef == "" {
+		return time.Time{}, nil
+	}
+	secret := &corev1.Secret{}
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: repo.Spec.AccessToken.SecretRef, Namespace: repo.Namespace}, secret); err != nil {
+		return time.Time{}, client.IgnoreNotFound(err)
+	}
+	expiresAt, ok := secret.Annotations[synv1alpha1.LieutenantAccessTokenExpiresAtAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, expiresAt)
+}
+
+// gitRepoCluster returns the name of the cluster owning the repo, or an empty string for repos not owned by a cluster
+func gitRepoCluster(repo synv1alpha1.GitRepo) string {
+	owner := metav1.GetControllerOf(&repo)
+	if owner == nil || owner.Kind != "Cluster" {
+		return ""
+	}
+	return owner.Name
+}
@@ -0,0 +1,143 @@
+package metrics_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/metrics"
+)
+
+func Test_GitRepoCollector(t *testing.T) {
+	namespace := "testns"
+	expectedMetricNames := []string{
+		"syn_lieutenant_gitrepo_phase",
+		"syn_lieutenant_gitrepo_access_token_expiry_timestamp_seconds",
+		"syn_lieutenant_gitrepo_generated_deploy_key_created_timestamp_seconds",
+		"syn_lieutenant_gitrepo_ci_variables",
+	}
+
+	c := prepareClient(t,
+		&synv1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "c-cluster",
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: synv1alpha1.GroupVersion.String(),
+					Kind:       "Cluster",
+					Name:       "c-cluster",
+					Controller: ptr.To(true),
+				}},
+			},
+			Spec: synv1alpha1.GitRepoSpec{
+				TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+				GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+					RepoType:    synv1alpha1.AutoRepoType,
+					AccessToken: synv1alpha1.AccessToken{SecretRef: "c-cluster-api-token"},
+				},
+			},
+			Status: synv1alpha1.GitRepoStatus{
+				Phase:                  ptr.To(synv1alpha1.Created),
+				LastAppliedCIVariables: `[{"name":"FOO","value":"bar"},{"name":"BAZ","value":"qux"}]`,
+				GeneratedDeployKeys: map[string]synv1alpha1.DeployKeyStatus{
+					"generated-steward": {SecretRef: corev1.LocalObjectReference{Name: "c-cluster-deploy-key-steward"}},
+				},
+			},
+		},
+		&synv1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "t-tenant",
+			},
+			Spec: synv1alpha1.GitRepoSpec{
+				TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+				GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+					RepoType: synv1alpha1.AutoRepoType,
+				},
+			},
+			Status: synv1alpha1.GitRepoStatus{
+				Phase: ptr.To(synv1alpha1.Failed),
+			},
+		},
+		&synv1alpha1.GitRepo{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "unmanaged",
+			},
+			Spec: synv1alpha1.GitRepoSpec{
+				GitRepoTemplate: synv1alpha1.GitRepoTemplate{
+					RepoType: synv1alpha1.UnmanagedRepoType,
+				},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "c-cluster-api-token",
+				Annotations: map[string]string{
+					"lieutenant.syn.tools/accessTokenExpiresAt": "2030-01-01T00:00:00Z",
+				},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         namespace,
+				Name:              "c-cluster-deploy-key-steward",
+				CreationTimestamp: metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+	)
+
+	subject := &metrics.GitRepoCollector{
+		Client: c,
+
+		Namespace: namespace,
+	}
+
+	metrics := `# HELP syn_lieutenant_gitrepo_access_token_expiry_timestamp_seconds The time the project access token of the GitRepo expires as a unix timestamp. Only exported for GitRepos with an access token.
+# TYPE syn_lieutenant_gitrepo_access_token_expiry_timestamp_seconds gauge
+syn_lieutenant_gitrepo_access_token_expiry_timestamp_seconds{cluster="c-cluster",gitrepo="c-cluster",tenant="t-tenant"} 1.893456e+09
+# HELP syn_lieutenant_gitrepo_ci_variables The number of CI variables of the GitRepo managed by the operator.
+# TYPE syn_lieutenant_gitrepo_ci_variables gauge
+syn_lieutenant_gitrepo_ci_variables{cluster="c-cluster",gitrepo="c-cluster",tenant="t-tenant"} 2
+syn_lieutenant_gitrepo_ci_variables{cluster="",gitrepo="t-tenant",tenant="t-tenant"} 0
+# HELP syn_lieutenant_gitrepo_generated_deploy_key_created_timestamp_seconds The time the generated deploy key of the GitRepo was created as a unix timestamp.
+# TYPE syn_lieutenant_gitrepo_generated_deploy_key_created_timestamp_seconds gauge
+syn_lieutenant_gitrepo_generated_deploy_key_created_timestamp_seconds{cluster="c-cluster",gitrepo="c-cluster",key="generated-steward",tenant="t-tenant"} 1.7040672e+09
+# HELP syn_lieutenant_gitrepo_phase The phase of the GitRepo. The value is 1 for the current phase and 0 for the other phases.
+# TYPE syn_lieutenant_gitrepo_phase gauge
+syn_lieutenant_gitrepo_phase{cluster="c-cluster",gitrepo="c-cluster",phase="created",tenant="t-tenant"} 1
+syn_lieutenant_gitrepo_phase{cluster="c-cluster",gitrepo="c-cluster",phase="creating",tenant="t-tenant"} 0
+syn_lieutenant_gitrepo_phase{cluster="c-cluster",gitrepo="c-cluster",phase="failed",tenant="t-tenant"} 0
+syn_lieutenant_gitrepo_phase{cluster="",gitrepo="t-tenant",phase="created",tenant="t-tenant"} 0
+syn_lieutenant_gitrepo_phase{cluster="",gitrepo="t-tenant",phase="creating",tenant="t-tenant"} 0
+syn_lieutenant_gitrepo_phase{cluster="",gitrepo="t-tenant",phase="failed",tenant="t-tenant"} 1
+`
+	require.NoError(t,
+		testutil.CollectAndCompare(subject, strings.NewReader(metrics), expectedMetricNames...),
+	)
+}
+
+func Test_GitRepoCollector_ListFail(t *testing.T) {
+	namespace := "testns"
+
+	listErr := errors.New("whoopsie daisy")
+
+	c := prepareFailingClient(t, listErr)
+
+	subject := &metrics.GitRepoCollector{
+		Client: c,
+
+		Namespace: namespace,
+	}
+
+	require.ErrorContains(t, testutil.CollectAndCompare(subject, strings.NewReader(``)), listErr.Error())
+}
@@ -3,12 +3,15 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/events"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -16,6 +19,8 @@ import (
 const (
 	protectionSettingEnvVar    = "LIEUTENANT_DELETE_PROTECTION"
 	DeleteProtectionAnnotation = "syn.tools/protected-delete"
+	// DeleteProtectionConfirm is the value of the delete protection annotation which requires confirming the deletion by name
+	DeleteProtectionConfirm = "confirm"
 )
 
 // Object defines an interface to extract necessary information from the CRs
@@ -49,14 +54,38 @@ type Context struct {
 	DefaultGlobalGitRepoUrl string
 	UseVault                bool
 	UseDeletionProtection   bool
+	// UseDeletionConfirmation makes the default deletion protection require confirming the deletion by name
+	UseDeletionConfirmation bool
+	// DeletionGracePeriod is the time after the deletion of an object before its external resources are touched
+	DeletionGracePeriod time.Duration
+	FactLabels          []string
+	Recorder            events.EventRecorder
+	// EphemeralDeletionPolicy is the deletion policy applied to expired ephemeral clusters
+	EphemeralDeletionPolicy synv1alpha1.DeletionPolicy
+	// ExpiryWarningPeriod is the time before the expiry of an ephemeral cluster from which on it's reported as expiring
+	ExpiryWarningPeriod time.Duration
+	// ServiceAccountTokenLifetime is the lifetime of the Steward tokens requested with the TokenRequest API.
+	// If it's zero, the tokens are read from the legacy service account token secrets.
+	ServiceAccountTokenLifetime time.Duration
+}
+
+// Eventf records an event for the given object, if an event recorder is configured.
+func (c *Context) Eventf(obj runtime.Object, eventtype, reason, action, note string, args ...interface{}) {
+	if c.Recorder == nil {
+		return
+	}
+	c.Recorder.Eventf(obj, nil, eventtype, reason, action, note, args...)
 }
 
 // Result indicates whether the current execution should be aborted and
 // if there was an error.
+// RequeueAfter is the time until the next deadline of the step, for example the expiry of a token.
+// RunPipeline returns the earliest deadline of all steps.
 type Result struct {
-	Abort   bool
-	Err     error
-	Requeue bool
+	Abort        bool
+	Err          error
+	Requeue      bool
+	RequeueAfter time.Duration
 }
 
 // Function defines the general form of a pipeline function.
@@ -71,18 +100,32 @@ func RunPipeline(obj Object, data *Context, steps []Step) Result {
 	l := data.Log.V(7).WithName("RunPipeline")
 	l.Info("running steps", "steps", stepNames(steps))
 
+	var requeueAfter time.Duration
 	for i, step := range steps {
 		r := step.F(obj, data)
 		l.Info("ran step", "step", step.Name, "result", r, "step_index", i)
+		requeueAfter = EarliestRequeue(requeueAfter, r.RequeueAfter)
 		if r.Abort || r.Err != nil {
 			if r.Err == nil {
-				return Result{Requeue: r.Requeue}
+				// Propagate the abort so a nested pipeline also stops the enclosing pipeline
+				return Result{Abort: true, Requeue: r.Requeue, RequeueAfter: requeueAfter}
 			}
 			return Result{Err: fmt.Errorf("step %s failed: %w", step.Name, r.Err)}
 		}
 	}
 
-	return Result{}
+	return Result{RequeueAfter: requeueAfter}
+}
+
+// EarliestRequeue returns the shorter of the two durations, ignoring durations which aren't positive.
+func EarliestRequeue(a, b time.Duration) time.Duration {
+	if a <= 0 {
+		return max(b, 0)
+	}
+	if b <= 0 || a < b {
+		return a
+	}
+	return b
 }
 
 func Common(obj Object, data *Context) Result {
@@ -2,13 +2,17 @@ package pipeline
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
 
 	"errors"
 
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -30,6 +34,9 @@ func addDeletionProtection(instance Object, data *Context) Result {
 
 		if _, ok := annotations[DeleteProtectionAnnotation]; !ok {
 			annotations[DeleteProtectionAnnotation] = "true"
+			if data.UseDeletionConfirmation {
+				annotations[DeleteProtectionAnnotation] = DeleteProtectionConfirm
+			}
 		}
 
 		instance.SetAnnotations(annotations)
@@ -118,26 +125,113 @@ func handleDeletion(obj Object, data *Context) Result {
 	}
 
 	instance := obj
+	protected := deletionProtected(instance)
 
-	annotationValue, exists := instance.GetAnnotations()[DeleteProtectionAnnotation]
+	if sliceContainsString(instance.GetFinalizers(), data.FinalizerName) && !protected {
+		return Result{}
+	}
 
-	var protected bool
-	var err error
-	if exists {
-		protected, err = strconv.ParseBool(annotationValue)
-		// Assume true if it can't be parsed
-		if err != nil {
-			protected = true
-		}
-	} else {
-		protected = false
+	return Result{Err: fmt.Errorf("finalzier was not removed")}
+}
+
+// deletionProtected returns true if the deletion protection annotation prevents the deletion of the object.
+// A protected object can be deleted once the deletion is confirmed by annotating it with its name.
+func deletionProtected(obj Object) bool {
+	annotations := obj.GetAnnotations()
+	if confirm, ok := annotations[synv1alpha1.ConfirmDeleteAnnotation]; ok && confirm == obj.GetName() {
+		return false
 	}
 
-	if sliceContainsString(instance.GetFinalizers(), data.FinalizerName) && !protected {
+	annotationValue, exists := annotations[DeleteProtectionAnnotation]
+	if !exists {
+		return false
+	}
+	if annotationValue == DeleteProtectionConfirm {
+		return true
+	}
+	protected, err := strconv.ParseBool(annotationValue)
+	// Assume true if it can't be parsed
+	return err != nil || protected
+}
+
+// CheckDeletionProtection stops the pipeline of a deleted object before any external resource is touched,
+// as long as the deletion protection or the deletion grace period blocks the deletion.
+// A blocked object is released without touching its external resources if it's annotated with the undelete annotation.
+func CheckDeletionProtection(obj Object, data *Context) Result {
+	if !data.Deleted {
+		return Result{}
+	}
+	// Decommissioning manages the deletion protection of a cluster and has to continue after the cluster was deleted
+	if cluster, ok := obj.(*synv1alpha1.Cluster); ok && cluster.DecommissionRequested() && cluster.Status.Phase != synv1alpha1.DecommissionedPhase {
 		return Result{}
 	}
 
-	return Result{Err: fmt.Errorf("finalzier was not removed")}
+	var requeueAfter time.Duration
+	if data.DeletionGracePeriod > 0 {
+		requeueAfter = time.Until(obj.GetDeletionTimestamp().Add(data.DeletionGracePeriod))
+	}
+	protected := deletionProtected(obj)
+	if !protected && requeueAfter <= 0 {
+		return Result{}
+	}
+
+	if undelete, _ := strconv.ParseBool(obj.GetAnnotations()[synv1alpha1.UndeleteAnnotation]); undelete {
+		return undeleteObject(obj, data)
+	}
+
+	data.Log.Info("Deletion is blocked", "protected", protected, "gracePeriodRemaining", max(requeueAfter, 0))
+	return Result{Abort: true, RequeueAfter: requeueAfter}
+}
+
+// undeleteObject releases a deleted object without touching its external resources.
+// The Clusters and GitRepos owned by the object are orphaned so they aren't garbage collected.
+func undeleteObject(obj Object, data *Context) Result {
+	data.Log.Info("Undeleting object, external resources are retained")
+	if err := orphanDependents(obj, data); err != nil {
+		return Result{Err: fmt.Errorf("orphan dependents: %w", err)}
+	}
+
+	controllerutil.RemoveFinalizer(obj, data.FinalizerName)
+	if err := data.Client.Update(data.Context, obj); err != nil {
+		if k8serrors.IsConflict(err) {
+			return Result{Requeue: true, Abort: true}
+		}
+		return Result{Err: fmt.Errorf("remove finalizer: %w", err)}
+	}
+	data.Eventf(obj, corev1.EventTypeNormal, "Undeleted", "Undelete", "Released the object without deleting its external resources")
+	return Result{Abort: true}
+}
+
+// orphanDependents removes the owner reference to the object from the Clusters and GitRepos it owns.
+func orphanDependents(obj Object, data *Context) error {
+	clusters := &synv1alpha1.ClusterList{}
+	if err := data.Client.List(data.Context, clusters, client.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("list clusters: %w", err)
+	}
+	repos := &synv1alpha1.GitRepoList{}
+	if err := data.Client.List(data.Context, repos, client.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("list gitrepos: %w", err)
+	}
+
+	dependents := []client.Object{}
+	for i := range clusters.Items {
+		dependents = append(dependents, &clusters.Items[i])
+	}
+	for i := range repos.Items {
+		dependents = append(dependents, &repos.Items[i])
+	}
+	for _, dep := range dependents {
+		refs := dep.GetOwnerReferences()
+		kept := slices.DeleteFunc(slices.Clone(refs), func(ref metav1.OwnerReference) bool { return ref.UID == obj.GetUID() })
+		if len(kept) == len(refs) {
+			continue
+		}
+		dep.SetOwnerReferences(kept)
+		if err := data.Client.Update(data.Context, dep); err != nil {
+			return fmt.Errorf("update %s: %w", dep.GetName(), err)
+		}
+	}
+	return nil
 }
 
 // Checks if the slice of strings contains a specific string
@@ -99,6 +99,43 @@ var handleDeletionCases = genericCases{
 			finalizerName: "test",
 		},
 	},
+	"Confirmation required": {
+		wantErr: true,
+		args: args{
+			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "c-cluster",
+					Annotations: map[string]string{
+						DeleteProtectionAnnotation:          DeleteProtectionConfirm,
+						synv1alpha1.ConfirmDeleteAnnotation: "c-other",
+					},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Finalizers: []string{
+						"test",
+					},
+				},
+			},
+			finalizerName: "test",
+		},
+	},
+	"Deletion confirmed": {
+		args: args{
+			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "c-cluster",
+					Annotations: map[string]string{
+						DeleteProtectionAnnotation:          DeleteProtectionConfirm,
+						synv1alpha1.ConfirmDeleteAnnotation: "c-cluster",
+					},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Finalizers: []string{
+						"test",
+					},
+				},
+			},
+			finalizerName: "test",
+		},
+	},
 	"Nonsense annotation value": {
 		wantErr: true,
 		args: args{
@@ -144,6 +181,7 @@ func TestHandleDeletion(t *testing.T) {
 type addDeletionProtectionArgs struct {
 	instance *synv1alpha1.Cluster
 	enable   bool
+	confirm  bool
 	result   string
 }
 
@@ -158,6 +196,14 @@ var addDeletionProtectionCases = map[string]struct {
 			result:   "true",
 		},
 	},
+	"Add deletion protection with confirmation": {
+		args: addDeletionProtectionArgs{
+			instance: &synv1alpha1.Cluster{},
+			enable:   true,
+			confirm:  true,
+			result:   DeleteProtectionConfirm,
+		},
+	},
 	"Don't add deletion protection": {
 		args: addDeletionProtectionArgs{
 			instance: &synv1alpha1.Cluster{},
@@ -171,7 +217,8 @@ func TestAddDeletionProtection(t *testing.T) {
 	for name, tt := range addDeletionProtectionCases {
 		t.Run(name, func(t *testing.T) {
 			addDeletionProtection(tt.args.instance, addLogger(&Context{
-				UseDeletionProtection: tt.args.enable,
+				UseDeletionProtection:   tt.args.enable,
+				UseDeletionConfirmation: tt.args.confirm,
 			}))
 
 			result := tt.args.instance.GetAnnotations()[DeleteProtectionAnnotation]
@@ -182,6 +229,98 @@ func TestAddDeletionProtection(t *testing.T) {
 	}
 }
 
+func TestCheckDeletionProtection(t *testing.T) {
+	deletedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	tests := map[string]struct {
+		annotations map[string]string
+		gracePeriod time.Duration
+		owned       bool
+
+		wantAbort    bool
+		wantRequeue  bool
+		wantReleased bool
+	}{
+		"unprotected": {},
+		"protected": {
+			annotations: map[string]string{DeleteProtectionAnnotation: "true"},
+			wantAbort:   true,
+		},
+		"confirmed": {
+			annotations: map[string]string{
+				DeleteProtectionAnnotation:          DeleteProtectionConfirm,
+				synv1alpha1.ConfirmDeleteAnnotation: "c-cluster",
+			},
+		},
+		"within grace period": {
+			gracePeriod: 2 * time.Hour,
+			wantAbort:   true,
+			wantRequeue: true,
+		},
+		"grace period passed": {
+			gracePeriod: 30 * time.Minute,
+		},
+		"undelete within grace period": {
+			annotations:  map[string]string{synv1alpha1.UndeleteAnnotation: "true"},
+			gracePeriod:  2 * time.Hour,
+			owned:        true,
+			wantAbort:    true,
+			wantReleased: true,
+		},
+		"undelete protected": {
+			annotations:  map[string]string{DeleteProtectionAnnotation: "true", synv1alpha1.UndeleteAnnotation: "true"},
+			wantAbort:    true,
+			wantReleased: true,
+		},
+		"undelete too late": {
+			annotations: map[string]string{synv1alpha1.UndeleteAnnotation: "true"},
+			gracePeriod: 30 * time.Minute,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cluster := &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "c-cluster",
+					UID:               "cluster-uid",
+					Annotations:       tt.annotations,
+					DeletionTimestamp: &deletedAt,
+					Finalizers:        []string{synv1alpha1.FinalizerName},
+				},
+			}
+			repo := &synv1alpha1.GitRepo{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "c-cluster",
+				},
+			}
+			if tt.owned {
+				repo.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(cluster, synv1alpha1.GroupVersion.WithKind("Cluster"))}
+			}
+			c, _ := testSetupClient(cluster, repo)
+
+			res := CheckDeletionProtection(cluster, addLogger(&Context{
+				Context:             context.Background(),
+				Client:              c,
+				Deleted:             true,
+				FinalizerName:       synv1alpha1.FinalizerName,
+				DeletionGracePeriod: tt.gracePeriod,
+			}))
+			require.NoError(t, res.Err)
+			assert.Equal(t, tt.wantAbort, res.Abort)
+			assert.Equal(t, tt.wantRequeue, res.RequeueAfter > 0)
+
+			if !tt.wantReleased {
+				assert.Equal(t, []string{synv1alpha1.FinalizerName}, cluster.Finalizers)
+				return
+			}
+			assert.Empty(t, cluster.Finalizers)
+			actual := &synv1alpha1.GitRepo{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(repo), actual))
+			assert.Empty(t, actual.OwnerReferences, "should orphan the GitRepo")
+		})
+	}
+}
+
 var checkIfDeletedCases = map[string]struct {
 	args    args
 	wantErr bool
@@ -357,3 +496,53 @@ func addLogger(c *Context) *Context {
 	c.Log = zapr.NewLogger(l)
 	return c
 }
+
+func TestRunPipeline_RequeueAfter(t *testing.T) {
+	requeueAfter := func(d time.Duration) Step {
+		return Step{Name: d.String(), F: func(Object, *Context) Result { return Result{RequeueAfter: d} }}
+	}
+	abort := Step{Name: "abort", F: func(Object, *Context) Result { return Result{Abort: true} }}
+	fail := Step{Name: "fail", F: func(Object, *Context) Result { return Result{Err: fmt.Errorf("failed")} }}
+
+	tests := map[string]struct {
+		steps []Step
+		want  time.Duration
+	}{
+		"no deadline": {
+			steps: []Step{requeueAfter(0)},
+		},
+		"earliest deadline": {
+			steps: []Step{requeueAfter(time.Hour), requeueAfter(0), requeueAfter(time.Minute), requeueAfter(2 * time.Minute)},
+			want:  time.Minute,
+		},
+		"deadlines in the past are ignored": {
+			steps: []Step{requeueAfter(-time.Minute), requeueAfter(time.Hour)},
+			want:  time.Hour,
+		},
+		"nested pipeline": {
+			steps: []Step{requeueAfter(time.Hour), {Name: "nested", F: func(obj Object, data *Context) Result {
+				return RunPipeline(obj, data, []Step{requeueAfter(time.Minute)})
+			}}},
+			want: time.Minute,
+		},
+		"abort keeps the deadline": {
+			steps: []Step{requeueAfter(time.Hour), abort, requeueAfter(time.Minute)},
+			want:  time.Hour,
+		},
+		"error drops the deadline": {
+			steps: []Step{requeueAfter(time.Hour), fail},
+		},
+		"nested abort stops the pipeline": {
+			steps: []Step{{Name: "nested", F: func(obj Object, data *Context) Result {
+				return RunPipeline(obj, data, []Step{abort})
+			}}, requeueAfter(time.Minute)},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := &Context{Log: zapr.NewLogger(zap.NewNop())}
+			res := RunPipeline(&synv1alpha1.Cluster{}, data, tt.steps)
+			assert.Equal(t, tt.want, res.RequeueAfter)
+		})
+	}
+}
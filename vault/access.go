@@ -0,0 +1,316 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/vault/api"
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterAccess describes the Vault policy and the auth role which grant a cluster read access to its secrets
+type ClusterAccess struct {
+	// Name is the name of the policy and the role
+	Name string
+	// Paths are the paths below which the cluster can read all secrets
+	Paths []string
+	// AuthPath is the mount path of the Kubernetes auth method, without the `auth/` prefix
+	AuthPath string
+	// ServiceAccountName and ServiceAccountNamespace are the service account bound to the role
+	ServiceAccountName      string
+	ServiceAccountNamespace string
+}
+
+// AccessClient manages the Vault policies and auth roles of clusters.
+// It's implemented by the Vault stores.
+type AccessClient interface {
+	SetClusterAccess(access ClusterAccess) error
+	RemoveClusterAccess(access ClusterAccess) error
+}
+
+// DefaultClusterAuthPathTemplate is the default template of the mount path of the Kubernetes auth method of a cluster.
+// Each cluster needs its own auth method, otherwise the Steward of any cluster could log in with the role of another cluster.
+const DefaultClusterAuthPathTemplate = "{{.Cluster}}"
+
+// ClusterAccessConfig configures the Vault policies and auth roles provisioned for clusters
+type ClusterAccessConfig struct {
+	// Enabled enables provisioning a policy and an auth role per cluster
+	Enabled bool
+	// AuthPathTemplate is a Go template of the mount path of the Kubernetes auth method of a cluster.
+	// The fields .Tenant and .Cluster are available.
+	// The template must render a different path for each cluster.
+	AuthPathTemplate string
+	// ServiceAccount is the service account bound to the role, in the form `namespace/name`
+	ServiceAccount string
+}
+
+type clusterAccessConfig struct {
+	enabled                 bool
+	authPath                *template.Template
+	serviceAccountName      string
+	serviceAccountNamespace string
+}
+
+var accessConfig = &clusterAccessConfig{}
+
+// ConfigureClusterAccess configures the Vault policies and auth roles provisioned for clusters.
+func ConfigureClusterAccess(cfg ClusterAccessConfig) error {
+	if !cfg.Enabled {
+		accessConfig = &clusterAccessConfig{}
+		return nil
+	}
+	if cfg.AuthPathTemplate == "" {
+		cfg.AuthPathTemplate = DefaultClusterAuthPathTemplate
+	}
+	tmpl, err := template.New("authPath").Option("missingkey=error").Parse(cfg.AuthPathTemplate)
+	if err != nil {
+		return fmt.Errorf("parse auth path template: %w", err)
+	}
+	namespace, name, ok := strings.Cut(cfg.ServiceAccount, "/")
+	if !ok || namespace == "" || name == "" {
+		return fmt.Errorf("service account %q must have the form namespace/name", cfg.ServiceAccount)
+	}
+	c := &clusterAccessConfig{
+		enabled:                 true,
+		authPath:                tmpl,
+		serviceAccountName:      name,
+		serviceAccountNamespace: namespace,
+	}
+
+	a, err := c.renderAuthPath("t-tenant", "c-cluster")
+	if err != nil {
+		return err
+	}
+	b, err := c.renderAuthPath("t-tenant", "c-other")
+	if err != nil {
+		return err
+	}
+	if a == b {
+		return fmt.Errorf("the auth path template %q must render a different path for each cluster", cfg.AuthPathTemplate)
+	}
+
+	accessConfig = c
+	return nil
+}
+
+// renderAuthPath returns the mount path of the Kubernetes auth method of the cluster
+func (c *clusterAccessConfig) renderAuthPath(tenant, cluster string) (string, error) {
+	var authPath strings.Builder
+	if err := c.authPath.Execute(&authPath, pathTemplateData{Tenant: tenant, Cluster: cluster}); err != nil {
+		return "", fmt.Errorf("render auth path template: %w", err)
+	}
+	return strings.Trim(authPath.String(), "/"), nil
+}
+
+// clusterAccess returns the policy and role of the cluster
+func (c *clusterAccessConfig) clusterAccess(tenant, cluster string) (ClusterAccess, error) {
+	authPath, err := c.renderAuthPath(tenant, cluster)
+	if err != nil {
+		return ClusterAccess{}, err
+	}
+	clusterPath, err := layout.clusterPath(tenant, cluster)
+	if err != nil {
+		return ClusterAccess{}, err
+	}
+	sharedPath, err := layout.sharedPath(tenant)
+	if err != nil {
+		return ClusterAccess{}, err
+	}
+	return ClusterAccess{
+		Name:                    "lieutenant-cluster-" + cluster,
+		Paths:                   []string{clusterPath, sharedPath},
+		AuthPath:                authPath,
+		ServiceAccountName:      c.serviceAccountName,
+		ServiceAccountNamespace: c.serviceAccountNamespace,
+	}, nil
+}
+
+// ReconcileClusterAccess provisions the Vault policy and the auth role of a cluster.
+// The result is reported in the condition VaultAccess.
+func ReconcileClusterAccess(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok || !data.UseVault || !accessConfig.enabled || data.Deleted || cluster.DecommissionRequested() {
+		return pipeline.Result{}
+	}
+
+	access, err := accessConfig.clusterAccess(cluster.GetTenantRef().Name, cluster.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+	vaultClient, err := getVaultClient(obj, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+	}
+	accessClient, ok := vaultClient.(AccessClient)
+	if !ok {
+		setVaultAccessCondition(cluster, metav1.ConditionFalse, "Unsupported", "The secret store doesn't support Vault policies")
+		return pipeline.Result{}
+	}
+
+	if err := accessClient.SetClusterAccess(access); err != nil {
+		setVaultAccessCondition(cluster, metav1.ConditionFalse, "Failed", err.Error())
+		return pipeline.Result{Err: fmt.Errorf("set vault access: %w", err)}
+	}
+	setVaultAccessCondition(cluster, metav1.ConditionTrue, "Provisioned",
+		fmt.Sprintf("Policy and role %s provisioned on auth/%s", access.Name, access.AuthPath))
+	return pipeline.Result{}
+}
+
+// RemoveClusterAccess removes the Vault policy and the auth role of a cluster.
+func RemoveClusterAccess(cluster *synv1alpha1.Cluster, data *pipeline.Context) error {
+	if !data.UseVault || !accessConfig.enabled {
+		return nil
+	}
+	access, err := accessConfig.clusterAccess(cluster.GetTenantRef().Name, cluster.GetName())
+	if err != nil {
+		return err
+	}
+	vaultClient, err := getVaultClient(cluster, data)
+	if err != nil {
+		return fmt.Errorf("get vault client: %w", err)
+	}
+	accessClient, ok := vaultClient.(AccessClient)
+	if !ok {
+		return nil
+	}
+	if err := accessClient.RemoveClusterAccess(access); err != nil {
+		return fmt.Errorf("remove vault access: %w", err)
+	}
+	if meta.FindStatusCondition(cluster.Status.Conditions, synv1alpha1.ConditionVaultAccess) != nil {
+		setVaultAccessCondition(cluster, metav1.ConditionFalse, "Removed", "Policy and role removed")
+	}
+	return nil
+}
+
+// HandleClusterAccessDeletion removes the Vault policy and the auth role of a deleted cluster.
+func HandleClusterAccessDeletion(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok || !data.Deleted {
+		return pipeline.Result{}
+	}
+	if err := RemoveClusterAccess(cluster, data); err != nil {
+		return pipeline.Result{Err: err}
+	}
+	return pipeline.Result{}
+}
+
+func setVaultAccessCondition(cluster *synv1alpha1.Cluster, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+		Type:               synv1alpha1.ConditionVaultAccess,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: cluster.Generation,
+	})
+}
+
+// vaultAccessWriter provisions policies and Kubernetes auth roles over a Vault connection.
+// The policy rules are rendered by the store, since they depend on the secret engine.
+type vaultAccessWriter struct {
+	logical     *api.Logical
+	policyRules func(secretPath string) string
+}
+
+func (w vaultAccessWriter) setClusterAccess(access ClusterAccess) error {
+	rules := make([]string, 0, len(access.Paths))
+	for _, p := range access.Paths {
+		rules = append(rules, w.policyRules(p))
+	}
+	policy := strings.Join(rules, "\n")
+
+	policyPath := path.Join("sys/policies/acl", access.Name)
+	current, err := w.logical.Read(policyPath)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Data["policy"] != policy {
+		if _, err := w.logical.Write(policyPath, map[string]interface{}{"policy": policy}); err != nil {
+			return fmt.Errorf("write policy: %w", err)
+		}
+	}
+
+	rolePath := path.Join("auth", access.AuthPath, "role", access.Name)
+	current, err = w.logical.Read(rolePath)
+	if err != nil {
+		return err
+	}
+	if current == nil ||
+		!stringListEquals(current.Data["bound_service_account_names"], access.ServiceAccountName) ||
+		!stringListEquals(current.Data["bound_service_account_namespaces"], access.ServiceAccountNamespace) ||
+		!stringListEquals(current.Data["token_policies"], access.Name) {
+		_, err := w.logical.Write(rolePath, map[string]interface{}{
+			"bound_service_account_names":      []string{access.ServiceAccountName},
+			"bound_service_account_namespaces": []string{access.ServiceAccountNamespace},
+			"token_policies":                   []string{access.Name},
+		})
+		if err != nil {
+			return fmt.Errorf("write role: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w vaultAccessWriter) removeClusterAccess(access ClusterAccess) error {
+	if _, err := w.logical.Delete(path.Join("auth", access.AuthPath, "role", access.Name)); err != nil {
+		return fmt.Errorf("delete role: %w", err)
+	}
+	if _, err := w.logical.Delete(path.Join("sys/policies/acl", access.Name)); err != nil {
+		return fmt.Errorf("delete policy: %w", err)
+	}
+	return nil
+}
+
+func stringListEquals(value interface{}, want ...string) bool {
+	list, ok := value.([]interface{})
+	if !ok || len(list) != len(want) {
+		return false
+	}
+	return slices.EqualFunc(list, want, func(a interface{}, b string) bool { return a == b })
+}
+
+// SetClusterAccess provisions the policy and the Kubernetes auth role of a cluster.
+func (b *BankVaultClient) SetClusterAccess(access ClusterAccess) error {
+	return b.accessWriter().setClusterAccess(access)
+}
+
+// RemoveClusterAccess removes the policy and the Kubernetes auth role of a cluster.
+func (b *BankVaultClient) RemoveClusterAccess(access ClusterAccess) error {
+	return b.accessWriter().removeClusterAccess(access)
+}
+
+func (b *BankVaultClient) accessWriter() vaultAccessWriter {
+	return vaultAccessWriter{
+		logical: b.client.RawClient().Logical(),
+		policyRules: func(secretPath string) string {
+			return fmt.Sprintf("path %q {\n  capabilities = [\"read\"]\n}\n\npath %q {\n  capabilities = [\"read\", \"list\"]\n}\n",
+				path.Join(b.secretEngine, "data", secretPath, "*"),
+				path.Join(b.secretEngine, "metadata", secretPath, "*"))
+		},
+	}
+}
+
+// SetClusterAccess provisions the policy and the Kubernetes auth role of a cluster.
+func (k *KVv1Client) SetClusterAccess(access ClusterAccess) error {
+	return k.accessWriter().setClusterAccess(access)
+}
+
+// RemoveClusterAccess removes the policy and the Kubernetes auth role of a cluster.
+func (k *KVv1Client) RemoveClusterAccess(access ClusterAccess) error {
+	return k.accessWriter().removeClusterAccess(access)
+}
+
+func (k *KVv1Client) accessWriter() vaultAccessWriter {
+	return vaultAccessWriter{
+		logical: k.client.RawClient().Logical(),
+		policyRules: func(secretPath string) string {
+			return fmt.Sprintf("path %q {\n  capabilities = [\"read\", \"list\"]\n}\n",
+				path.Join(k.secretEngine, secretPath, "*"))
+		},
+	}
+}
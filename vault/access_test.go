@@ -0,0 +1,213 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-logr/zapr"
+	"github.com/hashicorp/vault/api"
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/projectsyn/lieutenant-operator/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	uberzap "go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+type testAccessClient struct {
+	testMockClient
+	access  *ClusterAccess
+	removed bool
+}
+
+func (m *testAccessClient) WithDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) VaultClient {
+	m.deletionPolicy = deletionPolicy
+	return m
+}
+
+func (m *testAccessClient) SetClusterAccess(access ClusterAccess) error {
+	m.access = &access
+	return nil
+}
+
+func (m *testAccessClient) RemoveClusterAccess(access ClusterAccess) error {
+	m.access = nil
+	m.removed = true
+	return nil
+}
+
+func Test_reconcileClusterAccess(t *testing.T) {
+	mockClient := &testAccessClient{}
+	SetCustomClient(mockClient)
+	require.NoError(t, ConfigureClusterAccess(ClusterAccessConfig{
+		Enabled:          true,
+		AuthPathTemplate: "kubernetes-{{.Cluster}}",
+		ServiceAccount:   "syn/steward",
+	}))
+	defer func() { accessConfig = &clusterAccessConfig{} }()
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Spec.TenantRef.Name = "t-tenant"
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, ReconcileClusterAccess(cluster, data).Err)
+	assert.Equal(t, &ClusterAccess{
+		Name:                    "lieutenant-cluster-c-cluster",
+		Paths:                   []string{"t-tenant/c-cluster", "t-tenant/_shared"},
+		AuthPath:                "kubernetes-c-cluster",
+		ServiceAccountName:      "steward",
+		ServiceAccountNamespace: "syn",
+	}, mockClient.access)
+	assert.True(t, meta.IsStatusConditionTrue(cluster.Status.Conditions, synv1alpha1.ConditionVaultAccess))
+
+	data.Deleted = true
+	require.NoError(t, HandleClusterAccessDeletion(cluster, data).Err)
+	assert.True(t, mockClient.removed)
+	assert.True(t, meta.IsStatusConditionFalse(cluster.Status.Conditions, synv1alpha1.ConditionVaultAccess))
+
+	SetCustomClient(&testMockClient{})
+	data.Deleted = false
+	require.NoError(t, ReconcileClusterAccess(cluster, data).Err)
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, synv1alpha1.ConditionVaultAccess)
+	require.NotNil(t, cond)
+	assert.Equal(t, "Unsupported", cond.Reason)
+}
+
+func TestConfigureClusterAccess(t *testing.T) {
+	tests := map[string]struct {
+		authPath string
+
+		wantAuthPaths []string
+		wantErr       bool
+	}{
+		"default": {
+			wantAuthPaths: []string{"c-cluster", "c-other"},
+		},
+		"prefixed": {
+			authPath:      "/kubernetes-{{.Cluster}}/",
+			wantAuthPaths: []string{"kubernetes-c-cluster", "kubernetes-c-other"},
+		},
+		"shared mount": {
+			authPath: "kubernetes",
+			wantErr:  true,
+		},
+		"mount per tenant": {
+			authPath: "{{.Tenant}}",
+			wantErr:  true,
+		},
+		"unknown field": {
+			authPath: "{{.Name}}",
+			wantErr:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() { accessConfig = &clusterAccessConfig{} }()
+			err := ConfigureClusterAccess(ClusterAccessConfig{
+				Enabled:          true,
+				AuthPathTemplate: tc.authPath,
+				ServiceAccount:   "syn/steward",
+			})
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			authPaths := []string{}
+			for _, cluster := range []string{"c-cluster", "c-other"} {
+				access, err := accessConfig.clusterAccess("t-tenant", cluster)
+				require.NoError(t, err)
+				authPaths = append(authPaths, access.AuthPath)
+			}
+			assert.Equal(t, tc.wantAuthPaths, authPaths)
+		})
+	}
+}
+
+func TestBankVaultClient_SetClusterAccess(t *testing.T) {
+	zapLog, err := uberzap.NewDevelopment()
+	require.NoError(t, err)
+
+	written := map[string]map[string]interface{}{}
+	deleted := []string{}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			body := map[string]interface{}{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			written[r.URL.Path] = body
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/policies/acl/lieutenant-cluster-c-cluster", handler)
+	mux.HandleFunc("/v1/auth/kubernetes/role/lieutenant-cluster-c-cluster", handler)
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+	require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+	require.NoError(t, os.Setenv("VAULT_SECRET_ENGINE_PATH", ""))
+
+	b, err := newBankVaultClient(context.Background(), synv1alpha1.ArchivePolicy, zapr.NewLogger(zapLog))
+	require.NoError(t, err)
+
+	access := ClusterAccess{
+		Name:                    "lieutenant-cluster-c-cluster",
+		Paths:                   []string{"t-tenant/c-cluster", "t-tenant/_shared"},
+		AuthPath:                "kubernetes",
+		ServiceAccountName:      "steward",
+		ServiceAccountNamespace: "syn",
+	}
+	require.NoError(t, b.SetClusterAccess(access))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"/v1/sys/policies/acl/lieutenant-cluster-c-cluster": {
+			"policy": `path "kv/data/t-tenant/c-cluster/*" {
+  capabilities = ["read"]
+}
+
+path "kv/metadata/t-tenant/c-cluster/*" {
+  capabilities = ["read", "list"]
+}
+
+path "kv/data/t-tenant/_shared/*" {
+  capabilities = ["read"]
+}
+
+path "kv/metadata/t-tenant/_shared/*" {
+  capabilities = ["read", "list"]
+}
+`,
+		},
+		"/v1/auth/kubernetes/role/lieutenant-cluster-c-cluster": {
+			"bound_service_account_names":      []interface{}{"steward"},
+			"bound_service_account_namespaces": []interface{}{"syn"},
+			"token_policies":                   []interface{}{"lieutenant-cluster-c-cluster"},
+		},
+	}, written)
+
+	require.NoError(t, b.RemoveClusterAccess(access))
+	assert.Equal(t, []string{
+		"/v1/auth/kubernetes/role/lieutenant-cluster-c-cluster",
+		"/v1/sys/policies/acl/lieutenant-cluster-c-cluster",
+	}, deleted)
+}
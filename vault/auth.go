@@ -0,0 +1,234 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// AuthMethod selects how the operator authenticates against Vault
+type AuthMethod string
+
+const (
+	// TokenAuth uses the token in VAULT_TOKEN
+	TokenAuth AuthMethod = "token"
+	// KubernetesAuth logs in with the token of the service account of the operator
+	KubernetesAuth AuthMethod = "kubernetes"
+	// JWTAuth logs in with a JWT, for example a projected service account token, using the JWT/OIDC auth method
+	JWTAuth AuthMethod = "jwt"
+	// AppRoleAuth logs in with a role ID and a secret ID
+	AppRoleAuth AuthMethod = "approle"
+	// CertAuth logs in with a TLS client certificate
+	CertAuth AuthMethod = "cert"
+)
+
+// DefaultAuthRole is the role the operator logs in with if no role is configured
+const DefaultAuthRole = "lieutenant-operator"
+
+// loginBackoff is the delay between failed login attempts. It doubles up to five minutes.
+var loginBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    math.MaxInt32,
+	Cap:      5 * time.Minute,
+}
+
+// AuthConfig configures how the operator authenticates against Vault
+type AuthConfig struct {
+	// Method is the auth method.
+	// If it's empty, the token in VAULT_TOKEN is used if it's set or if the operator doesn't run in a pod.
+	// Otherwise the operator logs in with the Kubernetes auth method.
+	Method AuthMethod
+	// Path is the mount path of the auth method, without the `auth/` prefix.
+	// It defaults to the name of the method.
+	Path string
+	// Role is the role the operator logs in with. It's ignored by the AppRole auth method.
+	Role string
+	// JWTFile is the file containing the JWT for the Kubernetes and the JWT auth methods.
+	// It's read on each login, so it can be rotated.
+	JWTFile string
+	// RoleID is the role ID for the AppRole auth method
+	RoleID string
+	// SecretIDFile is the file containing the secret ID for the AppRole auth method.
+	// It's read on each login, so it can be rotated.
+	SecretIDFile string
+	// ClientCert and ClientKey are the files of the TLS client certificate and its key for the cert auth method
+	ClientCert string
+	ClientKey  string
+}
+
+var authConfig = AuthConfig{}
+
+// ConfigureAuth configures how the operator authenticates against Vault.
+func ConfigureAuth(cfg AuthConfig) error {
+	switch cfg.Method {
+	case "", TokenAuth, KubernetesAuth, JWTAuth:
+	case AppRoleAuth:
+		if cfg.RoleID == "" || cfg.SecretIDFile == "" {
+			return fmt.Errorf("the approle auth method requires a role ID and a secret ID file")
+		}
+	case CertAuth:
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return fmt.Errorf("the cert auth method requires a client certificate and a key")
+		}
+	default:
+		return fmt.Errorf("unknown auth method %q", cfg.Method)
+	}
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	authConfig = cfg
+	setInstance(nil, nil)
+	return nil
+}
+
+// method returns the configured auth method or detects it
+func (cfg AuthConfig) method() AuthMethod {
+	if cfg.Method != "" {
+		return cfg.Method
+	}
+	if os.Getenv(api.EnvVaultToken) != "" {
+		return TokenAuth
+	}
+	if _, err := os.Stat(k8sTokenPath); os.IsNotExist(err) {
+		return TokenAuth
+	}
+	return KubernetesAuth
+}
+
+// loginData returns the data of a login request.
+// Files are read on each call, since they might have been rotated.
+func (cfg AuthConfig) loginData() (map[string]interface{}, error) {
+	role := cfg.Role
+	if role == "" {
+		role = DefaultAuthRole
+	}
+
+	switch cfg.method() {
+	case KubernetesAuth, JWTAuth:
+		jwtFile := cfg.JWTFile
+		if jwtFile == "" {
+			jwtFile = k8sTokenPath
+		}
+		jwt, err := os.ReadFile(jwtFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWT: %w", err)
+		}
+		return map[string]interface{}{"jwt": strings.TrimSpace(string(jwt)), "role": role}, nil
+	case AppRoleAuth:
+		secretID, err := os.ReadFile(cfg.SecretIDFile)
+		if err != nil {
+			return nil, fmt.Errorf("read secret ID: %w", err)
+		}
+		return map[string]interface{}{"role_id": cfg.RoleID, "secret_id": strings.TrimSpace(string(secretID))}, nil
+	case CertAuth:
+		return map[string]interface{}{"name": role}, nil
+	default:
+		return nil, fmt.Errorf("auth method %q doesn't support logging in", cfg.method())
+	}
+}
+
+// loginPath returns the path of the login endpoint of the auth method
+func (cfg AuthConfig) loginPath() string {
+	authPath := strings.Trim(cfg.Path, "/")
+	if authPath == "" {
+		authPath = string(cfg.method())
+	}
+	return path.Join("auth", authPath, "login")
+}
+
+// tlsConfig returns the TLS configuration of the connection to Vault, if the auth method requires one
+func (cfg AuthConfig) tlsConfig() *api.TLSConfig {
+	if cfg.method() != CertAuth {
+		return nil
+	}
+	return &api.TLSConfig{ClientCert: cfg.ClientCert, ClientKey: cfg.ClientKey}
+}
+
+// authenticator logs in to Vault, renews the token and logs in again once the token can't be renewed anymore
+type authenticator struct {
+	client *api.Client
+	config AuthConfig
+	log    logr.Logger
+	// backoff is the delay between failed login attempts
+	backoff wait.Backoff
+}
+
+// login logs in and sets the token on the client
+func (a *authenticator) login() (*api.Secret, error) {
+	data, err := a.config.loginData()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := a.client.Logical().Write(a.config.loginPath(), data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Auth == nil {
+		return nil, fmt.Errorf("login response of %s contains no token", a.config.loginPath())
+	}
+	a.client.SetToken(secret.Auth.ClientToken)
+	a.log.Info("logged in to vault", "path", a.config.loginPath())
+	return secret, nil
+}
+
+// run keeps the token valid until the context is cancelled
+func (a *authenticator) run(ctx context.Context, secret *api.Secret) {
+	for {
+		a.watch(ctx, secret)
+
+		backoff := a.backoff
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			s, err := a.login()
+			if err == nil {
+				secret = s
+				break
+			}
+			delay := backoff.Step()
+			a.log.Error(err, "failed to log in to vault, retrying", "in", delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// watch renews the token until it can't be renewed anymore or the context is cancelled
+func (a *authenticator) watch(ctx context.Context, secret *api.Secret) {
+	watcher, err := a.client.NewLifetimeWatcher(&api.LifetimeWatcherInput{Secret: secret})
+	if err != nil {
+		a.log.Error(err, "failed to watch vault token")
+		return
+	}
+	go watcher.Start()
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-watcher.DoneCh():
+			if err != nil {
+				a.log.Error(err, "failed to renew vault token")
+			}
+			a.log.Info("vault token expires, logging in again")
+			return
+		case renewal := <-watcher.RenewCh():
+			ttl, _ := renewal.Secret.TokenTTL()
+			a.log.V(1).Info("renewed vault token", "ttl", ttl)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func TestConfigureAuth(t *testing.T) {
+	defer func() { authConfig = AuthConfig{} }()
+
+	assert.NoError(t, ConfigureAuth(AuthConfig{}))
+	assert.NoError(t, ConfigureAuth(AuthConfig{Method: JWTAuth}))
+	assert.NoError(t, ConfigureAuth(AuthConfig{Method: AppRoleAuth, RoleID: "id", SecretIDFile: "/secret-id"}))
+	assert.Error(t, ConfigureAuth(AuthConfig{Method: AppRoleAuth, RoleID: "id"}))
+	assert.NoError(t, ConfigureAuth(AuthConfig{Method: CertAuth, ClientCert: "/tls.crt", ClientKey: "/tls.key"}))
+	assert.Error(t, ConfigureAuth(AuthConfig{Method: CertAuth, ClientCert: "/tls.crt"}))
+	assert.Error(t, ConfigureAuth(AuthConfig{Method: "ldap"}))
+}
+
+func TestAuthConfig_loginData(t *testing.T) {
+	dir := t.TempDir()
+	jwtFile := filepath.Join(dir, "jwt")
+	require.NoError(t, os.WriteFile(jwtFile, []byte("my-jwt\n"), 0600))
+	secretIDFile := filepath.Join(dir, "secret-id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("my-secret-id"), 0600))
+
+	tests := map[string]struct {
+		config    AuthConfig
+		path      string
+		data      map[string]interface{}
+		wantError bool
+	}{
+		"kubernetes": {
+			config: AuthConfig{Method: KubernetesAuth, JWTFile: jwtFile},
+			path:   "auth/kubernetes/login",
+			data:   map[string]interface{}{"jwt": "my-jwt", "role": DefaultAuthRole},
+		},
+		"jwt with custom path and role": {
+			config: AuthConfig{Method: JWTAuth, Path: "/oidc/", Role: "operator", JWTFile: jwtFile},
+			path:   "auth/oidc/login",
+			data:   map[string]interface{}{"jwt": "my-jwt", "role": "operator"},
+		},
+		"jwt with missing file": {
+			config:    AuthConfig{Method: JWTAuth, JWTFile: filepath.Join(dir, "missing")},
+			path:      "auth/jwt/login",
+			wantError: true,
+		},
+		"approle": {
+			config: AuthConfig{Method: AppRoleAuth, RoleID: "my-role-id", SecretIDFile: secretIDFile},
+			path:   "auth/approle/login",
+			data:   map[string]interface{}{"role_id": "my-role-id", "secret_id": "my-secret-id"},
+		},
+		"cert": {
+			config: AuthConfig{Method: CertAuth, Role: "operator"},
+			path:   "auth/cert/login",
+			data:   map[string]interface{}{"name": "operator"},
+		},
+		"token": {
+			config:    AuthConfig{Method: TokenAuth},
+			path:      "auth/token/login",
+			wantError: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.path, tt.config.loginPath())
+			data, err := tt.config.loginData()
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.data, data)
+		})
+	}
+}
+
+func TestAuthenticator_relogin(t *testing.T) {
+	dir := t.TempDir()
+	secretIDFile := filepath.Join(dir, "secret-id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("my-secret-id"), 0600))
+
+	var mu sync.Mutex
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/auth/approle/login" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "my-secret-id", body["secret_id"])
+
+		mu.Lock()
+		logins++
+		n := logins
+		mu.Unlock()
+		// The first attempts to log in again fail, which forces a retry
+		if n == 2 || n == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// The token isn't renewable and expires immediately, which forces a new login
+		_, _ = fmt.Fprintf(w, `{"auth":{"client_token":"token-%d","renewable":false,"lease_duration":1}}`, n)
+	}))
+	defer server.Close()
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+	auth := &authenticator{
+		client:  client,
+		config:  AuthConfig{Method: AppRoleAuth, RoleID: "my-role-id", SecretIDFile: secretIDFile},
+		log:     zap.New(),
+		backoff: wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 5},
+	}
+
+	secret, err := auth.login()
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", client.Token())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		auth.run(ctx, secret)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		return client.Token() == "token-4"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run didn't stop after the context was cancelled")
+	}
+}
@@ -1,17 +1,20 @@
 package vault
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/banzaicloud/bank-vaults/pkg/sdk/vault"
 	"github.com/go-logr/logr"
 	"github.com/hashicorp/vault/api"
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
@@ -22,19 +25,39 @@ const (
 var (
 	// we're keeping a global client
 	instanceClient VaultClient
+	// instanceCancel stops keeping the token of the global client valid
+	instanceCancel context.CancelFunc
+	instanceMutex  sync.Mutex
 )
 
 // TODO: similar map like the template files
 type VaultSecret struct {
 	Path  string
 	Value string
+	// Key is the key of the value within the secret, it defaults to `token`
+	Key string
+	// Metadata is stored as custom metadata of the secret, if the store supports it
+	Metadata map[string]string
+}
+
+func (s VaultSecret) key() string {
+	if s.Key == "" {
+		return tokenName
+	}
+	return s.Key
 }
 
 type VaultClient interface {
 	AddSecrets(secrets []VaultSecret) error
 	// remove specific secret
 	RemoveSecrets(secret []VaultSecret) error
-	SetDeletionPolicy(synv1alpha1.DeletionPolicy)
+	// move all secrets below a path to another path
+	MoveSecrets(from, to string) error
+	// check whether a secret exists at the path
+	HasSecret(path string) (bool, error)
+	// WithDeletionPolicy returns a copy of the client which removes secrets according to the deletion policy.
+	// The copy shares the connection of the client.
+	WithDeletionPolicy(synv1alpha1.DeletionPolicy) VaultClient
 }
 
 type BankVaultClient struct {
@@ -44,51 +67,106 @@ type BankVaultClient struct {
 	log            logr.Logger
 }
 
-// NewClient returns the default VaultClient implementation, ready to be used.
-// It automatically detects, if there was a Vault token provided or if it's
-// running withing kubernetes.
-func NewClient(deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (VaultClient, error) {
+// StoreType selects the implementation of the VaultClient returned by NewClient
+type StoreType string
+
+const (
+	// KVv2Store stores the secrets in a KV version 2 secret engine of HashiCorp Vault or OpenBao
+	KVv2Store StoreType = "kv-v2"
+	// KVv1Store stores the secrets in a KV version 1 secret engine of HashiCorp Vault or OpenBao
+	KVv1Store StoreType = "kv-v1"
+	// KubernetesStore stores the secrets in Kubernetes Secrets
+	KubernetesStore StoreType = "kubernetes"
+)
+
+// StoreConfig configures the secret store returned by NewClient
+type StoreConfig struct {
+	Type StoreType
+	// Client is the Kubernetes client used by the Kubernetes store
+	Client client.Client
+	// Namespace is the namespace the Kubernetes store keeps the secrets in
+	Namespace string
+}
 
-	if instanceClient != nil {
-		instanceClient.SetDeletionPolicy(deletionPolicy)
-		return instanceClient, nil
+var storeConfig = StoreConfig{Type: KVv2Store}
+
+// ConfigureStore selects the secret store returned by NewClient.
+// The KV version 2 store is used if no store is configured.
+func ConfigureStore(cfg StoreConfig) error {
+	switch cfg.Type {
+	case "":
+		cfg.Type = KVv2Store
+	case KVv2Store, KVv1Store:
+	case KubernetesStore:
+		if cfg.Client == nil || cfg.Namespace == "" {
+			return fmt.Errorf("the kubernetes secret store requires a client and a namespace")
+		}
+	default:
+		return fmt.Errorf("unknown secret store %q", cfg.Type)
 	}
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	storeConfig = cfg
+	setInstance(nil, nil)
+	return nil
+}
 
-	c, err := newBankVaultClient(deletionPolicy, log)
-	if err != nil {
-		return nil, err
+// NewClient returns the VaultClient of the configured secret store, ready to be used.
+// For the Vault stores it automatically detects, if there was a Vault token provided or if it's
+// running withing kubernetes.
+// The returned client removes secrets according to the deletion policy, it's safe to use concurrently
+// with clients returned for other deletion policies.
+func NewClient(deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (VaultClient, error) {
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+
+	if instanceClient == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		c, err := newStore(ctx, storeConfig, deletionPolicy, log)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		setInstance(c, cancel)
 	}
-	instanceClient = c
 
-	return instanceClient, nil
+	return instanceClient.WithDeletionPolicy(deletionPolicy), nil
 }
 
 // SetCustomClient is used if a custom client needs to be used. Currently only
 // used for testing.
 func SetCustomClient(c VaultClient) {
-	instanceClient = c
+	instanceMutex.Lock()
+	defer instanceMutex.Unlock()
+	setInstance(c, nil)
 }
 
-func newBankVaultClient(deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (*BankVaultClient, error) {
-
-	client, err := vault.NewClientFromConfig(
-		&api.Config{Address: os.Getenv(api.EnvVaultAddress)},
-		vault.ClientAuthPath(os.Getenv("VAULT_AUTH_PATH")), // Will be set to "kubernetes" if empty string
-		vault.ClientRole("lieutenant-operator"),
-		vault.ClientLogger(logAdapter{log.WithName("vaultclient")}),
-	)
-	if err != nil {
-		return nil, err
+// setInstance replaces the global client and stops the previous one.
+// The caller must hold instanceMutex.
+func setInstance(c VaultClient, cancel context.CancelFunc) {
+	if instanceCancel != nil {
+		instanceCancel()
 	}
+	instanceClient = c
+	instanceCancel = cancel
+}
 
-	// if we're not in a k8s pod we'll use provided TOKEN env var
-	if _, err = os.Stat(k8sTokenPath); os.IsNotExist(err) {
-		client.RawClient().SetToken(os.Getenv(api.EnvVaultToken))
+// newStore returns the client of the secret store. The connection to Vault is kept alive until the context is cancelled.
+func newStore(ctx context.Context, cfg StoreConfig, deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (VaultClient, error) {
+	switch cfg.Type {
+	case KVv1Store:
+		return newKVv1Client(ctx, deletionPolicy, log)
+	case KubernetesStore:
+		return newKubernetesSecretStore(cfg.Client, cfg.Namespace, deletionPolicy, log), nil
+	default:
+		return newBankVaultClient(ctx, deletionPolicy, log)
 	}
+}
 
-	secretEngine := os.Getenv("VAULT_SECRET_ENGINE_PATH")
-	if secretEngine == "" {
-		secretEngine = "kv"
+func newBankVaultClient(ctx context.Context, deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (*BankVaultClient, error) {
+	client, secretEngine, err := newVaultConnection(ctx, log)
+	if err != nil {
+		return nil, err
 	}
 
 	return &BankVaultClient{
@@ -100,9 +178,62 @@ func newBankVaultClient(deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logg
 
 }
 
+// newVaultConnection connects to Vault and returns the client and the mount path of the secret engine.
+// Unless a token is used, it logs in with the configured auth method and keeps the token valid until the context is cancelled.
+func newVaultConnection(ctx context.Context, log logr.Logger) (*vault.Client, string, error) {
+
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return nil, "", config.Error
+	}
+	if tlsConfig := authConfig.tlsConfig(); tlsConfig != nil {
+		if err := config.ConfigureTLS(tlsConfig); err != nil {
+			return nil, "", err
+		}
+	}
+	rawClient, err := api.NewClient(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if authConfig.method() == TokenAuth {
+		if os.Getenv(api.EnvVaultToken) == "" {
+			return nil, "", fmt.Errorf("%s isn't set", api.EnvVaultToken)
+		}
+		rawClient.SetToken(os.Getenv(api.EnvVaultToken))
+	} else {
+		auth := &authenticator{
+			client:  rawClient,
+			config:  authConfig,
+			log:     log.WithName("vaultauth"),
+			backoff: loginBackoff,
+		}
+		secret, err := auth.login()
+		if err != nil {
+			return nil, "", fmt.Errorf("log in to vault: %w", err)
+		}
+		go auth.run(ctx, secret)
+	}
+
+	client, err := vault.NewClientFromRawClient(rawClient,
+		vault.ClientToken(rawClient.Token()),
+		vault.ClientLogger(logAdapter{log.WithName("vaultclient")}),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secretEngine := os.Getenv("VAULT_SECRET_ENGINE_PATH")
+	if secretEngine == "" {
+		secretEngine = "kv"
+	}
+
+	return client, secretEngine, nil
+}
+
 func (b *BankVaultClient) AddSecrets(secrets []VaultSecret) error {
 	for _, secret := range secrets {
-		err := b.addSecret(secret.Path, secret.Value)
+		err := b.addSecret(secret)
 		if err != nil {
 			return err
 		}
@@ -113,7 +244,8 @@ func (b *BankVaultClient) AddSecrets(secrets []VaultSecret) error {
 // addSecret saves the token in Vault, the path should have the form
 // tenant/cluster to work properly. It will check if the token exists and
 // re-apply it if not.
-func (b *BankVaultClient) addSecret(secretPath, token string) error {
+func (b *BankVaultClient) addSecret(vs VaultSecret) error {
+	secretPath, token, key := vs.Path, vs.Value, vs.key()
 
 	queryPath := path.Join(b.secretEngine, "data", secretPath)
 
@@ -126,10 +258,12 @@ func (b *BankVaultClient) addSecret(secretPath, token string) error {
 		b.log.WithName("vault").Info("does not yet exist, creating", "name", secretPath)
 		secret = &api.Secret{}
 		secret.Data = vault.NewData(0, map[string]interface{}{
-			tokenName: token,
+			key: token,
 		})
-		_, err = b.client.RawClient().Logical().Write(queryPath, secret.Data)
-		return err
+		if _, err = b.client.RawClient().Logical().Write(queryPath, secret.Data); err != nil {
+			return err
+		}
+		return b.setCustomMetadata(secretPath, vs.Metadata)
 	}
 
 	secretData, ok := secret.Data["data"].(map[string]interface{})
@@ -138,20 +272,58 @@ func (b *BankVaultClient) addSecret(secretPath, token string) error {
 		secretData = make(map[string]interface{})
 	}
 
-	if !ok || secretData[tokenName] != token {
+	if !ok || secretData[key] != token {
 
 		b.log.WithName("vault").Info("secrets don't match, re-applying")
 
-		secretData[tokenName] = token
+		secretData[key] = token
 
 		secret.Data["data"] = secretData
 
-		_, err = b.client.RawClient().Logical().Write(queryPath, secret.Data)
+		if _, err = b.client.RawClient().Logical().Write(queryPath, secret.Data); err != nil {
+			return err
+		}
+	}
+
+	return b.setCustomMetadata(secretPath, vs.Metadata)
+}
+
+// setCustomMetadata tags the secret with the custom metadata, if it differs from the current custom metadata.
+func (b *BankVaultClient) setCustomMetadata(secretPath string, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return nil
 	}
+	metadataPath := path.Join(b.secretEngine, "metadata", secretPath)
 
+	current, err := b.client.RawClient().Logical().Read(metadataPath)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		existing, _ := current.Data["custom_metadata"].(map[string]interface{})
+		if customMetadataEqual(existing, metadata) {
+			return nil
+		}
+	}
+
+	_, err = b.client.RawClient().Logical().Write(metadataPath, map[string]interface{}{
+		"custom_metadata": metadata,
+	})
 	return err
 }
 
+func customMetadataEqual(existing map[string]interface{}, metadata map[string]string) bool {
+	if len(existing) != len(metadata) {
+		return false
+	}
+	for k, v := range metadata {
+		if existing[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 // RemoveSecrets will remove all the keys bellow the given paths. It will list
 // all secrets of in the path and delete them according to the deletion policy.
 func (b *BankVaultClient) RemoveSecrets(secrets []VaultSecret) error {
@@ -164,61 +336,143 @@ func (b *BankVaultClient) RemoveSecrets(secrets []VaultSecret) error {
 	return nil
 }
 
-func isDirectory(path string) bool {
-	return strings.HasSuffix(path, "/")
+// MoveSecrets copies the latest version of all secrets below the path from to the path to.
+// The secrets below from are then soft deleted, or destroyed under the Delete policy.
+// They're never retained, since a move mustn't leave a live copy behind.
+func (b *BankVaultClient) MoveSecrets(from, to string) error {
+	if err := validateMove(from, to); err != nil {
+		return err
+	}
+	if err := b.copySecrets(from, to); err != nil {
+		return err
+	}
+	source := *b
+	if source.deletionPolicy != synv1alpha1.DeletePolicy {
+		source.deletionPolicy = synv1alpha1.ArchivePolicy
+	}
+	return source.removeSecret(VaultSecret{Path: from})
 }
 
-// removeSecret will remove the token according to the DeletetionPolicy
-func (b *BankVaultClient) removeSecret(removeSecret VaultSecret) error {
+// validateMove returns an error if the secrets below the path from can't be moved to the path to.
+// Moving secrets onto themselves or between nested paths would remove the moved secrets.
+func validateMove(from, to string) error {
+	from, to = path.Clean(strings.Trim(from, "/")), path.Clean(strings.Trim(to, "/"))
+	if from == to {
+		return fmt.Errorf("can't move secrets from '%s' onto themselves", from)
+	}
+	if strings.HasPrefix(to, from+"/") || strings.HasPrefix(from, to+"/") {
+		return fmt.Errorf("can't move secrets between the nested paths '%s' and '%s'", from, to)
+	}
+	return nil
+}
 
-	secrets, err := b.listSecrets(removeSecret.Path)
+func (b *BankVaultClient) copySecrets(from, to string) error {
+	secrets, err := b.listSecrets(from)
 	if err != nil {
 		return err
 	}
 
 	for _, secret := range secrets {
 		if isDirectory(secret) {
-			err := b.removeSecret(VaultSecret{
-				Path: path.Join(removeSecret.Path, secret),
-			})
-			if err != nil {
+			if err := b.copySecrets(path.Join(from, secret), path.Join(to, secret)); err != nil {
 				return err
 			}
 			continue
 		}
-		sPath := path.Join(b.secretEngine, "metadata", removeSecret.Path, secret)
 
-		s, err := b.client.RawClient().Logical().Read(sPath)
+		s, err := b.client.RawClient().Logical().Read(path.Join(b.secretEngine, "data", from, secret))
 		if err != nil {
 			return err
 		}
+		if s == nil || s.Data["data"] == nil {
+			// The latest version of the secret was deleted
+			continue
+		}
 
-		versions, err := b.getVersionList(s.Data)
+		b.log.WithName("vault").Info("moving secret", "from", path.Join(from, secret), "to", path.Join(to, secret))
+		_, err = b.client.RawClient().Logical().Write(path.Join(b.secretEngine, "data", to, secret), map[string]interface{}{
+			"data": s.Data["data"],
+		})
 		if err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		switch b.deletionPolicy {
-		case synv1alpha1.ArchivePolicy:
-			b.log.Info("soft deleting secret", "secret", removeSecret.Path)
-			err := b.deleteToken(path.Join(b.secretEngine, "delete", removeSecret.Path, secret), versions)
-			if err != nil {
-				return err
-			}
-		case synv1alpha1.DeletePolicy:
-			b.log.Info("destroying secret", "secret", removeSecret.Path)
-			err := b.destroyToken(path.Join(b.secretEngine, "metadata", removeSecret.Path, secret), versions)
+// HasSecret returns whether the latest version of the secret at the path exists.
+func (b *BankVaultClient) HasSecret(secretPath string) (bool, error) {
+	s, err := b.client.RawClient().Logical().Read(path.Join(b.secretEngine, "data", secretPath))
+	if err != nil {
+		return false, err
+	}
+	return s != nil && s.Data["data"] != nil, nil
+}
+
+func isDirectory(path string) bool {
+	return strings.HasSuffix(path, "/")
+}
+
+// removeSecret will remove the token according to the DeletetionPolicy
+func (b *BankVaultClient) removeSecret(removeSecret VaultSecret) error {
+
+	secrets, err := b.listSecrets(removeSecret.Path)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		// The path might be a secret itself
+		return b.removeLeaf(removeSecret.Path)
+	}
+
+	for _, secret := range secrets {
+		if isDirectory(secret) {
+			err := b.removeSecret(VaultSecret{
+				Path: path.Join(removeSecret.Path, secret),
+			})
 			if err != nil {
 				return err
 			}
-		default:
-			return fmt.Errorf("unknown DeletionPolicy, skipping")
+			continue
+		}
+		if err := b.removeLeaf(path.Join(removeSecret.Path, secret)); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// removeLeaf removes all versions of a single secret according to the DeletionPolicy
+func (b *BankVaultClient) removeLeaf(secretPath string) error {
+	s, err := b.client.RawClient().Logical().Read(path.Join(b.secretEngine, "metadata", secretPath))
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+
+	versions, err := b.getVersionList(s.Data)
+	if err != nil {
+		return err
+	}
+
+	switch b.deletionPolicy {
+	case synv1alpha1.ArchivePolicy:
+		b.log.Info("soft deleting secret", "secret", secretPath)
+		return b.deleteToken(path.Join(b.secretEngine, "delete", secretPath), versions)
+	case synv1alpha1.DeletePolicy:
+		b.log.Info("destroying secret", "secret", secretPath)
+		return b.destroyToken(path.Join(b.secretEngine, "metadata", secretPath), versions)
+	case synv1alpha1.RetainPolicy:
+		b.log.Info("retaining secret", "secret", secretPath)
+		return nil
+	default:
+		return fmt.Errorf("unknown DeletionPolicy, skipping")
+	}
+}
+
 func (b *BankVaultClient) getVersionList(data map[string]interface{}) (map[string]interface{}, error) {
 
 	versionlist := make([]int, 0)
@@ -262,6 +516,10 @@ func (b *BankVaultClient) listSecrets(secretPath string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	if secrets == nil {
+		// There are no secrets below the path
+		return []string{}, nil
+	}
 	data, ok := secrets.Data["keys"].([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("list of secrets can't be decoded")
@@ -280,6 +538,8 @@ func (b *BankVaultClient) listSecrets(secretPath string) ([]string, error) {
 
 }
 
-func (b *BankVaultClient) SetDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) {
-	b.deletionPolicy = deletionPolicy
+func (b *BankVaultClient) WithDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) VaultClient {
+	c := *b
+	c.deletionPolicy = deletionPolicy
+	return &c
 }
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/testr"
 	"github.com/go-logr/zapr"
 	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/assert"
@@ -222,6 +224,15 @@ func TestBankVaultClient_RemoveSecrets(t *testing.T) {
 				log:     zapr.NewLogger(zapLog),
 			},
 		},
+		{
+			name:    "retaining",
+			wantErr: false,
+			args: args{
+				secrets: []VaultSecret{{Path: "kv2/test", Value: ""}},
+				policy:  synv1alpha1.RetainPolicy,
+				log:     zapr.NewLogger(zapLog),
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -245,6 +256,99 @@ func TestBankVaultClient_RemoveSecrets(t *testing.T) {
 	}
 }
 
+func TestBankVaultClient_MoveSecrets(t *testing.T) {
+	zapLog, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	written := map[string]string{}
+	deleted := []string{}
+	versionBody := `{"data": {"versions": {"1": {"destroyed": false}}}}`
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/metadata/old/c-cluster", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"keys": ["steward", "custom/"]}}`)
+	})
+	mux.HandleFunc("/v1/kv/metadata/old/c-cluster/custom", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"keys": ["password"]}}`)
+	})
+	for _, secret := range []string{"steward", "custom/password"} {
+		mux.HandleFunc("/v1/kv/data/old/c-cluster/"+secret, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"data": {"data": {"token": %q}}}`, secret)
+		})
+		mux.HandleFunc("/v1/kv/data/new/c-cluster/"+secret, func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			written[r.URL.Path] = string(body)
+			w.WriteHeader(http.StatusNoContent)
+		})
+		mux.HandleFunc("/v1/kv/metadata/old/c-cluster/"+secret, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete {
+				deleted = append(deleted, r.URL.Path)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			_, _ = io.WriteString(w, versionBody)
+		})
+	}
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+	require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+
+	b, err := newBankVaultClient(context.Background(), synv1alpha1.DeletePolicy, zapr.NewLogger(zapLog))
+	require.NoError(t, err)
+
+	require.NoError(t, b.MoveSecrets("old/c-cluster", "new/c-cluster"))
+	assert.Equal(t, map[string]string{
+		"/v1/kv/data/new/c-cluster/steward":         `{"data":{"token":"steward"}}`,
+		"/v1/kv/data/new/c-cluster/custom/password": `{"data":{"token":"custom/password"}}`,
+	}, written)
+	assert.ElementsMatch(t, []string{
+		"/v1/kv/metadata/old/c-cluster/steward",
+		"/v1/kv/metadata/old/c-cluster/custom/password",
+	}, deleted)
+
+	assert.Error(t, b.MoveSecrets("old/c-cluster", "old/c-cluster"), "should refuse to move secrets onto themselves")
+	assert.Error(t, b.MoveSecrets("old", "old/c-cluster"), "should refuse to move secrets into their own subtree")
+	assert.Len(t, deleted, 2, "should not remove secrets of a refused move")
+}
+
+func TestBankVaultClient_MoveSecrets_retaining(t *testing.T) {
+	softDeleted := []string{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/metadata/old/c-cluster", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"keys": ["steward"]}}`)
+	})
+	mux.HandleFunc("/v1/kv/data/old/c-cluster/steward", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"data": {"token": "steward"}}}`)
+	})
+	mux.HandleFunc("/v1/kv/data/new/c-cluster/steward", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/v1/kv/metadata/old/c-cluster/steward", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"data": {"versions": {"1": {"destroyed": false}}}}`)
+	})
+	mux.HandleFunc("/v1/kv/delete/old/c-cluster/steward", func(w http.ResponseWriter, r *http.Request) {
+		softDeleted = append(softDeleted, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+	require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+
+	b, err := newBankVaultClient(context.Background(), synv1alpha1.RetainPolicy, testr.New(t))
+	require.NoError(t, err)
+
+	require.NoError(t, b.MoveSecrets("old/c-cluster", "new/c-cluster"))
+	assert.Equal(t, []string{"/v1/kv/delete/old/c-cluster/steward"}, softDeleted, "should not leave a live copy at the old path")
+}
+
 func getVersionHTTPServer(t *testing.T) *httptest.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/v1/kv/delete/kv2/test/foo", func(w http.ResponseWriter, r *http.Request) {
@@ -439,7 +543,7 @@ func TestBankVaultClient_listSecrets(t *testing.T) {
 
 			defer server.Close()
 
-			b, err := newBankVaultClient(tt.args.policy, zapr.NewLogger(zapLog))
+			b, err := newBankVaultClient(context.Background(), tt.args.policy, zapr.NewLogger(zapLog))
 			assert.NoError(t, err)
 
 			got, err := b.listSecrets(tt.args.secretPath)
@@ -453,3 +557,45 @@ func TestBankVaultClient_listSecrets(t *testing.T) {
 		})
 	}
 }
+
+func TestBankVaultClient_AddSecrets_customMetadata(t *testing.T) {
+	zapLog, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	written := map[string]string{}
+	record := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		written[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/kv/data/clusters/t-tenant/c-cluster/steward", record)
+	mux.HandleFunc("/v1/kv/metadata/clusters/t-tenant/c-cluster/steward", record)
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+	require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+	require.NoError(t, os.Setenv("VAULT_SECRET_ENGINE_PATH", ""))
+
+	b, err := newBankVaultClient(context.Background(), synv1alpha1.ArchivePolicy, zapr.NewLogger(zapLog))
+	require.NoError(t, err)
+
+	require.NoError(t, b.AddSecrets([]VaultSecret{{
+		Path:     "clusters/t-tenant/c-cluster/steward",
+		Key:      "jwt",
+		Value:    "secret",
+		Metadata: map[string]string{"tenant": "t-tenant", "cluster": "c-cluster"},
+	}}))
+	assert.Equal(t, map[string]string{
+		"/v1/kv/data/clusters/t-tenant/c-cluster/steward":     `{"data":{"jwt":"secret"},"options":{"cas":0}}`,
+		"/v1/kv/metadata/clusters/t-tenant/c-cluster/steward": `{"custom_metadata":{"cluster":"c-cluster","tenant":"t-tenant"}}`,
+	}, written)
+}
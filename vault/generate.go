@@ -0,0 +1,155 @@
+package vault
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultPasswordLength = 32
+	passwordCharset       = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	bech32Charset         = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// generateSecret generates the keys and values of a secret from its template
+func generateSecret(tmpl synv1alpha1.SecretTemplate) (map[string]string, error) {
+	switch tmpl.Type {
+	case synv1alpha1.PasswordSecret, "":
+		length := tmpl.Length
+		if length == 0 {
+			length = defaultPasswordLength
+		}
+		password, err := generatePassword(length)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"password": password}, nil
+	case synv1alpha1.SSHKeySecret:
+		private, public, err := generateSSHKey()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"privateKey": private, "publicKey": public}, nil
+	case synv1alpha1.AgeKeySecret:
+		identity, recipient, err := generateAgeKey()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"identity": identity, "recipient": recipient}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret type %q", tmpl.Type)
+	}
+}
+
+func generatePassword(length int) (string, error) {
+	max := big.NewInt(int64(len(passwordCharset)))
+	var b strings.Builder
+	for range length {
+		i, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(passwordCharset[i.Int64()])
+	}
+	return b.String(), nil
+}
+
+// generateSSHKey returns an Ed25519 private key in the OpenSSH format and its public key in the authorized_keys format
+func generateSSHKey() (string, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		return "", "", err
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		return "", "", err
+	}
+	return string(pem.EncodeToMemory(block)), strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublic))), nil
+}
+
+// generateAgeKey returns an age X25519 identity and its recipient
+func generateAgeKey() (string, string, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	identity := bech32Encode("age-secret-key-", convertBits(key.Bytes()))
+	recipient := bech32Encode("age", convertBits(key.PublicKey().Bytes()))
+	return strings.ToUpper(identity), recipient, nil
+}
+
+// bech32Encode encodes 5 bit values with the human readable part as specified by BIP 173.
+// Unlike BIP 173, the length isn't limited, as required by age.
+func bech32Encode(hrp string, values []byte) string {
+	combined := append(bech32HRPExpand(hrp), values...)
+	polymod := bech32Polymod(append(combined, 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := range 6 {
+		b.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range []byte(hrp) {
+		result = append(result, c>>5)
+	}
+	result = append(result, 0)
+	for _, c := range []byte(hrp) {
+		result = append(result, c&31)
+	}
+	return result
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := range 5 {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// convertBits converts bytes to 5 bit values, padding the last value with zeros
+func convertBits(data []byte) []byte {
+	var acc uint32
+	var bits uint
+	result := make([]byte, 0, len(data)*8/5+1)
+	for _, b := range data {
+		acc = (acc<<8 | uint32(b)) & 0xfff
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			result = append(result, byte(acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		result = append(result, byte(acc<<(5-bits))&31)
+	}
+	return result
+}
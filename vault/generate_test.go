@@ -0,0 +1,45 @@
+package vault
+
+import (
+	"strings"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_bech32Encode(t *testing.T) {
+	// Test vectors of BIP 173
+	assert.Equal(t, "a12uel5l", bech32Encode("a", []byte{}))
+	values := make([]byte, 32)
+	for i := range values {
+		values[i] = byte(i)
+	}
+	assert.Equal(t, "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", bech32Encode("abcdef", values))
+}
+
+func Test_generateSecret(t *testing.T) {
+	password, err := generateSecret(synv1alpha1.SecretTemplate{})
+	require.NoError(t, err)
+	assert.Len(t, password["password"], defaultPasswordLength)
+
+	password, err = generateSecret(synv1alpha1.SecretTemplate{Type: synv1alpha1.PasswordSecret, Length: 12})
+	require.NoError(t, err)
+	assert.Regexp(t, "^[a-zA-Z0-9]{12}$", password["password"])
+
+	sshKey, err := generateSecret(synv1alpha1.SecretTemplate{Type: synv1alpha1.SSHKeySecret})
+	require.NoError(t, err)
+	signer, err := ssh.ParsePrivateKey([]byte(sshKey["privateKey"]))
+	require.NoError(t, err)
+	assert.Equal(t, sshKey["publicKey"], strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey()))))
+
+	ageKey, err := generateSecret(synv1alpha1.SecretTemplate{Type: synv1alpha1.AgeKeySecret})
+	require.NoError(t, err)
+	assert.Regexp(t, "^AGE-SECRET-KEY-1[QPZRY9X8GF2TVDW0S3JN54KHCE6MUA7L]{58}$", ageKey["identity"])
+	assert.Regexp(t, "^age1[qpzry9x8gf2tvdw0s3jn54khce6mua7l]{58}$", ageKey["recipient"])
+
+	_, err = generateSecret(synv1alpha1.SecretTemplate{Type: "Certificate"})
+	assert.Error(t, err)
+}
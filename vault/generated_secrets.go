@@ -0,0 +1,137 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CreateGeneratedSecrets generates the secrets declared by the secret templates of a cluster.
+// Each secret is generated once and never overwritten, unless its rotation period has passed.
+// Secrets whose template was removed are removed according to the deletion policy.
+func CreateGeneratedSecrets(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok || !data.UseVault || data.Deleted || cluster.DecommissionRequested() {
+		return pipeline.Result{}
+	}
+	if len(cluster.Spec.SecretTemplates) == 0 && len(cluster.Status.GeneratedSecrets) == 0 {
+		return pipeline.Result{}
+	}
+
+	tenant := cluster.GetTenantRef().Name
+	clusterPath, err := layout.clusterPath(tenant, cluster.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+	tokenPath, err := layout.tokenPath(tenant, cluster.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+
+	vaultClient, err := getVaultClient(obj, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+	}
+
+	now := time.Now()
+	var requeueAfter time.Duration
+	generated := make([]synv1alpha1.GeneratedSecretStatus, 0, len(cluster.Spec.SecretTemplates))
+	for _, tmpl := range cluster.Spec.SecretTemplates {
+		secretPath := path.Join(clusterPath, tmpl.PathSuffix)
+		if secretPath == tokenPath {
+			return pipeline.Result{Err: fmt.Errorf("secret template %q conflicts with the Steward token", tmpl.PathSuffix)}
+		}
+		var rotation time.Duration
+		if tmpl.RotationPeriod != "" {
+			rotation, err = time.ParseDuration(tmpl.RotationPeriod)
+			if err != nil {
+				return pipeline.Result{Err: fmt.Errorf("parse rotation period of secret template %q: %w", tmpl.PathSuffix, err)}
+			}
+		}
+
+		exists, err := vaultClient.HasSecret(secretPath)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("check secret '%s': %w", secretPath, err)}
+		}
+
+		status := synv1alpha1.GeneratedSecretStatus{
+			PathSuffix:  tmpl.PathSuffix,
+			Type:        tmpl.Type,
+			GeneratedAt: metav1.NewTime(now),
+		}
+		previous := findGeneratedSecret(cluster.Status.GeneratedSecrets, tmpl.PathSuffix)
+		if exists && previous != nil {
+			status.GeneratedAt = previous.GeneratedAt
+		}
+		rotate := exists && rotation > 0 && !now.Before(status.GeneratedAt.Add(rotation))
+
+		if !exists || rotate {
+			if err := writeGeneratedSecret(vaultClient, tmpl, secretPath, layout.metadata(tenant, cluster.GetName())); err != nil {
+				return pipeline.Result{Err: fmt.Errorf("generate secret '%s': %w", secretPath, err)}
+			}
+			status.GeneratedAt = metav1.NewTime(now)
+			if rotate {
+				data.Eventf(cluster, corev1.EventTypeNormal, "SecretRotated", "Rotate", "Rotated generated secret %s", tmpl.PathSuffix)
+			} else {
+				data.Eventf(cluster, corev1.EventTypeNormal, "SecretGenerated", "Generate", "Generated secret %s", tmpl.PathSuffix)
+			}
+		}
+
+		if rotation > 0 {
+			next := status.GeneratedAt.Add(rotation).Sub(now)
+			if requeueAfter == 0 || next < requeueAfter {
+				requeueAfter = next
+			}
+		}
+		generated = append(generated, status)
+	}
+
+	removed := []VaultSecret{}
+	for _, s := range cluster.Status.GeneratedSecrets {
+		if !slices.ContainsFunc(cluster.Spec.SecretTemplates, func(t synv1alpha1.SecretTemplate) bool { return t.PathSuffix == s.PathSuffix }) {
+			removed = append(removed, VaultSecret{Path: path.Join(clusterPath, s.PathSuffix)})
+		}
+	}
+	if len(removed) > 0 {
+		if err := vaultClient.RemoveSecrets(removed); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("remove generated secrets: %w", err)}
+		}
+	}
+
+	cluster.Status.GeneratedSecrets = generated
+	return pipeline.Result{RequeueAfter: requeueAfter}
+}
+
+func writeGeneratedSecret(vaultClient VaultClient, tmpl synv1alpha1.SecretTemplate, secretPath string, metadata map[string]string) error {
+	values, err := generateSecret(tmpl)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	secrets := make([]VaultSecret, 0, len(values))
+	for _, k := range keys {
+		secrets = append(secrets, VaultSecret{Path: secretPath, Key: k, Value: values[k], Metadata: metadata})
+	}
+	return vaultClient.AddSecrets(secrets)
+}
+
+func findGeneratedSecret(secrets []synv1alpha1.GeneratedSecretStatus, pathSuffix string) *synv1alpha1.GeneratedSecretStatus {
+	for i := range secrets {
+		if secrets[i].PathSuffix == pathSuffix {
+			return &secrets[i]
+		}
+	}
+	return nil
+}
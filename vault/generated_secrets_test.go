@@ -0,0 +1,56 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func Test_createGeneratedSecrets(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Spec.TenantRef.Name = "t-tenant"
+	cluster.Spec.SecretTemplates = []synv1alpha1.SecretTemplate{
+		{PathSuffix: "admin", Type: synv1alpha1.PasswordSecret},
+		{PathSuffix: "ssh/deploy", Type: synv1alpha1.SSHKeySecret, RotationPeriod: "720h"},
+	}
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	res := CreateGeneratedSecrets(cluster, data)
+	require.NoError(t, res.Err)
+	assert.InDelta(t, 720*time.Hour, res.RequeueAfter, float64(time.Minute))
+	require.Len(t, cluster.Status.GeneratedSecrets, 2)
+	admin := mockClient.secrets["t-tenant/c-cluster/admin"]["password"]
+	assert.Len(t, admin, 32)
+	assert.Contains(t, mockClient.secrets["t-tenant/c-cluster/ssh/deploy"], "privateKey")
+	assert.Contains(t, mockClient.secrets["t-tenant/c-cluster/ssh/deploy"], "publicKey")
+
+	require.NoError(t, CreateGeneratedSecrets(cluster, data).Err)
+	assert.Equal(t, admin, mockClient.secrets["t-tenant/c-cluster/admin"]["password"], "must not overwrite existing secrets")
+
+	deployKey := mockClient.secrets["t-tenant/c-cluster/ssh/deploy"]["privateKey"]
+	cluster.Status.GeneratedSecrets[1].GeneratedAt = metav1.NewTime(time.Now().Add(-721 * time.Hour))
+	require.NoError(t, CreateGeneratedSecrets(cluster, data).Err)
+	assert.NotEqual(t, deployKey, mockClient.secrets["t-tenant/c-cluster/ssh/deploy"]["privateKey"], "should rotate the secret")
+	assert.WithinDuration(t, time.Now(), cluster.Status.GeneratedSecrets[1].GeneratedAt.Time, time.Minute)
+
+	cluster.Spec.SecretTemplates = cluster.Spec.SecretTemplates[1:]
+	require.NoError(t, CreateGeneratedSecrets(cluster, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "t-tenant/c-cluster/admin"}}, mockClient.removed)
+	assert.Len(t, cluster.Status.GeneratedSecrets, 1)
+
+	cluster.Spec.SecretTemplates = []synv1alpha1.SecretTemplate{{PathSuffix: "steward"}}
+	assert.Error(t, CreateGeneratedSecrets(cluster, data).Err, "must not overwrite the Steward token")
+}
@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"fmt"
+
+	"github.com/banzaicloud/bank-vaults/pkg/sdk/vault"
+	"github.com/go-logr/logr"
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+// connectionChecker is implemented by clients which connect to an external secret store
+type connectionChecker interface {
+	checkConnection() error
+}
+
+// CheckConnection verifies that the configured secret store is reachable and that the operator is authenticated.
+// Stores which don't depend on an external service always pass.
+func CheckConnection(log logr.Logger) error {
+	c, err := NewClient(synv1alpha1.RetainPolicy, log)
+	if err != nil {
+		return err
+	}
+	if checker, ok := c.(connectionChecker); ok {
+		return checker.checkConnection()
+	}
+	return nil
+}
+
+func (b *BankVaultClient) checkConnection() error {
+	return checkVaultConnection(b.client)
+}
+
+func (k *KVv1Client) checkConnection() error {
+	return checkVaultConnection(k.client)
+}
+
+// checkVaultConnection verifies that Vault is unsealed and that the token of the client is valid
+func checkVaultConnection(client *vault.Client) error {
+	raw := client.RawClient()
+	status, err := raw.Sys().SealStatus()
+	if err != nil {
+		return fmt.Errorf("get seal status: %w", err)
+	}
+	if status.Sealed {
+		return fmt.Errorf("vault is sealed")
+	}
+	if _, err := raw.Auth().Token().LookupSelf(); err != nil {
+		return fmt.Errorf("look up token: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/testutils"
+)
+
+func TestCheckConnection(t *testing.T) {
+	tests := map[string]struct {
+		sealed      bool
+		tokenStatus int
+		wantErr     string
+	}{
+		"ready": {
+			tokenStatus: http.StatusOK,
+		},
+		"sealed": {
+			sealed:      true,
+			tokenStatus: http.StatusOK,
+			wantErr:     "vault is sealed",
+		},
+		"invalid token": {
+			tokenStatus: http.StatusForbidden,
+			wantErr:     "look up token",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v1/sys/seal-status", func(w http.ResponseWriter, r *http.Request) {
+				if tc.sealed {
+					_, _ = io.WriteString(w, `{"sealed":true}`)
+					return
+				}
+				_, _ = io.WriteString(w, `{"sealed":false}`)
+			})
+			mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.tokenStatus)
+				if tc.tokenStatus != http.StatusOK {
+					_, _ = io.WriteString(w, `{"errors":["permission denied"]}`)
+					return
+				}
+				_, _ = io.WriteString(w, `{"data":{"ttl":3600}}`)
+			})
+			mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+			require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+
+			b, err := newBankVaultClient(context.Background(), synv1alpha1.RetainPolicy, testr.New(t))
+			require.NoError(t, err)
+			SetCustomClient(b)
+			defer SetCustomClient(nil)
+
+			err = CheckConnection(testr.New(t))
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckConnection_withoutVault(t *testing.T) {
+	SetCustomClient(&testMockClient{})
+	defer SetCustomClient(nil)
+
+	assert.NoError(t, CheckConnection(testr.New(t)))
+}
@@ -0,0 +1,266 @@
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SecretStoreLabel marks the Secrets managed by the Kubernetes secret store
+	SecretStoreLabel = "lieutenant.syn.tools/secret-store"
+	// SecretPathAnnotation holds the path of a secret managed by the Kubernetes secret store
+	SecretPathAnnotation = "lieutenant.syn.tools/secret-path"
+	// SecretArchivedAnnotation marks an archived secret of the Kubernetes secret store
+	SecretArchivedAnnotation = "lieutenant.syn.tools/secret-archived"
+)
+
+// KubernetesSecretStore stores the secrets in Kubernetes Secrets in a single namespace.
+// It's meant for small installations without Vault.
+// Each secret is stored in a Secret named after its path and a hash of the path, see storeSecretName.
+// The Secrets are looked up by the path in their SecretPathAnnotation, not by their name.
+type KubernetesSecretStore struct {
+	client         client.Client
+	namespace      string
+	deletionPolicy synv1alpha1.DeletionPolicy
+	log            logr.Logger
+}
+
+func newKubernetesSecretStore(c client.Client, namespace string, deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) *KubernetesSecretStore {
+	return &KubernetesSecretStore{
+		client:         c,
+		namespace:      namespace,
+		deletionPolicy: deletionPolicy,
+		log:            log,
+	}
+}
+
+func (k *KubernetesSecretStore) AddSecrets(secrets []VaultSecret) error {
+	for _, secret := range secrets {
+		if err := k.writeSecret(secret.Path, map[string][]byte{secret.key(): []byte(secret.Value)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasSecret returns whether a secret which isn't archived exists at the path.
+func (k *KubernetesSecretStore) HasSecret(secretPath string) (bool, error) {
+	secret, err := k.getSecret(secretPath)
+	if err != nil || secret == nil {
+		return false, err
+	}
+	_, archived := secret.Annotations[SecretArchivedAnnotation]
+	return !archived, nil
+}
+
+// writeSecret creates or updates the keys of the Secret of the path. Writing an archived secret restores it without its previous keys.
+func (k *KubernetesSecretStore) writeSecret(secretPath string, data map[string][]byte) error {
+	ctx := context.Background()
+	secretPath = strings.Trim(secretPath, "/")
+
+	secret, err := k.getSecret(secretPath)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		name := storeSecretName(secretPath)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid name %q for secret %q: %s", name, secretPath, strings.Join(errs, ", "))
+		}
+		k.log.WithName("vault").Info("does not yet exist, creating", "name", secretPath)
+		return k.client.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   k.namespace,
+				Labels:      map[string]string{SecretStoreLabel: "true"},
+				Annotations: map[string]string{SecretPathAnnotation: secretPath},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		})
+	}
+
+	_, archived := secret.Annotations[SecretArchivedAnnotation]
+	merged := map[string][]byte{}
+	if !archived {
+		maps.Copy(merged, secret.Data)
+	}
+	maps.Copy(merged, data)
+	if !archived && dataEqual(secret.Data, merged) {
+		return nil
+	}
+
+	k.log.WithName("vault").Info("secrets don't match, re-applying", "name", secretPath)
+	delete(secret.Annotations, SecretArchivedAnnotation)
+	secret.Data = merged
+	return k.client.Update(ctx, secret)
+}
+
+// RemoveSecrets removes all the secrets below the given paths according to the deletion policy.
+// Archived secrets are kept with an annotation marking them as archived.
+func (k *KubernetesSecretStore) RemoveSecrets(secrets []VaultSecret) error {
+	for _, secret := range secrets {
+		if err := k.removeSecret(secret.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveSecrets copies all secrets below the path from to the path to.
+// The secrets below from are then archived, or deleted under the Delete policy.
+// They're never retained, since a move mustn't leave a live copy behind.
+func (k *KubernetesSecretStore) MoveSecrets(from, to string) error {
+	if err := validateMove(from, to); err != nil {
+		return err
+	}
+	secrets, err := k.listSecrets(from)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets {
+		secretPath := secret.Annotations[SecretPathAnnotation]
+		target := path.Join(to, strings.TrimPrefix(secretPath, strings.Trim(from, "/")))
+		k.log.WithName("vault").Info("moving secret", "from", secretPath, "to", target)
+		if err := k.writeSecret(target, secret.Data); err != nil {
+			return err
+		}
+	}
+	source := *k
+	if source.deletionPolicy != synv1alpha1.DeletePolicy {
+		source.deletionPolicy = synv1alpha1.ArchivePolicy
+	}
+	return source.removeSecret(from)
+}
+
+func (k *KubernetesSecretStore) removeSecret(secretPath string) error {
+	ctx := context.Background()
+	secrets, err := k.listSecrets(secretPath)
+	if err != nil {
+		return err
+	}
+
+	for i := range secrets {
+		secret := &secrets[i]
+		switch k.deletionPolicy {
+		case synv1alpha1.ArchivePolicy:
+			k.log.Info("archiving secret", "secret", secret.Annotations[SecretPathAnnotation])
+			secret.Annotations[SecretArchivedAnnotation] = "true"
+			if err := k.client.Update(ctx, secret); err != nil {
+				return err
+			}
+		case synv1alpha1.DeletePolicy:
+			k.log.Info("deleting secret", "secret", secret.Annotations[SecretPathAnnotation])
+			if err := k.client.Delete(ctx, secret); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		case synv1alpha1.RetainPolicy:
+			k.log.Info("retaining secret", "secret", secret.Annotations[SecretPathAnnotation])
+		default:
+			return fmt.Errorf("unknown DeletionPolicy, skipping")
+		}
+	}
+	return nil
+}
+
+// getSecret returns the Secret of the path, including archived ones. It returns nil if there's no Secret for the path.
+func (k *KubernetesSecretStore) getSecret(secretPath string) (*corev1.Secret, error) {
+	secretPath = strings.Trim(secretPath, "/")
+
+	secrets, err := k.storeSecrets()
+	if err != nil {
+		return nil, err
+	}
+	for i := range secrets {
+		if secrets[i].Annotations[SecretPathAnnotation] == secretPath {
+			return &secrets[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// listSecrets returns the secrets which aren't archived at or below the path.
+func (k *KubernetesSecretStore) listSecrets(secretPath string) ([]corev1.Secret, error) {
+	secretPath = strings.Trim(secretPath, "/")
+
+	secrets, err := k.storeSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]corev1.Secret, 0)
+	for _, secret := range secrets {
+		if _, archived := secret.Annotations[SecretArchivedAnnotation]; archived {
+			continue
+		}
+		p := secret.Annotations[SecretPathAnnotation]
+		if p == secretPath || strings.HasPrefix(p, secretPath+"/") {
+			result = append(result, secret)
+		}
+	}
+	return result, nil
+}
+
+func (k *KubernetesSecretStore) WithDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) VaultClient {
+	c := *k
+	c.deletionPolicy = deletionPolicy
+	return &c
+}
+
+// storeSecrets returns all Secrets of the store
+func (k *KubernetesSecretStore) storeSecrets() ([]corev1.Secret, error) {
+	list := &corev1.SecretList{}
+	if err := k.client.List(context.Background(), list, client.InNamespace(k.namespace), client.MatchingLabels{SecretStoreLabel: "true"}); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+var invalidSecretNameCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
+// storeSecretHashLength is the number of hex characters of the hash of the path in the name of a Secret
+const storeSecretHashLength = 16
+
+// storeSecretName returns the name of the Secret of the path.
+// The name consists of the path with all characters other than lowercase letters and digits replaced by dashes,
+// followed by a hash of the path, for example `t-tenant-c-cluster-steward-0123456789abcdef`.
+// The hash keeps the names of different paths apart, the readable part is truncated to fit into the maximum length of a name.
+func storeSecretName(secretPath string) string {
+	secretPath = strings.Trim(secretPath, "/")
+	sum := sha256.Sum256([]byte(secretPath))
+	hash := hex.EncodeToString(sum[:])[:storeSecretHashLength]
+
+	readable := strings.Trim(invalidSecretNameCharacters.ReplaceAllLiteralString(strings.ToLower(secretPath), "-"), "-")
+	if maxLen := validation.DNS1123SubdomainMaxLength - len(hash) - 1; len(readable) > maxLen {
+		readable = strings.TrimRight(readable[:maxLen], "-")
+	}
+	if readable == "" {
+		return hash
+	}
+	return readable + "-" + hash
+}
+
+func dataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || string(v) != string(w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func newTestStoreSecret(p, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        storeSecretName(p),
+			Namespace:   "lieutenant",
+			Labels:      map[string]string{SecretStoreLabel: "true"},
+			Annotations: map[string]string{SecretPathAnnotation: p},
+		},
+		Data: map[string][]byte{tokenName: []byte(token)},
+	}
+}
+
+func getTestStoreSecrets(t *testing.T, c client.Client) map[string]string {
+	list := &corev1.SecretList{}
+	require.NoError(t, c.List(context.Background(), list, client.InNamespace("lieutenant")))
+	result := map[string]string{}
+	for _, s := range list.Items {
+		p := s.Annotations[SecretPathAnnotation]
+		if _, ok := s.Annotations[SecretArchivedAnnotation]; ok {
+			p += " (archived)"
+		}
+		result[p] = string(s.Data[tokenName])
+	}
+	return result
+}
+
+func TestKubernetesSecretStore_AddSecrets(t *testing.T) {
+	archived := newTestStoreSecret("t-tenant/c-archived/steward", "archived")
+	archived.Annotations[SecretArchivedAnnotation] = "true"
+	c := fake.NewClientBuilder().WithObjects(
+		newTestStoreSecret("t-tenant/c-cluster/steward", "old"),
+		archived,
+	).Build()
+	s := newKubernetesSecretStore(c, "lieutenant", synv1alpha1.ArchivePolicy, zap.New())
+
+	require.NoError(t, s.AddSecrets([]VaultSecret{
+		{Path: "t-tenant/c-cluster/steward", Value: "new"},
+		{Path: "t-tenant/c-other/steward", Value: "other"},
+		{Path: "t-tenant/c-archived/steward", Value: "restored"},
+	}))
+	assert.Equal(t, map[string]string{
+		"t-tenant/c-cluster/steward":  "new",
+		"t-tenant/c-other/steward":    "other",
+		"t-tenant/c-archived/steward": "restored",
+	}, getTestStoreSecrets(t, c))
+}
+
+func TestKubernetesSecretStore_RemoveSecrets(t *testing.T) {
+	tests := map[string]struct {
+		policy synv1alpha1.DeletionPolicy
+		want   map[string]string
+	}{
+		"delete": {
+			policy: synv1alpha1.DeletePolicy,
+			want: map[string]string{
+				"t-tenant/c-cluster-2/steward": "other",
+			},
+		},
+		"archive": {
+			policy: synv1alpha1.ArchivePolicy,
+			want: map[string]string{
+				"t-tenant/c-cluster/steward (archived)":         "steward",
+				"t-tenant/c-cluster/custom/password (archived)": "password",
+				"t-tenant/c-cluster-2/steward":                  "other",
+			},
+		},
+		"retain": {
+			policy: synv1alpha1.RetainPolicy,
+			want: map[string]string{
+				"t-tenant/c-cluster/steward":         "steward",
+				"t-tenant/c-cluster/custom/password": "password",
+				"t-tenant/c-cluster-2/steward":       "other",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(
+				newTestStoreSecret("t-tenant/c-cluster/steward", "steward"),
+				newTestStoreSecret("t-tenant/c-cluster/custom/password", "password"),
+				newTestStoreSecret("t-tenant/c-cluster-2/steward", "other"),
+			).Build()
+			s := newKubernetesSecretStore(c, "lieutenant", tc.policy, zap.New())
+
+			require.NoError(t, s.RemoveSecrets([]VaultSecret{{Path: "t-tenant/c-cluster"}}))
+			assert.Equal(t, tc.want, getTestStoreSecrets(t, c))
+		})
+	}
+}
+
+func TestKubernetesSecretStore_MoveSecrets(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		newTestStoreSecret("old/c-cluster/steward", "steward"),
+		newTestStoreSecret("old/c-cluster/custom/password", "password"),
+	).Build()
+	s := newKubernetesSecretStore(c, "lieutenant", synv1alpha1.DeletePolicy, zap.New())
+
+	require.NoError(t, s.MoveSecrets("old/c-cluster", "new/c-cluster"))
+	assert.Equal(t, map[string]string{
+		"new/c-cluster/steward":         "steward",
+		"new/c-cluster/custom/password": "password",
+	}, getTestStoreSecrets(t, c))
+
+	assert.Error(t, s.MoveSecrets("new/c-cluster", "new/c-cluster"), "should refuse to move secrets onto themselves")
+	assert.Error(t, s.MoveSecrets("new", "new/c-cluster"), "should refuse to move secrets into their own subtree")
+	assert.Len(t, getTestStoreSecrets(t, c), 2)
+}
+
+func TestKubernetesSecretStore_MoveSecrets_retaining(t *testing.T) {
+	for _, policy := range []synv1alpha1.DeletionPolicy{synv1alpha1.RetainPolicy, synv1alpha1.ArchivePolicy} {
+		t.Run(string(policy), func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(
+				newTestStoreSecret("old/c-cluster/steward", "steward"),
+			).Build()
+			s := newKubernetesSecretStore(c, "lieutenant", policy, zap.New())
+
+			require.NoError(t, s.MoveSecrets("old/c-cluster", "new/c-cluster"))
+			assert.Equal(t, map[string]string{
+				"new/c-cluster/steward":            "steward",
+				"old/c-cluster/steward (archived)": "steward",
+			}, getTestStoreSecrets(t, c), "should archive the secrets at the old path")
+		})
+	}
+}
+
+func TestKubernetesSecretStore_AddSecrets_collidingNames(t *testing.T) {
+	legacy := newTestStoreSecret("t-tenant/c-legacy/steward", "old")
+	legacy.Name = "t-tenant.c-legacy.steward"
+	c := fake.NewClientBuilder().WithObjects(legacy).Build()
+	s := newKubernetesSecretStore(c, "lieutenant", synv1alpha1.DeletePolicy, zap.New())
+
+	require.NoError(t, s.AddSecrets([]VaultSecret{
+		{Path: "t-tenant/c.cluster/steward", Value: "dotted"},
+		{Path: "t-tenant.c/cluster/steward", Value: "other"},
+		{Path: "t-tenant/c-legacy/steward", Value: "new"},
+	}))
+	assert.Equal(t, map[string]string{
+		"t-tenant/c.cluster/steward": "dotted",
+		"t-tenant.c/cluster/steward": "other",
+		"t-tenant/c-legacy/steward":  "new",
+	}, getTestStoreSecrets(t, c), "should keep paths apart and find secrets by their path")
+
+	ok, err := s.HasSecret("t-tenant/c-legacy/steward")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func Test_storeSecretName(t *testing.T) {
+	tests := map[string]struct {
+		path    string
+		wantPfx string
+	}{
+		"simple": {
+			path:    "t-tenant/c-cluster/steward",
+			wantPfx: "t-tenant-c-cluster-steward-",
+		},
+		"invalid characters": {
+			path:    "/Clusters/t_tenant/c.cluster/",
+			wantPfx: "clusters-t-tenant-c-cluster-",
+		},
+		"long": {
+			path:    strings.Repeat("segment/", 40),
+			wantPfx: "segment-segment-",
+		},
+		"no readable characters": {
+			path: "_/_",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			n := storeSecretName(tc.path)
+			assert.Empty(t, validation.IsDNS1123Subdomain(n))
+			assert.True(t, strings.HasPrefix(n, tc.wantPfx), "name %q should start with %q", n, tc.wantPfx)
+			assert.Equal(t, n, storeSecretName(strings.Trim(tc.path, "/")), "name should only depend on the trimmed path")
+		})
+	}
+	assert.NotEqual(t, storeSecretName("a/b.c"), storeSecretName("a.b/c"))
+}
+
+func TestConfigureStore(t *testing.T) {
+	defer func() {
+		storeConfig = StoreConfig{Type: KVv2Store}
+		instanceClient = nil
+	}()
+
+	assert.Error(t, ConfigureStore(StoreConfig{Type: "kv-v3"}))
+	assert.Error(t, ConfigureStore(StoreConfig{Type: KubernetesStore}))
+
+	c := fake.NewClientBuilder().Build()
+	require.NoError(t, ConfigureStore(StoreConfig{Type: KubernetesStore, Client: c, Namespace: "lieutenant"}))
+	vc, err := NewClient(synv1alpha1.DeletePolicy, zap.New())
+	require.NoError(t, err)
+	assert.IsType(t, &KubernetesSecretStore{}, vc)
+
+	retaining, err := NewClient(synv1alpha1.RetainPolicy, zap.New())
+	require.NoError(t, err)
+	assert.Equal(t, synv1alpha1.RetainPolicy, retaining.(*KubernetesSecretStore).deletionPolicy)
+	assert.Equal(t, synv1alpha1.DeletePolicy, vc.(*KubernetesSecretStore).deletionPolicy, "clients must not share the deletion policy")
+}
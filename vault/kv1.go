@@ -0,0 +1,205 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+
+	"github.com/banzaicloud/bank-vaults/pkg/sdk/vault"
+	"github.com/go-logr/logr"
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+)
+
+// KVv1Client stores the secrets in a KV version 1 secret engine.
+// KV version 1 doesn't support soft deletion, archived secrets are therefore retained.
+type KVv1Client struct {
+	client         *vault.Client
+	secretEngine   string
+	deletionPolicy synv1alpha1.DeletionPolicy
+	log            logr.Logger
+}
+
+func newKVv1Client(ctx context.Context, deletionPolicy synv1alpha1.DeletionPolicy, log logr.Logger) (*KVv1Client, error) {
+	client, secretEngine, err := newVaultConnection(ctx, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KVv1Client{
+		client:         client,
+		secretEngine:   secretEngine,
+		deletionPolicy: deletionPolicy,
+		log:            log,
+	}, nil
+}
+
+func (k *KVv1Client) AddSecrets(secrets []VaultSecret) error {
+	for _, secret := range secrets {
+		if err := k.addSecret(secret.Path, secret.key(), secret.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSecret saves the token in Vault if it doesn't exist yet or has a different value.
+// KV version 1 doesn't support custom metadata.
+func (k *KVv1Client) addSecret(secretPath, key, token string) error {
+	queryPath := path.Join(k.secretEngine, secretPath)
+
+	secret, err := k.client.RawClient().Logical().Read(queryPath)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{}
+	if secret != nil {
+		if secret.Data[key] == token {
+			return nil
+		}
+		maps.Copy(data, secret.Data)
+	}
+	data[key] = token
+
+	k.log.WithName("vault").Info("writing secret", "name", secretPath)
+	_, err = k.client.RawClient().Logical().Write(queryPath, data)
+	return err
+}
+
+// RemoveSecrets removes all the keys below the given paths according to the deletion policy.
+func (k *KVv1Client) RemoveSecrets(secrets []VaultSecret) error {
+	for _, secret := range secrets {
+		if err := k.removeSecret(secret.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MoveSecrets copies all secrets below the path from to the path to.
+// The secrets below from are then deleted regardless of the deletion policy, since a move mustn't leave a live copy behind
+// and KV version 1 can't archive secrets.
+func (k *KVv1Client) MoveSecrets(from, to string) error {
+	if err := validateMove(from, to); err != nil {
+		return err
+	}
+	if err := k.copySecrets(from, to); err != nil {
+		return err
+	}
+	source := *k
+	source.deletionPolicy = synv1alpha1.DeletePolicy
+	return source.removeSecret(from)
+}
+
+func (k *KVv1Client) copySecrets(from, to string) error {
+	secrets, err := k.listSecrets(from)
+	if err != nil {
+		return err
+	}
+
+	for _, secret := range secrets {
+		if isDirectory(secret) {
+			if err := k.copySecrets(path.Join(from, secret), path.Join(to, secret)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, err := k.client.RawClient().Logical().Read(path.Join(k.secretEngine, from, secret))
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			continue
+		}
+
+		k.log.WithName("vault").Info("moving secret", "from", path.Join(from, secret), "to", path.Join(to, secret))
+		if _, err := k.client.RawClient().Logical().Write(path.Join(k.secretEngine, to, secret), s.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HasSecret returns whether the secret at the path exists.
+func (k *KVv1Client) HasSecret(secretPath string) (bool, error) {
+	s, err := k.client.RawClient().Logical().Read(path.Join(k.secretEngine, secretPath))
+	if err != nil {
+		return false, err
+	}
+	return s != nil, nil
+}
+
+func (k *KVv1Client) removeSecret(secretPath string) error {
+	secrets, err := k.listSecrets(secretPath)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		// The path might be a secret itself
+		exists, err := k.HasSecret(secretPath)
+		if err != nil || !exists {
+			return err
+		}
+		return k.removeLeaf(secretPath)
+	}
+
+	for _, secret := range secrets {
+		if isDirectory(secret) {
+			if err := k.removeSecret(path.Join(secretPath, secret)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := k.removeLeaf(path.Join(secretPath, secret)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *KVv1Client) removeLeaf(secretPath string) error {
+	switch k.deletionPolicy {
+	case synv1alpha1.ArchivePolicy, synv1alpha1.RetainPolicy:
+		k.log.Info("retaining secret", "secret", secretPath)
+	case synv1alpha1.DeletePolicy:
+		k.log.Info("deleting secret", "secret", secretPath)
+		if _, err := k.client.RawClient().Logical().Delete(path.Join(k.secretEngine, secretPath)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown DeletionPolicy, skipping")
+	}
+	return nil
+}
+
+func (k *KVv1Client) listSecrets(secretPath string) ([]string, error) {
+	secrets, err := k.client.RawClient().Logical().List(path.Join(k.secretEngine, secretPath))
+	if err != nil {
+		return nil, err
+	}
+	if secrets == nil {
+		// There are no secrets below the path
+		return []string{}, nil
+	}
+	data, ok := secrets.Data["keys"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("list of secrets can't be decoded")
+	}
+
+	result := make([]string, 0, len(data))
+	for _, secret := range data {
+		s, ok := secret.(string)
+		if !ok {
+			return nil, fmt.Errorf("list of secrets can't be decoded")
+		}
+		result = append(result, s)
+	}
+	return result, nil
+}
+
+func (k *KVv1Client) WithDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) VaultClient {
+	c := *k
+	c.deletionPolicy = deletionPolicy
+	return &c
+}
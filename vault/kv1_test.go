@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/zapr"
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/testutils"
+)
+
+// testKVv1Server is a minimal fake of a KV version 1 secret engine mounted at kv
+type testKVv1Server struct {
+	secrets map[string]map[string]interface{}
+}
+
+func (f *testKVv1Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	switch {
+	case r.Method == "LIST" || r.URL.Query().Get("list") == "true":
+		keys := []string{}
+		for s := range f.secrets {
+			rest, ok := strings.CutPrefix(s, p+"/")
+			if !ok {
+				continue
+			}
+			if dir, _, ok := strings.Cut(rest, "/"); ok {
+				rest = dir + "/"
+			}
+			if !slices.Contains(keys, rest) {
+				keys = append(keys, rest)
+			}
+		}
+		if len(keys) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"keys": keys}})
+	case r.Method == http.MethodGet:
+		s, ok := f.secrets[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"errors":[]}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": s})
+	case r.Method == http.MethodPut || r.Method == http.MethodPost:
+		data := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&data)
+		f.secrets[p] = data
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		delete(f.secrets, p)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newTestKVv1Client(t *testing.T, secrets map[string]map[string]interface{}, policy synv1alpha1.DeletionPolicy) (*KVv1Client, *testKVv1Server) {
+	zapLog, err := zap.NewDevelopment()
+	require.NoError(t, err)
+
+	fake := &testKVv1Server{secrets: secrets}
+	mux := http.NewServeMux()
+	mux.Handle("/v1/kv/", fake)
+	mux.HandleFunc("/", testutils.LogNotFoundHandler(t))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	require.NoError(t, os.Setenv(api.EnvVaultToken, "myroot"))
+	require.NoError(t, os.Setenv(api.EnvVaultAddress, server.URL))
+	require.NoError(t, os.Setenv("VAULT_SECRET_ENGINE_PATH", ""))
+
+	c, err := newKVv1Client(context.Background(), policy, zapr.NewLogger(zapLog))
+	require.NoError(t, err)
+	return c, fake
+}
+
+func TestKVv1Client_AddSecrets(t *testing.T) {
+	c, fake := newTestKVv1Client(t, map[string]map[string]interface{}{
+		"t-tenant/c-cluster/steward": {"token": "old"},
+	}, synv1alpha1.ArchivePolicy)
+
+	require.NoError(t, c.AddSecrets([]VaultSecret{
+		{Path: "t-tenant/c-cluster/steward", Value: "new"},
+		{Path: "t-tenant/c-other/steward", Value: "other"},
+	}))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"t-tenant/c-cluster/steward": {"token": "new"},
+		"t-tenant/c-other/steward":   {"token": "other"},
+	}, fake.secrets)
+}
+
+func TestKVv1Client_RemoveSecrets(t *testing.T) {
+	tests := map[string]struct {
+		policy synv1alpha1.DeletionPolicy
+		want   []string
+	}{
+		"delete": {
+			policy: synv1alpha1.DeletePolicy,
+			want:   []string{"t-tenant/c-other/steward"},
+		},
+		"archive": {
+			policy: synv1alpha1.ArchivePolicy,
+			want:   []string{"t-tenant/c-cluster/steward", "t-tenant/c-cluster/custom/password", "t-tenant/c-other/steward"},
+		},
+		"retain": {
+			policy: synv1alpha1.RetainPolicy,
+			want:   []string{"t-tenant/c-cluster/steward", "t-tenant/c-cluster/custom/password", "t-tenant/c-other/steward"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, fake := newTestKVv1Client(t, map[string]map[string]interface{}{
+				"t-tenant/c-cluster/steward":         {"token": "steward"},
+				"t-tenant/c-cluster/custom/password": {"token": "password"},
+				"t-tenant/c-other/steward":           {"token": "other"},
+			}, tc.policy)
+
+			require.NoError(t, c.RemoveSecrets([]VaultSecret{{Path: "t-tenant/c-cluster"}}))
+			remaining := []string{}
+			for p := range fake.secrets {
+				remaining = append(remaining, p)
+			}
+			assert.ElementsMatch(t, tc.want, remaining)
+		})
+	}
+}
+
+func TestKVv1Client_MoveSecrets_retaining(t *testing.T) {
+	for _, policy := range []synv1alpha1.DeletionPolicy{synv1alpha1.RetainPolicy, synv1alpha1.ArchivePolicy} {
+		t.Run(string(policy), func(t *testing.T) {
+			c, fake := newTestKVv1Client(t, map[string]map[string]interface{}{
+				"old/c-cluster/steward": {"token": "steward"},
+			}, policy)
+
+			require.NoError(t, c.MoveSecrets("old/c-cluster", "new/c-cluster"))
+			assert.Equal(t, map[string]map[string]interface{}{
+				"new/c-cluster/steward": {"token": "steward"},
+			}, fake.secrets, "should not leave a copy at the old path")
+		})
+	}
+}
+
+func TestKVv1Client_MoveSecrets(t *testing.T) {
+	c, fake := newTestKVv1Client(t, map[string]map[string]interface{}{
+		"old/c-cluster/steward":         {"token": "steward"},
+		"old/c-cluster/custom/password": {"token": "password"},
+	}, synv1alpha1.DeletePolicy)
+
+	require.NoError(t, c.MoveSecrets("old/c-cluster", "new/c-cluster"))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"new/c-cluster/steward":         {"token": "steward"},
+		"new/c-cluster/custom/password": {"token": "password"},
+	}, fake.secrets)
+
+	assert.Error(t, c.MoveSecrets("new/c-cluster", "new/c-cluster/"), "should refuse to move secrets onto themselves")
+	assert.Error(t, c.MoveSecrets("new", "new/c-cluster"), "should refuse to move secrets into their own subtree")
+	assert.Error(t, c.MoveSecrets("new/c-cluster", "new"), "should refuse to move secrets into their parent")
+	assert.Len(t, fake.secrets, 2)
+}
+
+func TestKVv1Client_RemoveSecrets_leaf(t *testing.T) {
+	c, fake := newTestKVv1Client(t, map[string]map[string]interface{}{
+		"t-tenant/c-cluster/steward": {"token": "steward"},
+		"t-tenant/c-cluster/admin":   {"password": "password"},
+	}, synv1alpha1.DeletePolicy)
+
+	require.NoError(t, c.RemoveSecrets([]VaultSecret{{Path: "t-tenant/c-cluster/admin"}}))
+	assert.Equal(t, map[string]map[string]interface{}{
+		"t-tenant/c-cluster/steward": {"token": "steward"},
+	}, fake.secrets)
+}
@@ -0,0 +1,189 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultPathTemplate is the default template of the path of the Steward token of a cluster
+const DefaultPathTemplate = "{{.Tenant}}/{{.Cluster}}/steward"
+
+// DefaultSharedPathTemplate is the default template of the path of the secrets shared by all clusters of a tenant.
+// The leading underscore keeps it apart from the directories of the clusters, as cluster names can't start with one.
+const DefaultSharedPathTemplate = "{{.Tenant}}/_shared"
+
+// Keys of the custom metadata of the secrets
+const (
+	TenantMetadataKey      = "tenant"
+	ClusterMetadataKey     = "cluster"
+	OperatorUIDMetadataKey = "operator-uid"
+)
+
+// LayoutConfig configures where and how the secrets of clusters are stored
+type LayoutConfig struct {
+	// PathTemplate is a Go template of the path of the Steward token of a cluster.
+	// The fields .Tenant and .Cluster are available.
+	// The token must be stored in a directory named after the cluster, which contains all secrets of the cluster.
+	PathTemplate string
+	// KeyName is the key of the Steward token within its secret
+	KeyName string
+	// OperatorUID identifies the operator instance in the custom metadata of the secrets
+	OperatorUID string
+	// SharedPathTemplate is a Go template of the path of the secrets shared by all clusters of a tenant.
+	// The field .Tenant is available.
+	SharedPathTemplate string
+}
+
+type secretLayout struct {
+	template    *template.Template
+	shared      *template.Template
+	keyName     string
+	operatorUID string
+}
+
+type pathTemplateData struct {
+	Tenant  string
+	Cluster string
+}
+
+var (
+	defaultLayout = mustParseLayout(LayoutConfig{})
+	layout        = defaultLayout
+)
+
+// ConfigureLayout sets the layout of the secrets of clusters.
+// The default path template and the key `token` are used for unset fields.
+func ConfigureLayout(cfg LayoutConfig) error {
+	l, err := parseLayout(cfg)
+	if err != nil {
+		return err
+	}
+	layout = l
+	return nil
+}
+
+func mustParseLayout(cfg LayoutConfig) *secretLayout {
+	l, err := parseLayout(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func parseLayout(cfg LayoutConfig) (*secretLayout, error) {
+	if cfg.PathTemplate == "" {
+		cfg.PathTemplate = DefaultPathTemplate
+	}
+	if cfg.KeyName == "" {
+		cfg.KeyName = tokenName
+	}
+	if cfg.SharedPathTemplate == "" {
+		cfg.SharedPathTemplate = DefaultSharedPathTemplate
+	}
+
+	tmpl, err := template.New("path").Option("missingkey=error").Parse(cfg.PathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse path template: %w", err)
+	}
+	shared, err := template.New("sharedPath").Option("missingkey=error").Parse(cfg.SharedPathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse shared path template: %w", err)
+	}
+	l := &secretLayout{
+		template:    tmpl,
+		shared:      shared,
+		keyName:     cfg.KeyName,
+		operatorUID: cfg.OperatorUID,
+	}
+
+	p, err := l.clusterPath("t-tenant", "c-cluster")
+	if err != nil {
+		return nil, err
+	}
+	if path.Base(p) != "c-cluster" {
+		return nil, fmt.Errorf("the path template %q must store the token in a directory named after the cluster", cfg.PathTemplate)
+	}
+	sp, err := l.sharedPath("t-tenant")
+	if err != nil {
+		return nil, err
+	}
+	// No cluster may store its secrets in or above the shared path, including a cluster named like the shared directory
+	clusters := []string{"c-cluster", "shared"}
+	if len(validation.IsDNS1123Label(path.Base(sp))) == 0 {
+		clusters = append(clusters, path.Base(sp))
+	}
+	for _, cluster := range clusters {
+		cp, err := l.clusterPath("t-tenant", cluster)
+		if err != nil {
+			return nil, err
+		}
+		if overlaps(sp, cp) {
+			return nil, fmt.Errorf("the shared path template %q overlaps with the secrets of cluster %q at %q", cfg.SharedPathTemplate, cluster, cp)
+		}
+	}
+	return l, nil
+}
+
+// overlaps returns true if one of the paths is equal to or below the other
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// tokenPath returns the path of the Steward token of the cluster
+func (l *secretLayout) tokenPath(tenant, cluster string) (string, error) {
+	var b strings.Builder
+	if err := l.template.Execute(&b, pathTemplateData{Tenant: tenant, Cluster: cluster}); err != nil {
+		return "", fmt.Errorf("render path template: %w", err)
+	}
+	return path.Clean(strings.Trim(b.String(), "/")), nil
+}
+
+// clusterPath returns the path below which all secrets of the cluster are stored
+func (l *secretLayout) clusterPath(tenant, cluster string) (string, error) {
+	p, err := l.tokenPath(tenant, cluster)
+	if err != nil {
+		return "", err
+	}
+	return path.Dir(p), nil
+}
+
+// sharedPath returns the path below which the secrets shared by all clusters of the tenant are stored
+func (l *secretLayout) sharedPath(tenant string) (string, error) {
+	var b strings.Builder
+	if err := l.shared.Execute(&b, pathTemplateData{Tenant: tenant}); err != nil {
+		return "", fmt.Errorf("render shared path template: %w", err)
+	}
+	p := path.Clean(strings.Trim(b.String(), "/"))
+	if p == "." {
+		return "", fmt.Errorf("the shared path of tenant %q is empty", tenant)
+	}
+	return p, nil
+}
+
+// tokenSecret returns the secret of the Steward token of the cluster, tagged with the custom metadata
+func (l *secretLayout) tokenSecret(tenant, cluster, token string) (VaultSecret, error) {
+	p, err := l.tokenPath(tenant, cluster)
+	if err != nil {
+		return VaultSecret{}, err
+	}
+	return VaultSecret{Path: p, Key: l.keyName, Value: token, Metadata: l.metadata(tenant, cluster)}, nil
+}
+
+// metadata returns the custom metadata of the secrets of the cluster.
+// The cluster is omitted for the secrets of the tenant.
+func (l *secretLayout) metadata(tenant, cluster string) map[string]string {
+	metadata := map[string]string{
+		TenantMetadataKey: tenant,
+	}
+	if cluster != "" {
+		metadata[ClusterMetadataKey] = cluster
+	}
+	if l.operatorUID != "" {
+		metadata[OperatorUIDMetadataKey] = l.operatorUID
+	}
+	return metadata
+}
@@ -0,0 +1,99 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseLayout(t *testing.T) {
+	tests := map[string]struct {
+		cfg LayoutConfig
+
+		wantToken  string
+		wantShared string
+		wantSecret VaultSecret
+		wantErr    bool
+	}{
+		"default": {
+			wantToken:  "t-tenant/c-cluster/steward",
+			wantShared: "t-tenant/_shared",
+			wantSecret: VaultSecret{
+				Path:     "t-tenant/c-cluster/steward",
+				Key:      "token",
+				Value:    "secret",
+				Metadata: map[string]string{"tenant": "t-tenant", "cluster": "c-cluster"},
+			},
+		},
+		"prefixed": {
+			cfg:       LayoutConfig{PathTemplate: "/clusters/{{.Tenant}}/{{.Cluster}}/steward-token", KeyName: "jwt", OperatorUID: "1234"},
+			wantToken: "clusters/t-tenant/c-cluster/steward-token",
+			wantSecret: VaultSecret{
+				Path:     "clusters/t-tenant/c-cluster/steward-token",
+				Key:      "jwt",
+				Value:    "secret",
+				Metadata: map[string]string{"tenant": "t-tenant", "cluster": "c-cluster", "operator-uid": "1234"},
+			},
+		},
+		"not grouped by tenant": {
+			cfg:       LayoutConfig{PathTemplate: "clusters/{{.Cluster}}/steward"},
+			wantToken: "clusters/c-cluster/steward",
+		},
+		"no cluster directory": {
+			cfg:     LayoutConfig{PathTemplate: "{{.Tenant}}/{{.Cluster}}"},
+			wantErr: true,
+		},
+		"unknown field": {
+			cfg:     LayoutConfig{PathTemplate: "{{.Tenant}}/{{.Name}}/steward"},
+			wantErr: true,
+		},
+		"shared path of a cluster": {
+			cfg:     LayoutConfig{SharedPathTemplate: "{{.Tenant}}/shared"},
+			wantErr: true,
+		},
+		"shared path above clusters": {
+			cfg:     LayoutConfig{SharedPathTemplate: "{{.Tenant}}"},
+			wantErr: true,
+		},
+		"shared path of a custom layout": {
+			cfg:     LayoutConfig{PathTemplate: "clusters/{{.Cluster}}/steward", SharedPathTemplate: "clusters/{{.Tenant}}"},
+			wantErr: true,
+		},
+		"separate shared path": {
+			cfg:        LayoutConfig{PathTemplate: "clusters/{{.Cluster}}/steward", SharedPathTemplate: "shared/{{.Tenant}}"},
+			wantToken:  "clusters/c-cluster/steward",
+			wantShared: "shared/t-tenant",
+		},
+		"invalid template": {
+			cfg:     LayoutConfig{PathTemplate: "{{.Tenant}/{{.Cluster}}/steward"},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			l, err := parseLayout(tc.cfg)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			p, err := l.tokenPath("t-tenant", "c-cluster")
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantToken, p)
+
+			if tc.wantShared != "" {
+				p, err := l.sharedPath("t-tenant")
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantShared, p)
+			}
+
+			if tc.wantSecret.Path != "" {
+				s, err := l.tokenSecret("t-tenant", "c-cluster", "secret")
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantSecret, s)
+			}
+		})
+	}
+}
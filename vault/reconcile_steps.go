@@ -3,46 +3,277 @@ package vault
 import (
 	"fmt"
 	"path"
+	"slices"
 	"sort"
+	"time"
 
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/projectsyn/lieutenant-operator/collection"
 	"github.com/projectsyn/lieutenant-operator/pipeline"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// getVaultClient returns a client which removes secrets according to the deletion policy of the object.
 func getVaultClient(obj pipeline.Object, data *pipeline.Context) (VaultClient, error) {
-	deletionPolicy := data.DefaultDeletionPolicy
+	deletionPolicy, err := getDeletionPolicy(obj, data)
+	if err != nil {
+		return nil, err
+	}
 
 	return NewClient(deletionPolicy, data.Log)
 }
 
+// getDeletionPolicy returns the deletion policy of the object.
+// Clusters without a deletion policy use the deletion policy of the cluster template of their tenant.
+// The default deletion policy is used if neither is set.
+func getDeletionPolicy(obj pipeline.Object, data *pipeline.Context) (synv1alpha1.DeletionPolicy, error) {
+	if policy := obj.GetDeletionPolicy(); policy != "" {
+		return policy, nil
+	}
+
+	if _, ok := obj.(*synv1alpha1.Cluster); ok && data.Client != nil {
+		tenant := &synv1alpha1.Tenant{}
+		err := data.Client.Get(data.Context, types.NamespacedName{Name: obj.GetTenantRef().Name, Namespace: obj.GetNamespace()}, tenant)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("get tenant: %w", err)
+		}
+		if err == nil && tenant.Spec.ClusterTemplate != nil && tenant.Spec.ClusterTemplate.DeletionPolicy != "" {
+			return tenant.Spec.ClusterTemplate.DeletionPolicy, nil
+		}
+	}
+
+	return data.DefaultDeletionPolicy, nil
+}
+
 func CreateOrUpdateVault(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
 	if !data.UseVault {
 		return pipeline.Result{}
 	}
+	// The secrets of a decommissioned cluster are removed and must not be recreated
+	cluster, isCluster := obj.(*synv1alpha1.Cluster)
+	if isCluster && cluster.DecommissionRequested() {
+		return pipeline.Result{}
+	}
 
-	secretPath := path.Join(obj.GetTenantRef().Name, obj.GetName(), "steward")
+	vaultClient, err := getVaultClient(obj, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+	}
+	if isCluster && data.ServiceAccountTokenLifetime > 0 {
+		return updateBoundToken(cluster, vaultClient, data)
+	}
 
 	token, err := GetServiceAccountToken(obj, data)
 	if err != nil {
 		return pipeline.Result{Err: fmt.Errorf("get SA token: %w", err)}
 	}
 
-	vaultClient, err := getVaultClient(obj, data)
+	secret, err := layout.tokenSecret(obj.GetTenantRef().Name, obj.GetName(), token)
 	if err != nil {
-		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+		return pipeline.Result{Err: err}
+	}
+
+	err = vaultClient.AddSecrets([]VaultSecret{secret})
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("add vault secret '%s': %w", secret.Path, err)}
+	}
+	if isCluster {
+		cluster.Status.VaultSecretPath = secret.Path
+		cluster.Status.ServiceAccountTokenExpiresAt = nil
+	}
+
+	return pipeline.Result{}
+}
+
+// updateBoundToken writes a token with a bounded lifetime, requested with the TokenRequest API, to Vault.
+// The token is replaced once two thirds of its lifetime have passed.
+func updateBoundToken(cluster *synv1alpha1.Cluster, vaultClient VaultClient, data *pipeline.Context) pipeline.Result {
+	tenant := cluster.GetTenantRef().Name
+	tokenPath, err := layout.tokenPath(tenant, cluster.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+	renewWindow := data.ServiceAccountTokenLifetime / 3
+
+	if expiresAt := cluster.Status.ServiceAccountTokenExpiresAt; expiresAt != nil && cluster.Status.VaultSecretPath == tokenPath {
+		renewAt := expiresAt.Add(-renewWindow)
+		if time.Now().Before(renewAt) {
+			exists, err := vaultClient.HasSecret(tokenPath)
+			if err != nil {
+				return pipeline.Result{Err: fmt.Errorf("check vault secret '%s': %w", tokenPath, err)}
+			}
+			if exists {
+				return pipeline.Result{RequeueAfter: time.Until(renewAt)}
+			}
+		}
+	}
+
+	token, expiresAt, err := RequestServiceAccountToken(cluster, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("request SA token: %w", err)}
+	}
+	secret, err := layout.tokenSecret(tenant, cluster.GetName(), token)
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+	if err := vaultClient.AddSecrets([]VaultSecret{secret}); err != nil {
+		return pipeline.Result{Err: fmt.Errorf("add vault secret '%s': %w", secret.Path, err)}
+	}
+	cluster.Status.VaultSecretPath = secret.Path
+	cluster.Status.ServiceAccountTokenExpiresAt = &expiresAt
+	return pipeline.Result{RequeueAfter: time.Until(expiresAt.Add(-renewWindow))}
+}
+
+// RequestServiceAccountToken requests a token of the service account named after the object with the TokenRequest API.
+// The token is valid for the configured lifetime.
+func RequestServiceAccountToken(obj pipeline.Object, data *pipeline.Context) (string, metav1.Time, error) {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+	}
+	expirationSeconds := int64(data.ServiceAccountTokenLifetime.Seconds())
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	}
+	if err := data.Client.SubResource("token").Create(data.Context, sa, tokenRequest); err != nil {
+		return "", metav1.Time{}, err
+	}
+	return tokenRequest.Status.Token, tokenRequest.Status.ExpirationTimestamp, nil
+}
+
+// MigrateVaultSecrets moves the Vault secrets of a cluster to the path of the configured layout.
+// The secrets of clusters without a recorded path are expected at the path of the default layout.
+func MigrateVaultSecrets(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok || !data.UseVault || data.Deleted || cluster.DecommissionRequested() {
+		return pipeline.Result{}
 	}
 
-	err = vaultClient.AddSecrets([]VaultSecret{{Path: secretPath, Value: token}})
+	// The secrets of a cluster which is moved to another tenant are still stored below the old tenant
+	tenant := cluster.GetTenantRef().Name
+	if m := cluster.Status.TenantMigration; m != nil && !m.VaultSecretsMoved {
+		tenant = m.From
+	}
+
+	current, err := layout.tokenPath(tenant, cluster.GetName())
 	if err != nil {
-		return pipeline.Result{Err: fmt.Errorf("add vault secret '%s': %w", secretPath, err)}
+		return pipeline.Result{Err: err}
+	}
+	previous := cluster.Status.VaultSecretPath
+	if previous == "" {
+		previous, err = defaultLayout.tokenPath(tenant, cluster.GetName())
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
 	}
 
+	if from, to := path.Dir(previous), path.Dir(current); from != to {
+		vaultClient, err := getVaultClient(obj, data)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+		}
+		data.Log.Info("Migrating vault secrets to new layout", "from", from, "to", to)
+		if err := vaultClient.MoveSecrets(from, to); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("move vault secrets from '%s' to '%s': %w", from, to, err)}
+		}
+	}
+	cluster.Status.VaultSecretPath = current
 	return pipeline.Result{}
 }
 
+// MoveVaultSecrets moves the Vault secrets of a cluster which is moved to another tenant to the path of the new tenant.
+func MoveVaultSecrets(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok || data.Deleted {
+		return pipeline.Result{}
+	}
+	migration := cluster.Status.TenantMigration
+	if migration == nil || migration.VaultSecretsMoved {
+		return pipeline.Result{}
+	}
+
+	if data.UseVault {
+		vaultClient, err := getVaultClient(obj, data)
+		if err != nil {
+			return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+		}
+		from, err := layout.clusterPath(migration.From, obj.GetName())
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
+		to, err := layout.clusterPath(migration.To, obj.GetName())
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
+		// If the path template doesn't contain the tenant, the secrets stay where they are
+		if from != to {
+			if err := vaultClient.MoveSecrets(from, to); err != nil {
+				return pipeline.Result{Err: fmt.Errorf("move vault secrets from '%s' to '%s': %w", from, to, err)}
+			}
+		}
+	}
+
+	migration.VaultSecretsMoved = true
+	return pipeline.Result{}
+}
+
+// RemoveClusterSecrets removes all Vault secrets of the cluster.
+func RemoveClusterSecrets(cluster *synv1alpha1.Cluster, data *pipeline.Context) error {
+	if !data.UseVault {
+		return nil
+	}
+	vaultClient, err := getVaultClient(cluster, data)
+	if err != nil {
+		return fmt.Errorf("get vault client: %w", err)
+	}
+	secrets, err := clusterSecrets(cluster)
+	if err != nil {
+		return err
+	}
+	if err := vaultClient.RemoveSecrets(secrets); err != nil {
+		return fmt.Errorf("remove secrets: %w", err)
+	}
+	return nil
+}
+
+// clusterSecrets returns the paths below which secrets of the cluster might be stored.
+func clusterSecrets(obj pipeline.Object) ([]VaultSecret, error) {
+	p, err := layout.clusterPath(obj.GetTenantRef().Name, obj.GetName())
+	if err != nil {
+		return nil, err
+	}
+	secrets := []VaultSecret{{Path: p}}
+
+	cluster, ok := obj.(*synv1alpha1.Cluster)
+	if !ok {
+		return secrets, nil
+	}
+	// Secrets of a cluster which is moved to another tenant might still be stored below the old tenant
+	if m := cluster.Status.TenantMigration; m != nil && !m.VaultSecretsMoved {
+		p, err := layout.clusterPath(m.From, cluster.GetName())
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, VaultSecret{Path: p})
+	}
+	// Secrets which weren't migrated to the current layout yet
+	if recorded := cluster.Status.VaultSecretPath; recorded != "" {
+		p := path.Dir(recorded)
+		if !slices.ContainsFunc(secrets, func(s VaultSecret) bool { return s.Path == p }) {
+			secrets = append(secrets, VaultSecret{Path: p})
+		}
+	}
+	return secrets, nil
+}
+
 func GetServiceAccountToken(instance metav1.Object, data *pipeline.Context) (string, error) {
 	secrets := &corev1.SecretList{}
 
@@ -78,22 +309,41 @@ func HandleVaultDeletion(obj pipeline.Object, data *pipeline.Context) pipeline.R
 		return pipeline.Result{}
 	}
 
-	repoName := types.NamespacedName{
-		Name:      obj.GetTenantRef().Name,
-		Namespace: obj.GetNamespace(),
-	}
-
-	secretPath := path.Join(repoName.Name, obj.GetName(), "steward")
-
 	if data.Deleted {
 		vaultClient, err := getVaultClient(obj, data)
 		if err != nil {
 			return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
 		}
-		err = vaultClient.RemoveSecrets([]VaultSecret{{Path: path.Dir(secretPath), Value: ""}})
+		secrets, err := clusterSecrets(obj)
+		if err != nil {
+			return pipeline.Result{Err: err}
+		}
+		err = vaultClient.RemoveSecrets(secrets)
 		if err != nil {
 			return pipeline.Result{Err: fmt.Errorf("remove secret: %w", err)}
 		}
 	}
 	return pipeline.Result{}
 }
+
+// HandleTenantVaultDeletion removes the shared secrets of a deleted tenant according to the deletion policy of the tenant.
+// The secrets of the clusters of the tenant are left to the deletion of the clusters, which honors their own deletion policies.
+func HandleTenantVaultDeletion(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	if !data.UseVault || !data.Deleted {
+		return pipeline.Result{}
+	}
+
+	sharedPath, err := layout.sharedPath(obj.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+
+	vaultClient, err := getVaultClient(obj, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+	}
+	if err := vaultClient.RemoveSecrets([]VaultSecret{{Path: sharedPath}}); err != nil {
+		return pipeline.Result{Err: fmt.Errorf("remove secrets: %w", err)}
+	}
+	return pipeline.Result{}
+}
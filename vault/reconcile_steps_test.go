@@ -1,12 +1,16 @@
 package vault
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
 	"github.com/projectsyn/lieutenant-operator/pipeline"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -15,14 +19,43 @@ import (
 
 type testMockClient struct {
 	deletionPolicy synv1alpha1.DeletionPolicy
+	moved          [][2]string
+	removed        []VaultSecret
+	secrets        map[string]map[string]string
 }
 
-func (m *testMockClient) AddSecrets(_ []VaultSecret) error { return nil }
+func (m *testMockClient) AddSecrets(secrets []VaultSecret) error {
+	for _, s := range secrets {
+		if m.secrets == nil {
+			m.secrets = map[string]map[string]string{}
+		}
+		if m.secrets[s.Path] == nil {
+			m.secrets[s.Path] = map[string]string{}
+		}
+		m.secrets[s.Path][s.key()] = s.Value
+	}
+	return nil
+}
+
+func (m *testMockClient) RemoveSecrets(secrets []VaultSecret) error {
+	m.removed = append(m.removed, secrets...)
+	return nil
+}
+
+func (m *testMockClient) MoveSecrets(from, to string) error {
+	m.moved = append(m.moved, [2]string{from, to})
+	return nil
+}
 
-func (m *testMockClient) RemoveSecrets(_ []VaultSecret) error { return nil }
+func (m *testMockClient) HasSecret(p string) (bool, error) {
+	_, ok := m.secrets[p]
+	return ok, nil
+}
 
-func (m *testMockClient) SetDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) {
+// WithDeletionPolicy records the deletion policy and returns the mock itself, so the calls are recorded in one place
+func (m *testMockClient) WithDeletionPolicy(deletionPolicy synv1alpha1.DeletionPolicy) VaultClient {
 	m.deletionPolicy = deletionPolicy
+	return m
 }
 
 type args struct {
@@ -85,12 +118,14 @@ var handleVaultDeletionCases = map[string]struct {
 		want: synv1alpha1.ArchivePolicy,
 		args: args{
 			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-cluster", Namespace: "lieutenant"},
 				Spec: synv1alpha1.ClusterSpec{
 					DeletionPolicy: synv1alpha1.ArchivePolicy,
 				},
 			},
 			data: &pipeline.Context{
 				Deleted:               true,
+				UseVault:              true,
 				DefaultDeletionPolicy: synv1alpha1.ArchivePolicy,
 			},
 		},
@@ -99,16 +134,77 @@ var handleVaultDeletionCases = map[string]struct {
 		want: synv1alpha1.DeletePolicy,
 		args: args{
 			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-cluster", Namespace: "lieutenant"},
 				Spec: synv1alpha1.ClusterSpec{
 					DeletionPolicy: synv1alpha1.DeletePolicy,
 				},
 			},
 			data: &pipeline.Context{
 				Deleted:               true,
+				UseVault:              true,
 				DefaultDeletionPolicy: synv1alpha1.ArchivePolicy,
 			},
 		},
 	},
+	"retain overrides default": {
+		want: synv1alpha1.RetainPolicy,
+		args: args{
+			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-cluster", Namespace: "lieutenant"},
+				Spec: synv1alpha1.ClusterSpec{
+					DeletionPolicy: synv1alpha1.RetainPolicy,
+				},
+			},
+			data: &pipeline.Context{
+				Deleted:               true,
+				UseVault:              true,
+				DefaultDeletionPolicy: synv1alpha1.DeletePolicy,
+			},
+		},
+	},
+	"cluster template of tenant": {
+		want: synv1alpha1.RetainPolicy,
+		args: args{
+			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-cluster", Namespace: "lieutenant"},
+				Spec: synv1alpha1.ClusterSpec{
+					TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+				},
+			},
+			tenant: &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "t-tenant", Namespace: "lieutenant"},
+				Spec: synv1alpha1.TenantSpec{
+					ClusterTemplate: &synv1alpha1.ClusterSpec{
+						DeletionPolicy: synv1alpha1.RetainPolicy,
+					},
+				},
+			},
+			data: &pipeline.Context{
+				Deleted:               true,
+				UseVault:              true,
+				DefaultDeletionPolicy: synv1alpha1.DeletePolicy,
+			},
+		},
+	},
+	"default": {
+		want: synv1alpha1.DeletePolicy,
+		args: args{
+			cluster: &synv1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "c-cluster", Namespace: "lieutenant"},
+				Spec: synv1alpha1.ClusterSpec{
+					TenantRef: corev1.LocalObjectReference{Name: "t-tenant"},
+				},
+			},
+			tenant: &synv1alpha1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "t-tenant", Namespace: "lieutenant"},
+			},
+			data: &pipeline.Context{
+				Deleted:               true,
+				UseVault:              true,
+				DefaultDeletionPolicy: synv1alpha1.DeletePolicy,
+			},
+		},
+	},
 }
 
 func Test_handleVaultDeletion(t *testing.T) {
@@ -126,10 +222,180 @@ func Test_handleVaultDeletion(t *testing.T) {
 			require.NoError(t, synv1alpha1.AddToScheme(s))
 
 			s.AddKnownTypes(synv1alpha1.GroupVersion, objs...)
-			tt.args.data.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(tt.args.cluster).Build()
+			builder := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.args.cluster)
+			if tt.args.tenant != nil {
+				builder = builder.WithObjects(tt.args.tenant)
+			}
+			tt.args.data.Client = builder.Build()
+			tt.args.data.Log = zap.New()
 
 			got := HandleVaultDeletion(tt.args.cluster, tt.args.data)
 			assert.NoError(t, got.Err)
+			assert.Equal(t, tt.want, mockClient.deletionPolicy)
 		})
 	}
 }
+
+func Test_moveVaultSecrets(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Status.TenantMigration = &synv1alpha1.TenantMigration{From: "t-a", To: "t-b"}
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, MoveVaultSecrets(cluster, data).Err)
+	assert.True(t, cluster.Status.TenantMigration.VaultSecretsMoved)
+	assert.Equal(t, [][2]string{{"t-a/c-cluster", "t-b/c-cluster"}}, mockClient.moved)
+
+	require.NoError(t, MoveVaultSecrets(cluster, data).Err)
+	assert.Len(t, mockClient.moved, 1, "secrets must only be moved once")
+}
+
+func Test_moveVaultSecrets_withoutTenant(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+	defer func() { layout = defaultLayout }()
+	require.NoError(t, ConfigureLayout(LayoutConfig{PathTemplate: "clusters/{{.Cluster}}/steward"}))
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Status.TenantMigration = &synv1alpha1.TenantMigration{From: "t-a", To: "t-b"}
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, MoveVaultSecrets(cluster, data).Err)
+	assert.True(t, cluster.Status.TenantMigration.VaultSecretsMoved)
+	assert.Empty(t, mockClient.moved, "should not move secrets whose path doesn't depend on the tenant")
+	assert.Empty(t, mockClient.removed)
+}
+
+func Test_removeClusterSecrets(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Spec.TenantRef.Name = "t-b"
+	cluster.Spec.Decommission = true
+	cluster.Status.TenantMigration = &synv1alpha1.TenantMigration{From: "t-a", To: "t-b"}
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, RemoveClusterSecrets(cluster, data))
+	assert.Equal(t, []VaultSecret{{Path: "t-b/c-cluster"}, {Path: "t-a/c-cluster"}}, mockClient.removed)
+
+	require.NoError(t, CreateOrUpdateVault(cluster, data).Err, "should not recreate secrets of a decommissioned cluster")
+}
+
+func Test_handleTenantVaultDeletion(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	tenant := &synv1alpha1.Tenant{}
+	tenant.Name = "t-tenant"
+	data := &pipeline.Context{
+		Log:                   zap.New(),
+		UseVault:              true,
+		DefaultDeletionPolicy: synv1alpha1.ArchivePolicy,
+	}
+
+	require.NoError(t, HandleTenantVaultDeletion(tenant, data).Err)
+	assert.Empty(t, mockClient.removed, "should not remove secrets of a tenant which isn't deleted")
+
+	data.Deleted = true
+	require.NoError(t, HandleTenantVaultDeletion(tenant, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "t-tenant/_shared"}}, mockClient.removed, "should only remove the shared secrets, not the secrets of the clusters")
+	assert.Equal(t, synv1alpha1.ArchivePolicy, mockClient.deletionPolicy)
+
+	tenant.Spec.DeletionPolicy = synv1alpha1.DeletePolicy
+	require.NoError(t, HandleTenantVaultDeletion(tenant, data).Err)
+	assert.Equal(t, synv1alpha1.DeletePolicy, mockClient.deletionPolicy, "should honor the deletion policy of the tenant")
+
+	defer func() { layout = defaultLayout }()
+	require.NoError(t, ConfigureLayout(LayoutConfig{SharedPathTemplate: "shared/{{.Tenant}}"}))
+	mockClient.removed = nil
+	require.NoError(t, HandleTenantVaultDeletion(tenant, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "shared/t-tenant"}}, mockClient.removed, "should remove shared secrets outside of the tenant path")
+}
+
+func Test_migrateVaultSecrets(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+	defer func() { layout = defaultLayout }()
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Spec.TenantRef.Name = "t-tenant"
+	data := &pipeline.Context{
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, MigrateVaultSecrets(cluster, data).Err)
+	assert.Empty(t, mockClient.moved, "should not move secrets stored with the default layout")
+	assert.Equal(t, "t-tenant/c-cluster/steward", cluster.Status.VaultSecretPath)
+
+	require.NoError(t, ConfigureLayout(LayoutConfig{PathTemplate: "clusters/{{.Tenant}}/{{.Cluster}}/steward"}))
+	require.NoError(t, MigrateVaultSecrets(cluster, data).Err)
+	assert.Equal(t, [][2]string{{"t-tenant/c-cluster", "clusters/t-tenant/c-cluster"}}, mockClient.moved)
+	assert.Equal(t, "clusters/t-tenant/c-cluster/steward", cluster.Status.VaultSecretPath)
+
+	require.NoError(t, MigrateVaultSecrets(cluster, data).Err)
+	assert.Len(t, mockClient.moved, 1, "secrets must only be moved once")
+
+	data.Deleted = true
+	require.NoError(t, HandleVaultDeletion(cluster, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "clusters/t-tenant/c-cluster"}}, mockClient.removed)
+}
+
+func Test_createOrUpdateVault_boundToken(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	cluster := &synv1alpha1.Cluster{}
+	cluster.Name = "c-cluster"
+	cluster.Namespace = "lieutenant"
+	cluster.Spec.TenantRef.Name = "t-tenant"
+	sa := &corev1.ServiceAccount{}
+	sa.Name = cluster.Name
+	sa.Namespace = cluster.Namespace
+
+	data := &pipeline.Context{
+		Context:                     context.TODO(),
+		Client:                      fake.NewClientBuilder().WithObjects(sa).Build(),
+		Log:                         zap.New(),
+		UseVault:                    true,
+		ServiceAccountTokenLifetime: 24 * time.Hour,
+	}
+
+	res := CreateOrUpdateVault(cluster, data)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "fake-token", mockClient.secrets["t-tenant/c-cluster/steward"]["token"])
+	assert.Equal(t, "t-tenant/c-cluster/steward", cluster.Status.VaultSecretPath)
+	require.NotNil(t, cluster.Status.ServiceAccountTokenExpiresAt)
+	assert.Positive(t, res.RequeueAfter)
+
+	mockClient.secrets["t-tenant/c-cluster/steward"]["token"] = "current-token"
+	res = CreateOrUpdateVault(cluster, data)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "current-token", mockClient.secrets["t-tenant/c-cluster/steward"]["token"], "should not replace a valid token")
+	assert.Positive(t, res.RequeueAfter)
+
+	expiresAt := metav1.NewTime(time.Now().Add(time.Hour))
+	cluster.Status.ServiceAccountTokenExpiresAt = &expiresAt
+	require.NoError(t, CreateOrUpdateVault(cluster, data).Err)
+	assert.Equal(t, "fake-token", mockClient.secrets["t-tenant/c-cluster/steward"]["token"], "should replace a token which expires soon")
+
+	delete(mockClient.secrets, "t-tenant/c-cluster/steward")
+	require.NoError(t, CreateOrUpdateVault(cluster, data).Err)
+	assert.Equal(t, "fake-token", mockClient.secrets["t-tenant/c-cluster/steward"]["token"], "should rewrite a missing token")
+}
@@ -0,0 +1,104 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// SyncSharedSecrets mirrors the Secrets referenced by the shared secrets of a tenant into the shared Vault path of the tenant.
+// Secrets which were removed from the tenant and keys which were removed from a Secret are removed according to the deletion policy.
+// Only Secrets with the label SharedSecretLabel set to true are mirrored.
+func SyncSharedSecrets(obj pipeline.Object, data *pipeline.Context) pipeline.Result {
+	tenant, ok := obj.(*synv1alpha1.Tenant)
+	if !ok || !data.UseVault || data.Deleted {
+		return pipeline.Result{}
+	}
+	if len(tenant.Spec.SharedSecrets) == 0 && len(tenant.Status.SharedSecrets) == 0 {
+		return pipeline.Result{}
+	}
+
+	sharedPath, err := layout.sharedPath(tenant.GetName())
+	if err != nil {
+		return pipeline.Result{Err: err}
+	}
+	vaultClient, err := getVaultClient(obj, data)
+	if err != nil {
+		return pipeline.Result{Err: fmt.Errorf("get vault client: %w", err)}
+	}
+	metadata := layout.metadata(tenant.GetName(), "")
+
+	synced := make([]synv1alpha1.SharedSecretStatus, 0, len(tenant.Spec.SharedSecrets))
+	for _, shared := range tenant.Spec.SharedSecrets {
+		key := types.NamespacedName{Name: shared.SecretRef.Name, Namespace: tenant.GetNamespace()}
+		// Check the label on the metadata, so the content of Secrets which didn't opt in is never read
+		secretMeta := &metav1.PartialObjectMetadata{}
+		secretMeta.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+		if err := data.Client.Get(data.Context, key, secretMeta); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("get secret '%s' of shared secret '%s': %w", key.Name, shared.Name, err)}
+		}
+		if secretMeta.GetLabels()[synv1alpha1.SharedSecretLabel] != "true" {
+			return pipeline.Result{Err: fmt.Errorf("secret '%s' of shared secret '%s' isn't labeled with %s=true", key.Name, shared.Name, synv1alpha1.SharedSecretLabel)}
+		}
+
+		secret := &corev1.Secret{}
+		if err := data.Client.Get(data.Context, key, secret); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("get secret '%s' of shared secret '%s': %w", key.Name, shared.Name, err)}
+		}
+
+		keys := make([]string, 0, len(secret.Data))
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		secretPath := path.Join(sharedPath, shared.Name)
+		if previous := findSharedSecret(tenant.Status.SharedSecrets, shared.Name); previous != nil &&
+			slices.ContainsFunc(previous.Keys, func(k string) bool { return !slices.Contains(keys, k) }) {
+			// The secret is written anew, since keys can't be removed individually
+			if err := vaultClient.RemoveSecrets([]VaultSecret{{Path: secretPath}}); err != nil {
+				return pipeline.Result{Err: fmt.Errorf("remove shared secret '%s': %w", secretPath, err)}
+			}
+		}
+
+		vaultSecrets := make([]VaultSecret, 0, len(keys))
+		for _, k := range keys {
+			vaultSecrets = append(vaultSecrets, VaultSecret{Path: secretPath, Key: k, Value: string(secret.Data[k]), Metadata: metadata})
+		}
+		if err := vaultClient.AddSecrets(vaultSecrets); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("add shared secret '%s': %w", secretPath, err)}
+		}
+		synced = append(synced, synv1alpha1.SharedSecretStatus{Name: shared.Name, Keys: keys})
+	}
+
+	removed := []VaultSecret{}
+	for _, s := range tenant.Status.SharedSecrets {
+		if !slices.ContainsFunc(tenant.Spec.SharedSecrets, func(shared synv1alpha1.SharedSecret) bool { return shared.Name == s.Name }) {
+			removed = append(removed, VaultSecret{Path: path.Join(sharedPath, s.Name)})
+		}
+	}
+	if len(removed) > 0 {
+		if err := vaultClient.RemoveSecrets(removed); err != nil {
+			return pipeline.Result{Err: fmt.Errorf("remove shared secrets: %w", err)}
+		}
+	}
+
+	tenant.Status.SharedSecrets = synced
+	return pipeline.Result{}
+}
+
+func findSharedSecret(secrets []synv1alpha1.SharedSecretStatus, name string) *synv1alpha1.SharedSecretStatus {
+	for i := range secrets {
+		if secrets[i].Name == name {
+			return &secrets[i]
+		}
+	}
+	return nil
+}
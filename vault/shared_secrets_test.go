@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	synv1alpha1 "github.com/projectsyn/lieutenant-operator/api/v1alpha1"
+	"github.com/projectsyn/lieutenant-operator/pipeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+func Test_syncSharedSecrets(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	secret := &corev1.Secret{}
+	secret.Name = "registry-credentials"
+	secret.Namespace = "lieutenant"
+	secret.Labels = map[string]string{synv1alpha1.SharedSecretLabel: "true"}
+	secret.Data = map[string][]byte{
+		"username": []byte("user"),
+		"password": []byte("secret"),
+	}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	tenant := &synv1alpha1.Tenant{}
+	tenant.Name = "t-tenant"
+	tenant.Namespace = "lieutenant"
+	tenant.Spec.SharedSecrets = []synv1alpha1.SharedSecret{{
+		Name:      "registry",
+		SecretRef: corev1.LocalObjectReference{Name: "registry-credentials"},
+	}}
+	data := &pipeline.Context{
+		Context:  context.TODO(),
+		Client:   c,
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	require.NoError(t, SyncSharedSecrets(tenant, data).Err)
+	assert.Equal(t, map[string]string{"username": "user", "password": "secret"}, mockClient.secrets["t-tenant/_shared/registry"])
+	assert.Equal(t, []synv1alpha1.SharedSecretStatus{{Name: "registry", Keys: []string{"password", "username"}}}, tenant.Status.SharedSecrets)
+	assert.Empty(t, mockClient.removed)
+
+	secret.Data = map[string][]byte{"token": []byte("token")}
+	require.NoError(t, c.Update(context.TODO(), secret))
+	require.NoError(t, SyncSharedSecrets(tenant, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "t-tenant/_shared/registry"}}, mockClient.removed, "should write the secret anew if keys were removed")
+	assert.Equal(t, []synv1alpha1.SharedSecretStatus{{Name: "registry", Keys: []string{"token"}}}, tenant.Status.SharedSecrets)
+
+	mockClient.removed = nil
+	tenant.Spec.SharedSecrets = nil
+	require.NoError(t, SyncSharedSecrets(tenant, data).Err)
+	assert.Equal(t, []VaultSecret{{Path: "t-tenant/_shared/registry"}}, mockClient.removed, "should remove secrets which were removed from the tenant")
+	assert.Empty(t, tenant.Status.SharedSecrets)
+
+	tenant.Spec.SharedSecrets = []synv1alpha1.SharedSecret{{
+		Name:      "missing",
+		SecretRef: corev1.LocalObjectReference{Name: "missing"},
+	}}
+	assert.Error(t, SyncSharedSecrets(tenant, data).Err, "should fail if the secret doesn't exist")
+}
+
+func Test_syncSharedSecrets_notLabeled(t *testing.T) {
+	mockClient := &testMockClient{}
+	SetCustomClient(mockClient)
+
+	secret := &corev1.Secret{}
+	secret.Name = "admin-credentials"
+	secret.Namespace = "lieutenant"
+	secret.Data = map[string][]byte{"password": []byte("secret")}
+	c := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	tenant := &synv1alpha1.Tenant{}
+	tenant.Name = "t-tenant"
+	tenant.Namespace = "lieutenant"
+	tenant.Spec.SharedSecrets = []synv1alpha1.SharedSecret{{
+		Name:      "admin",
+		SecretRef: corev1.LocalObjectReference{Name: "admin-credentials"},
+	}}
+	data := &pipeline.Context{
+		Context:  context.TODO(),
+		Client:   c,
+		Log:      zap.New(),
+		UseVault: true,
+	}
+
+	assert.ErrorContains(t, SyncSharedSecrets(tenant, data).Err, "isn't labeled with lieutenant.syn.tools/shared-secret=true")
+	assert.Empty(t, mockClient.secrets, "should not mirror a secret without the label")
+	assert.Empty(t, tenant.Status.SharedSecrets)
+}